@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/benmyles/clippycli/clippy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxCount caps --count, since each extra candidate costs tokens and screen
+// space
+const maxCount = 10
+
+// listMarker matches numbering or bullets the model adds despite being asked
+// not to
+var listMarker = regexp.MustCompile(`^(\d+[.)]|[-*])\s+`)
+
+// alternativesFormat asks for count different commands instead of one
+func alternativesFormat(count int) string {
+	return fmt.Sprintf("Give %d different commands that each accomplish the goal, best first, one per line with nothing else: no numbering, bullets, or explanations. Each must fit on one line, so join any steps with &&.", count)
+}
+
+// parseAlternatives splits a finished reply into its alternatives, one
+// command per line
+func parseAlternatives(reply string) []string {
+	var alts []string
+	for _, line := range strings.Split(reply, "\n") {
+		line = clippy.SanitizeCommand(listMarker.ReplaceAllString(strings.TrimSpace(line), ""))
+		if line != "" {
+			alts = append(alts, line)
+		}
+	}
+	return alts
+}
+
+// completedAlternatives returns the alternatives in a reply that's still
+// streaming, leaving out the last line until it's finished, so each option
+// is revealed once it's complete
+func completedAlternatives(text string) []string {
+	i := strings.LastIndexByte(text, '\n')
+	if i < 0 {
+		return nil
+	}
+	return parseAlternatives(text[:i])
+}
+
+// selectCommand makes the i-th alternative the current command, refreshing
+// what was worked out from the previous one
+func (m model) selectCommand(i int) (model, tea.Cmd) {
+	m.selectedCmd = i
+	m.generatedCmd = m.generatedCmds[i]
+	m.originalCmd = m.generatedCmd
+	m.missingTools = missingTools(m.generatedCmd)
+	m.critique = ""
+	m.critiqueErr = nil
+	return m.startExplanation()
+}
+
+// alternativesView lists the alternatives with the selected one marked
+func (m model) alternativesView() string {
+	var content strings.Builder
+	content.WriteString(promptStyle.Render("Choose a command:"))
+	content.WriteString("\n")
+	for i, alt := range m.generatedCmds {
+		cursor := "  "
+		if i == m.selectedCmd {
+			cursor = "> "
+		}
+		line := fmt.Sprintf("%s%d. %s", cursor, i+1, alt)
+		if i == m.selectedCmd {
+			content.WriteString(promptStyle.Render(line))
+		} else {
+			content.WriteString(line)
+		}
+		content.WriteString("\n")
+	}
+	return content.String()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCompletedAlternatives(t *testing.T) {
+	// Only alternatives whose line has been completed are revealed
+	steps := []struct {
+		text     string
+		expected int
+	}{
+		{"ls -l", 0},
+		{"ls -la\nfind . -na", 1},
+		{"ls -la\nfind . -name \"*.go\"\n", 2},
+		{"ls -la\nfind . -name \"*.go\"\n\n", 2},
+		{"ls -la\nfind . -name \"*.go\"\n\ndu -sh *", 2},
+	}
+	for _, step := range steps {
+		if alts := completedAlternatives(step.text); len(alts) != step.expected {
+			t.Errorf("After %q expected %d alternatives, got %d: %v", step.text, step.expected, len(alts), alts)
+		}
+	}
+
+	// The finished reply includes the trailing line
+	alts := parseAlternatives(steps[len(steps)-1].text)
+	expected := []string{"ls -la", `find . -name "*.go"`, "du -sh *"}
+	if strings.Join(alts, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %q, got %q", expected, alts)
+	}
+}
+
+func TestAlternativesRevealedWhileLoading(t *testing.T) {
+	testModel := initialModel("list files", false)
+	testModel.opts.count = 3
+
+	// The streamed text arrives whole each time, ending partway through a line
+	updatedModel, _ := testModel.Update(cmdStreamChunkMsg{text: "ls\nls -l"})
+	updatedModel, _ = updatedModel.Update(cmdStreamChunkMsg{text: "ls\nls -la\nfind"})
+
+	m, ok := updatedModel.(model)
+	if !ok {
+		t.Fatal("Expected updatedModel to be of type model")
+	}
+
+	if m.state != stateLoading {
+		t.Errorf("Expected state to remain stateLoading, got %v", m.state)
+	}
+
+	if len(m.alternatives) != 2 {
+		t.Fatalf("Expected 2 alternatives, got %d", len(m.alternatives))
+	}
+
+	view := m.View()
+	if !strings.Contains(view, "ls -la") {
+		t.Error("Expected loading view to show the revealed alternatives")
+	}
+}
+
+func TestParseAlternativesStripsNumbering(t *testing.T) {
+	alts := parseAlternatives("1. ls -la\n2) ls -A\n- find . -maxdepth 1\n\n")
+	expected := []string{"ls -la", "ls -A", "find . -maxdepth 1"}
+	if strings.Join(alts, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %q, got %q", expected, alts)
+	}
+}
+
+func TestParseArgsCount(t *testing.T) {
+	opts, err := parseArgs([]string{"--count", "3", "list files"})
+	if err != nil || opts.count != 3 {
+		t.Fatalf("Expected count 3, got %d (err %v)", opts.count, err)
+	}
+	for _, args := range [][]string{{"--count", "0"}, {"--count", "11"}, {"--count", "3", "--with-undo"}, {"--count", "2", "--as-script"}} {
+		if _, err := parseArgs(args); err == nil {
+			t.Errorf("Expected an error for %v", args)
+		}
+	}
+}
+
+func TestChooseAlternative(t *testing.T) {
+	stubClipboard(t)
+	m := initialModel("list files", false)
+	m.opts.count = 3
+	if !strings.Contains(m.systemPrompt(""), "Give 3 different commands") {
+		t.Error("Expected the system prompt to ask for 3 commands")
+	}
+
+	m.generator = fakeGenerator{text: "ls -la\nls -A\nfind . -maxdepth 1"}
+	updated, _ := m.Update(m.generateCommand()())
+	m = updated.(model)
+	if len(m.generatedCmds) != 3 || m.generatedCmd != "ls -la" {
+		t.Fatalf("Expected 3 alternatives with the first selected, got %q and %q", m.generatedCmds, m.generatedCmd)
+	}
+
+	// Up wraps around to the last one
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m = updated.(model)
+	if m.selectedCmd != 2 || m.generatedCmd != "find . -maxdepth 1" {
+		t.Errorf("Expected the last alternative to be selected, got %d: %q", m.selectedCmd, m.generatedCmd)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = updated.(model)
+	if m.generatedCmd != "ls -A" {
+		t.Errorf("Expected the second alternative to be selected, got %q", m.generatedCmd)
+	}
+	if !strings.Contains(m.View(), "> 2. ls -A") {
+		t.Error("Expected the view to mark the selected alternative")
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if msg, ok := cmd().(cmdCopiedMsg); !ok || msg.cmd != "ls -A" {
+		t.Errorf("Expected the selected alternative to be copied, got %#v", msg)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// auditEntry records a user bypassing a safety warning
+type auditEntry struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command,omitempty"`
+	Prompt  string    `json:"prompt,omitempty"`
+	Reason  string    `json:"reason"`
+}
+
+// appendAuditEntry appends entry as a JSON line to the audit log at path
+func appendAuditEntry(path string, entry auditEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// audit records an override in the audit log, if one is configured. Failures
+// never block the user but are kept on the model so they can be surfaced.
+func (m model) audit(reason, command string) model {
+	if m.opts.auditLog == "" {
+		return m
+	}
+
+	m.auditErr = appendAuditEntry(m.opts.auditLog, auditEntry{
+		Time:    time.Now().UTC(),
+		Command: command,
+		Prompt:  m.prompt,
+		Reason:  reason,
+	})
+	return m
+}
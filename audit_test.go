@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestOverrideWritesAuditEntry(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "logs", "audit.jsonl")
+
+	testModel := initialModel("list files", false)
+	testModel.opts.auditLog = logPath
+
+	// Forcing past the injection warning is an override and should be logged
+	updatedModel, _ := testModel.Update(injectionWarningMsg{})
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+
+	m := updatedModel.(model)
+	if m.auditErr != nil {
+		t.Fatalf("Expected audit entry to be written, got error: %v", m.auditErr)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 audit entry, got %d", len(lines))
+	}
+
+	var entry auditEntry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Failed to parse audit entry: %v", err)
+	}
+
+	if entry.Time.IsZero() {
+		t.Error("Expected audit entry to have a timestamp")
+	}
+	if entry.Prompt != "list files" {
+		t.Errorf("Expected audit prompt %q, got %q", "list files", entry.Prompt)
+	}
+	if entry.Reason == "" {
+		t.Error("Expected audit entry to have a reason")
+	}
+}
+
+func TestAuditFailureIsSurfaced(t *testing.T) {
+	// A path under a regular file can't be created
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	testModel := initialModel("list files", false)
+	testModel.opts.auditLog = filepath.Join(file, "audit.jsonl")
+
+	m := testModel.audit("test override", "rm -rf build")
+	if m.auditErr == nil {
+		t.Fatal("Expected an audit error to be recorded")
+	}
+	if !strings.Contains(m.View(), "could not write audit log") {
+		t.Error("Expected the view to surface the audit failure")
+	}
+}
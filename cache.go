@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// replyCacheTTL is how long a reply is reused for an identical request
+const replyCacheTTL = 24 * time.Hour
+
+// cachedReply is a model's reply kept for reuse, one file per request
+type cachedReply struct {
+	Time  time.Time `json:"time"`
+	Reply string    `json:"reply"`
+}
+
+// readsCache reports whether a generation may be answered from a previously
+// cached reply instead of calling the API
+func (o options) readsCache() bool {
+	return !o.alwaysFresh && !o.noCache
+}
+
+// writesCache reports whether a fresh generation may be stored for reuse.
+// --always-fresh suppresses writes too, so comparing outputs across runs
+// never leaves results behind that a later run could pick up
+func (o options) writesCache() bool {
+	return !o.alwaysFresh
+}
+
+// defaultReplyCachePath returns the directory replies are cached in
+func defaultReplyCachePath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "replies"), nil
+}
+
+// replyCacheKey identifies a request by everything that shapes the reply:
+// the model it goes to and how it samples, the system prompt with the
+// environment info in it, and what the user asked for
+func replyCacheKey(provider, model string, temperature *float64, maxTokens int, systemPrompt, prompt string) string {
+	sampling := "default"
+	if temperature != nil {
+		sampling = strconv.FormatFloat(*temperature, 'g', -1, 64)
+	}
+	h := sha256.New()
+	for _, part := range []string{provider, model, sampling, strconv.Itoa(maxTokens), systemPrompt, prompt} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// readCachedReply returns the reply cached in dir under key, if there's one
+// younger than ttl. Expired replies are removed as they're found.
+func readCachedReply(dir, key string, now time.Time, ttl time.Duration) (string, bool) {
+	path := filepath.Join(dir, key+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	var entry cachedReply
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Reply == "" {
+		return "", false
+	}
+	if now.Sub(entry.Time) > ttl || entry.Time.After(now) {
+		os.Remove(path)
+		return "", false
+	}
+	return entry.Reply, true
+}
+
+// writeCachedReply caches reply in dir under key. Prompts can hold anything
+// the user typed, so the cache is private to them.
+func writeCachedReply(dir, key string, entry cachedReply) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	// Written aside and renamed, so a concurrent run never reads half a reply
+	tmp, err := os.CreateTemp(dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, key+".json"))
+}
+
+// cachedGeneration answers the request from the cache, if it may be and an
+// identical one was made recently
+func (m model) cachedGeneration(key, fullPrompt string) (cmdGeneratedMsg, bool) {
+	if m.replyCachePath == "" || !m.opts.readsCache() {
+		return cmdGeneratedMsg{}, false
+	}
+	reply, ok := readCachedReply(m.replyCachePath, key, time.Now(), replyCacheTTL)
+	if !ok {
+		return cmdGeneratedMsg{}, false
+	}
+	msg := m.finishGeneration(reply, fullPrompt)
+	if msg.err != nil {
+		return cmdGeneratedMsg{}, false
+	}
+	msg.cached = true
+	return msg, true
+}
+
+// cacheReply keeps a reply that produced a command for identical requests.
+// A failed write only costs a later API call, so errors are ignored.
+func (m model) cacheReply(key, reply string) {
+	if m.replyCachePath == "" || !m.opts.writesCache() {
+		return
+	}
+	_ = writeCachedReply(m.replyCachePath, key, cachedReply{Time: time.Now().UTC(), Reply: reply})
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benmyles/clippycli/clippy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAlwaysFreshSkipsCache(t *testing.T) {
+	opts, err := parseArgs([]string{"list", "files"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !opts.readsCache() || !opts.writesCache() {
+		t.Error("Expected the cache to be used by default")
+	}
+
+	opts, err = parseArgs([]string{"--always-fresh", "list", "files"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if opts.readsCache() {
+		t.Error("Expected --always-fresh to skip cache reads")
+	}
+	if opts.writesCache() {
+		t.Error("Expected --always-fresh to skip cache writes")
+	}
+	if opts.prompt != "list files" {
+		t.Errorf("Expected prompt 'list files', got %q", opts.prompt)
+	}
+}
+
+func TestNoCacheStillRefreshes(t *testing.T) {
+	opts, err := parseArgs([]string{"--no-cache", "list", "files"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if opts.readsCache() {
+		t.Error("Expected --no-cache to skip cache reads")
+	}
+	if !opts.writesCache() {
+		t.Error("Expected --no-cache to still cache the fresh reply")
+	}
+}
+
+func TestReplyCacheHitAndMiss(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	key := replyCacheKey("anthropic", "claude-sonnet-4-20250514", nil, 0, "system", "list files")
+
+	if _, ok := readCachedReply(dir, key, now, replyCacheTTL); ok {
+		t.Error("Expected a miss before anything was cached")
+	}
+	if err := writeCachedReply(dir, key, cachedReply{Time: now, Reply: "ls -la"}); err != nil {
+		t.Fatalf("writeCachedReply failed: %v", err)
+	}
+	if reply, ok := readCachedReply(dir, key, now.Add(time.Minute), replyCacheTTL); !ok || reply != "ls -la" {
+		t.Errorf("Expected a hit for the same request, got %q (hit %v)", reply, ok)
+	}
+
+	// Anything that changes the reply makes a different request
+	hot := 0.9
+	for _, other := range []string{
+		replyCacheKey("openai", "claude-sonnet-4-20250514", nil, 0, "system", "list files"),
+		replyCacheKey("anthropic", "claude-opus-4-20250514", nil, 0, "system", "list files"),
+		replyCacheKey("anthropic", "claude-sonnet-4-20250514", nil, 0, "system\nShell: fish", "list files"),
+		replyCacheKey("anthropic", "claude-sonnet-4-20250514", nil, 0, "system", "list all files"),
+		replyCacheKey("anthropic", "claude-sonnet-4-20250514", nil, 0, "systemlist", " files"),
+		replyCacheKey("anthropic", "claude-sonnet-4-20250514", &hot, 0, "system", "list files"),
+		replyCacheKey("anthropic", "claude-sonnet-4-20250514", nil, 512, "system", "list files"),
+	} {
+		if _, ok := readCachedReply(dir, other, now, replyCacheTTL); ok {
+			t.Errorf("Expected a miss for key %s", other)
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(dir, key+".json"))
+	if err != nil {
+		t.Fatalf("Expected the reply to be cached as a file: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		t.Errorf("Expected the cached reply to be private, got %v", info.Mode().Perm())
+	}
+}
+
+func TestReplyCacheExpires(t *testing.T) {
+	dir := t.TempDir()
+	saved := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	key := replyCacheKey("anthropic", "haiku", nil, 0, "system", "list files")
+	if err := writeCachedReply(dir, key, cachedReply{Time: saved, Reply: "ls"}); err != nil {
+		t.Fatalf("writeCachedReply failed: %v", err)
+	}
+
+	if _, ok := readCachedReply(dir, key, saved.Add(replyCacheTTL-time.Second), replyCacheTTL); !ok {
+		t.Error("Expected a hit just inside the TTL")
+	}
+	if _, ok := readCachedReply(dir, key, saved.Add(replyCacheTTL+time.Second), replyCacheTTL); ok {
+		t.Error("Expected a miss once the TTL has passed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, key+".json")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Expected the expired reply to be removed")
+	}
+}
+
+func TestGenerationUsesReplyCache(t *testing.T) {
+	m := initialModel("list files by date", false)
+	m.replyCachePath = t.TempDir()
+	m.generator = fakeGenerator{text: "ls -lt", inputTokens: 100, outputTokens: 5}
+
+	if msg := m.runGeneration().(cmdGeneratedMsg); msg.cached || msg.cmd != "ls -lt" {
+		t.Fatalf("Expected the first request to call the API, got %+v", msg)
+	}
+
+	// A hit never reaches the API
+	m.generator = fakeGenerator{err: errors.New("API called")}
+	msg := m.runGeneration().(cmdGeneratedMsg)
+	if !msg.cached || msg.err != nil || msg.cmd != "ls -lt" || msg.inputTokens != 0 {
+		t.Fatalf("Expected the cached command without any tokens, got %+v", msg)
+	}
+	updated, _ := m.Update(msg)
+	if view := updated.(model).View(); !strings.Contains(view, "(cached") {
+		t.Errorf("Expected the result to say it was cached, got %q", view)
+	}
+
+	// --no-cache asks again, and the fresh reply replaces the cached one
+	m.opts.noCache = true
+	m.generator = fakeGenerator{text: "ls -ltr"}
+	if msg := m.runGeneration().(cmdGeneratedMsg); msg.cached || msg.cmd != "ls -ltr" {
+		t.Errorf("Expected --no-cache to call the API, got %+v", msg)
+	}
+	m.opts.noCache = false
+	m.generator = fakeGenerator{err: errors.New("API called")}
+	if msg := m.runGeneration().(cmdGeneratedMsg); msg.cmd != "ls -ltr" {
+		t.Errorf("Expected the refreshed reply to be cached, got %+v", msg)
+	}
+
+	m.opts.alwaysFresh = true
+	if msg := m.runGeneration().(cmdGeneratedMsg); msg.err == nil {
+		t.Error("Expected --always-fresh to call the API")
+	}
+
+	// Nor does its reply replace the cached one
+	m.generator = fakeGenerator{text: "ls -1t"}
+	if msg := m.runGeneration().(cmdGeneratedMsg); msg.cached || msg.cmd != "ls -1t" {
+		t.Fatalf("Expected --always-fresh to use the fresh reply, got %+v", msg)
+	}
+	m.opts.alwaysFresh = false
+	m.generator = fakeGenerator{err: errors.New("API called")}
+	if msg := m.runGeneration().(cmdGeneratedMsg); msg.cmd != "ls -ltr" {
+		t.Errorf("Expected the cached reply to be left alone by --always-fresh, got %+v", msg)
+	}
+}
+
+func TestAlwaysFreshWritesNothingToCache(t *testing.T) {
+	m := initialModel("list files by date", false)
+	m.replyCachePath = t.TempDir()
+	m.opts.alwaysFresh = true
+	m.generator = fakeGenerator{text: "ls -lt"}
+
+	if msg := m.runGeneration().(cmdGeneratedMsg); msg.err != nil || msg.cmd != "ls -lt" {
+		t.Fatalf("Expected a successful generation, got %+v", msg)
+	}
+	if entries, _ := os.ReadDir(m.replyCachePath); len(entries) != 0 {
+		t.Errorf("Expected --always-fresh to leave the cache empty, got %d entries", len(entries))
+	}
+}
+
+func TestTruncatedReplyIsNotCached(t *testing.T) {
+	m := initialModel("archive the logs", false)
+	m.replyCachePath = t.TempDir()
+	m.generator = fakeGenerator{text: "tar czf logs.tgz", stopReason: clippy.StopMaxTokens}
+	m.runGeneration()
+
+	if entries, _ := os.ReadDir(m.replyCachePath); len(entries) != 0 {
+		t.Errorf("Expected a cut-off reply not to be cached, got %d entries", len(entries))
+	}
+}
+
+func TestRegenerateSkipsReplyCache(t *testing.T) {
+	m := initialModel("list files by date", false)
+	m.replyCachePath = t.TempDir()
+	m.generator = fakeGenerator{text: "ls -lt"}
+	updated, _ := m.Update(m.runGeneration())
+
+	m = updated.(model)
+	m.generator = fakeGenerator{text: "ls -ltr"}
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	for _, msg := range collectMsgs(cmd) {
+		if generated, ok := msg.(cmdGeneratedMsg); ok {
+			updated, _ = updated.Update(generated)
+		}
+	}
+	if m = updated.(model); m.generatedCmd != "ls -ltr" || m.cached {
+		t.Errorf("Expected r to ask for a fresh command, got %q (cached %v)", m.generatedCmd, m.cached)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// clipboardTargetNames are the selections --clipboard-targets can write to
+var clipboardTargetNames = []string{"clipboard", "primary"}
+
+// clipboardLookPath finds clipboard tools on the PATH; replaced in tests
+var clipboardLookPath = exec.LookPath
+
+// runClipboardCmd runs a clipboard tool; replaced in tests
+var runClipboardCmd = func(cmd *exec.Cmd) error {
+	return cmd.Run()
+}
+
+// parseClipboardTargets validates a comma-separated list of targets
+func parseClipboardTargets(value string) ([]string, error) {
+	var targets []string
+	for _, target := range strings.Split(value, ",") {
+		target = strings.TrimSpace(target)
+		if target == "" {
+			continue
+		}
+		valid := false
+		for _, name := range clipboardTargetNames {
+			valid = valid || target == name
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown clipboard target %q (expected %s)", target, strings.Join(clipboardTargetNames, " or "))
+		}
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("--clipboard-targets needs at least one target")
+	}
+	return targets, nil
+}
+
+// clipboardTargetArgs returns the command line that copies stdin to target,
+// preferring wl-copy under Wayland, or nil if no suitable tool is installed
+func clipboardTargetArgs(target string, wayland bool) []string {
+	var candidates [][]string
+	if wayland {
+		if target == "primary" {
+			candidates = append(candidates, []string{"wl-copy", "--primary"})
+		} else {
+			candidates = append(candidates, []string{"wl-copy"})
+		}
+	}
+	candidates = append(candidates,
+		[]string{"xclip", "-selection", target},
+		[]string{"xsel", "--" + target, "--input"},
+	)
+
+	for _, args := range candidates {
+		if _, err := clipboardLookPath(args[0]); err == nil {
+			return args
+		}
+	}
+	return nil
+}
+
+// copyToTargets writes text to each requested target, returning the targets
+// that succeeded alongside any failures. The primary selection only exists on
+// X11 and Wayland, so elsewhere the regular clipboard is all there is.
+func copyToTargets(goos, text string, targets []string) ([]string, error) {
+	wayland := os.Getenv("WAYLAND_DISPLAY") != ""
+
+	var copied []string
+	var errs []error
+	for _, target := range targets {
+		var err error
+		switch {
+		case goos == "darwin" || goos == "windows":
+			if target == "primary" {
+				err = fmt.Errorf("primary selection is not supported on %s", goos)
+			} else {
+				err = clipboardWriteAll(text)
+			}
+		default:
+			if args := clipboardTargetArgs(target, wayland); args == nil {
+				err = errors.New("no clipboard tool found (install wl-copy, xclip, or xsel)")
+			} else {
+				cmd := exec.Command(args[0], args[1:]...)
+				cmd.Stdin = strings.NewReader(text)
+				err = runClipboardCmd(cmd)
+			}
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", target, err))
+		} else {
+			copied = append(copied, target)
+		}
+	}
+	return copied, errors.Join(errs...)
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stubClipboardTools pretends only the given tools are installed and records
+// each clipboard command run, along with what it was given on stdin
+func stubClipboardTools(t *testing.T, installed ...string) *[][]string {
+	t.Helper()
+	origLook, origRun := clipboardLookPath, runClipboardCmd
+	t.Cleanup(func() { clipboardLookPath, runClipboardCmd = origLook, origRun })
+
+	clipboardLookPath = func(name string) (string, error) {
+		for _, tool := range installed {
+			if tool == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+
+	var ran [][]string
+	runClipboardCmd = func(cmd *exec.Cmd) error {
+		input, _ := io.ReadAll(cmd.Stdin)
+		ran = append(ran, append(append([]string{}, cmd.Args...), string(input)))
+		return nil
+	}
+	return &ran
+}
+
+func TestCopyToTargetsX11(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "")
+	ran := stubClipboardTools(t, "xclip")
+
+	copied, err := copyToTargets("linux", "ls -la", []string{"primary", "clipboard"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(copied, []string{"primary", "clipboard"}) {
+		t.Errorf("Expected both targets to be copied, got %v", copied)
+	}
+
+	expected := [][]string{
+		{"xclip", "-selection", "primary", "ls -la"},
+		{"xclip", "-selection", "clipboard", "ls -la"},
+	}
+	if !reflect.DeepEqual(*ran, expected) {
+		t.Errorf("Expected %v, got %v", expected, *ran)
+	}
+}
+
+func TestCopyToTargetsWayland(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "wayland-0")
+	ran := stubClipboardTools(t, "wl-copy", "xclip")
+
+	if _, err := copyToTargets("linux", "ls", []string{"primary", "clipboard"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := [][]string{{"wl-copy", "--primary", "ls"}, {"wl-copy", "ls"}}
+	if !reflect.DeepEqual(*ran, expected) {
+		t.Errorf("Expected %v, got %v", expected, *ran)
+	}
+}
+
+func TestCopyToTargetsFallsBackToXsel(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "")
+	ran := stubClipboardTools(t, "xsel")
+
+	if _, err := copyToTargets("linux", "ls", []string{"primary"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := [][]string{{"xsel", "--primary", "--input", "ls"}}
+	if !reflect.DeepEqual(*ran, expected) {
+		t.Errorf("Expected %v, got %v", expected, *ran)
+	}
+}
+
+func TestCopyToTargetsReportsFailures(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "")
+	stubClipboardTools(t)
+
+	copied, err := copyToTargets("linux", "ls", []string{"primary", "clipboard"})
+	if len(copied) != 0 || err == nil {
+		t.Errorf("Expected every target to fail without clipboard tools, got %v, %v", copied, err)
+	}
+
+	// Only the regular clipboard exists on macOS
+	copiedText := stubClipboard(t)
+	copied, err = copyToTargets("darwin", "ls", []string{"primary", "clipboard"})
+	if !reflect.DeepEqual(copied, []string{"clipboard"}) || *copiedText != "ls" {
+		t.Errorf("Expected only the clipboard to be copied on macOS, got %v", copied)
+	}
+	if err == nil || !strings.Contains(err.Error(), "primary") {
+		t.Errorf("Expected the primary selection to be reported as failed, got %v", err)
+	}
+}
+
+func TestCopyCommandWithTargetsKeepsPartialSuccess(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "")
+	stubClipboardTools(t, "xclip")
+	orig := runClipboardCmd
+	runClipboardCmd = func(cmd *exec.Cmd) error {
+		if cmd.Args[2] == "primary" {
+			return errors.New("no X selection owner")
+		}
+		return orig(cmd)
+	}
+
+	testModel := initialModel("list files", false)
+	testModel.opts.clipboardTargets = []string{"primary", "clipboard"}
+	msg := testModel.copyCommand("ls")().(cmdCopiedMsg)
+	if msg.cmd != "ls" || !reflect.DeepEqual(msg.targets, []string{"clipboard"}) {
+		t.Errorf("Expected the clipboard copy to be kept, got %q to %v", msg.cmd, msg.targets)
+	}
+	if msg.err == nil {
+		t.Error("Expected the primary failure to be reported")
+	}
+}
+
+func TestParseClipboardTargets(t *testing.T) {
+	opts, err := parseArgs([]string{"--clipboard-targets", "primary,clipboard", "list"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(opts.clipboardTargets, []string{"primary", "clipboard"}) {
+		t.Errorf("Expected both targets, got %v", opts.clipboardTargets)
+	}
+
+	for _, bad := range []string{"secondary", ",", ""} {
+		if _, err := parseArgs([]string{"--clipboard-targets=" + bad}); err == nil {
+			t.Errorf("Expected an error for targets %q", bad)
+		}
+	}
+}
+
+// stubClipboardRead makes reading the clipboard return text and err
+func stubClipboardRead(t *testing.T, text string, err error) {
+	t.Helper()
+	orig := clipboardReadAll
+	clipboardReadAll = func() (string, error) { return text, err }
+	t.Cleanup(func() { clipboardReadAll = orig })
+}
+
+func TestFromClipboardSeedsPrompt(t *testing.T) {
+	stubClipboardRead(t, "  rename every .jpeg file to .jpg\n", nil)
+
+	m := newModel(options{fromClipboard: true})
+	if m.textarea.Value() != "rename every .jpeg file to .jpg" {
+		t.Errorf("Expected the clipboard to seed the prompt, got %q", m.textarea.Value())
+	}
+	// It's shown for review rather than sent straight away
+	if m.state != stateInput {
+		t.Errorf("Expected state to be stateInput, got %v", m.state)
+	}
+}
+
+func TestFromClipboardKeepsArgumentPrompt(t *testing.T) {
+	stubClipboardRead(t, "something else", nil)
+
+	m := newModel(options{prompt: "list files", fromClipboard: true})
+	if m.textarea.Value() != "list files" {
+		t.Errorf("Expected the argument to win over the clipboard, got %q", m.textarea.Value())
+	}
+}
+
+func TestFromClipboardEmptyOrUnreadable(t *testing.T) {
+	stubClipboardRead(t, " \n", nil)
+	m := newModel(options{fromClipboard: true})
+	if m.textarea.Value() != "" || m.clipboardErr == nil {
+		t.Error("Expected an empty clipboard to leave the prompt empty with a note")
+	}
+
+	stubClipboardRead(t, "", errors.New("no clipboard utilities available"))
+	m = newModel(options{fromClipboard: true})
+	if m.textarea.Value() != "" || m.state != stateInput {
+		t.Error("Expected an unreadable clipboard to leave the prompt empty")
+	}
+	if !strings.Contains(m.View(), "no clipboard utilities available") {
+		t.Error("Expected the view to say why the clipboard wasn't used")
+	}
+}
+
+func TestPasteClipboardKey(t *testing.T) {
+	stubClipboardRead(t, "find large files", nil)
+
+	var m tea.Model = initialModel("", false)
+	m = typeText(m, "old text")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+	if got := m.(model).textarea.Value(); got != "find large files" {
+		t.Errorf("Expected Ctrl+Y to replace the prompt with the clipboard, got %q", got)
+	}
+}
+
+func TestCopyChecksClipboardKeptCommand(t *testing.T) {
+	written := stubClipboard(t)
+	if err := copyToClipboard("ls -la"); err != nil || *written != "ls -la" {
+		t.Fatalf("Expected the copy to succeed, got %q (err %v)", *written, err)
+	}
+
+	// Line endings converted by the clipboard still count as a match
+	stubClipboardRead(t, "ls -la\r\n", nil)
+	if err := copyToClipboard("ls -la"); err != nil {
+		t.Errorf("Expected a trailing newline to be ignored, got %v", err)
+	}
+
+	// A clipboard that silently drops the write is reported
+	stubClipboardRead(t, "", nil)
+	msg := newModel(options{}).copyCommand("ls -la")().(cmdCopiedMsg)
+	if !errors.Is(msg.err, errClipboardUnavailable) || !msg.printed {
+		t.Fatalf("Expected the copy to fail and the command to be printed instead, got %+v", msg)
+	}
+	for _, want := range []string{"xclip", "--print"} {
+		if !strings.Contains(msg.err.Error(), want) {
+			t.Errorf("Expected the error to suggest %q, got %v", want, msg.err)
+		}
+	}
+}
+
+func TestNoClipboardPrintsCommand(t *testing.T) {
+	written := stubClipboard(t)
+	m := newModel(options{})
+	m.noClipboard = true
+	m.state = stateResult
+	m.generatedCmd = "ls -la"
+
+	if view := m.View(); !strings.Contains(view, "to print (no clipboard found)") {
+		t.Errorf("Expected the help to say Enter prints, got %q", view)
+	}
+
+	msg := m.executeCommand()().(cmdCopiedMsg)
+	if !msg.printed || msg.err != nil || *written != "" {
+		t.Fatalf("Expected the command to be printed without touching the clipboard, got %+v", msg)
+	}
+	updated, _ := m.Update(msg)
+	m = updated.(model)
+
+	banner := m.copiedBanner(false)
+	if strings.Contains(banner, glyphs.border.Left) || !strings.Contains(banner, "\n\nls -la\n\n") {
+		t.Errorf("Expected the bare command for copying by hand, got %q", banner)
+	}
+
+	m.opts.quiet = true
+	var stdout, stderr bytes.Buffer
+	m.printCopied(true, &stdout, &stderr)
+	if stdout.String() != "ls -la\n" {
+		t.Errorf("Expected --quiet to print just the command, got %q", stdout.String())
+	}
+}
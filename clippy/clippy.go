@@ -0,0 +1,78 @@
+// Package clippy turns a description of a task into a shell command using a
+// language model. It's the core of the clippycli command, for programs that
+// want to generate commands without the terminal UI.
+package clippy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrEmptyReply is reported when the model's reply has no text to use, e.g.
+// when it only held a tool call, rather than returning an empty command
+var ErrEmptyReply = errors.New("model returned no command, try rephrasing")
+
+// Options describe a command to generate
+type Options struct {
+	Prompt    string // What the command should do, e.g. "list all files"
+	Provider  string // anthropic, openai or ollama; empty means anthropic
+	Model     string // A model name or Claude alias like "haiku"; empty means the provider's default
+	MaxTokens int    // Longest reply allowed, or zero for DefaultMaxTokens
+	EnvInfo   string // The user's OS, shell and so on, so the command suits them
+	// Temperature, if set, is the sampling temperature, from 0 for the most
+	// predictable commands to MaxTemperature; values outside that are clamped
+	Temperature *float64
+	// Generator, if set, is used instead of one made from Provider, Model and
+	// MaxTokens
+	Generator Generator
+}
+
+// Result is a generated command with the tokens it took
+type Result struct {
+	Command      string
+	StopReason   string // Why the model stopped; StopMaxTokens means the command may be cut off
+	InputTokens  int
+	OutputTokens int
+}
+
+// Generate asks the model for a command that does what opts.Prompt
+// describes. API keys are read from the provider's usual environment
+// variable, such as ANTHROPIC_API_KEY.
+func Generate(ctx context.Context, opts Options) (Result, error) {
+	g := opts.Generator
+	if g == nil {
+		provider := opts.Provider
+		if provider == "" {
+			provider = "anthropic"
+		}
+		if !KnownProvider(provider) {
+			return Result{}, fmt.Errorf("unknown provider %q, expected %s", provider, ProviderNames())
+		}
+		model, err := ResolveModel(provider, opts.Model)
+		if err != nil {
+			return Result{}, err
+		}
+		maxTokens, _ := CapMaxTokens(ModelFor(provider, model), opts.MaxTokens)
+		g = NewGenerator(provider, model, maxTokens)
+	}
+	if opts.Temperature != nil {
+		temperature, _ := ClampTemperature(*opts.Temperature)
+		g = WithTemperature(g, temperature)
+	}
+
+	reply, err := g.Generate(ctx, SystemPrompt(opts.EnvInfo), []Message{UserMessage(opts.Prompt)}, nil)
+	result := Result{
+		Command:      SanitizeCommand(reply.Text),
+		StopReason:   reply.StopReason,
+		InputTokens:  reply.InputTokens,
+		OutputTokens: reply.OutputTokens,
+	}
+	if err != nil {
+		return result, err
+	}
+	if result.Command == "" {
+		return result, ErrEmptyReply
+	}
+	return result, nil
+}
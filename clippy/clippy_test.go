@@ -0,0 +1,73 @@
+package clippy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeGenerator replies with fixed text, recording what it was sent
+type fakeGenerator struct {
+	reply        Generation
+	err          error
+	systemPrompt *string
+	messages     *[]Message
+}
+
+func (g fakeGenerator) Generate(_ context.Context, systemPrompt string, messages []Message, _ func(string)) (Generation, error) {
+	if g.systemPrompt != nil {
+		*g.systemPrompt = systemPrompt
+		*g.messages = messages
+	}
+	return g.reply, g.err
+}
+
+func (g fakeGenerator) WarmUp(context.Context) error { return nil }
+
+func TestGenerate(t *testing.T) {
+	var systemPrompt string
+	var messages []Message
+	g := fakeGenerator{
+		reply:        Generation{Text: "```bash\nls -la\n```\n", StopReason: "end_turn", InputTokens: 120, OutputTokens: 5},
+		systemPrompt: &systemPrompt,
+		messages:     &messages,
+	}
+
+	result, err := Generate(context.Background(), Options{Prompt: "list files", EnvInfo: "Shell: /bin/zsh", Generator: g})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Command != "ls -la" {
+		t.Errorf("Expected the command without its code fence, got %q", result.Command)
+	}
+	if result.StopReason != "end_turn" || result.InputTokens != 120 || result.OutputTokens != 5 {
+		t.Errorf("Expected the stop reason and token usage to be kept, got %+v", result)
+	}
+	if systemPrompt != SystemPrompt("Shell: /bin/zsh") {
+		t.Errorf("Expected the system prompt to describe the environment, got %q", systemPrompt)
+	}
+	if len(messages) != 1 || messages[0] != UserMessage("list files") {
+		t.Errorf("Expected the prompt as the only message, got %+v", messages)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	ctx := context.Background()
+	if _, err := Generate(ctx, Options{Prompt: "list files", Generator: fakeGenerator{reply: Generation{Text: "```\n```"}}}); !errors.Is(err, ErrEmptyReply) {
+		t.Errorf("Expected an empty reply to be an error, got %v", err)
+	}
+
+	failed := errors.New("overloaded")
+	result, err := Generate(ctx, Options{Prompt: "find go files", Generator: fakeGenerator{reply: Generation{Text: "find . -na"}, err: failed}})
+	if err != failed || result.Command != "find . -na" {
+		t.Errorf("Expected the partial command with the error, got %q and %v", result.Command, err)
+	}
+
+	if _, err := Generate(ctx, Options{Prompt: "list files", Provider: "gemini"}); err == nil || !strings.Contains(err.Error(), "unknown provider") {
+		t.Errorf("Expected an unknown provider to be rejected, got %v", err)
+	}
+	if _, err := Generate(ctx, Options{Prompt: "list files", Model: "gpt-4o"}); err == nil || !strings.Contains(err.Error(), "unknown model") {
+		t.Errorf("Expected an unknown Claude model to be rejected, got %v", err)
+	}
+}
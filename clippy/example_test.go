@@ -0,0 +1,28 @@
+package clippy_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/benmyles/clippycli/clippy"
+)
+
+// Generating a command needs an API key, here read from ANTHROPIC_API_KEY,
+// so this example isn't run as a test.
+func ExampleGenerate() {
+	result, err := clippy.Generate(context.Background(), clippy.Options{
+		Prompt:    "find all .go files changed in the last day",
+		Model:     "haiku",
+		MaxTokens: 256,
+		EnvInfo:   "OS: linux\nShell: /bin/bash",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if result.StopReason == clippy.StopMaxTokens {
+		log.Print("the command may be cut off")
+	}
+	fmt.Println(result.Command)
+	fmt.Printf("%d tokens in, %d out\n", result.InputTokens, result.OutputTokens)
+}
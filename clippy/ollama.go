@@ -0,0 +1,203 @@
+package clippy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	// ollamaModel is the local model used when --model isn't given
+	ollamaModel = "llama3"
+	// ollamaHost is where Ollama listens unless $OLLAMA_HOST says otherwise
+	ollamaHost = "http://localhost:11434"
+)
+
+// ollamaGenerator runs a local model through Ollama's generate API, for
+// machines that can't reach a hosted provider. It needs no API key.
+type ollamaGenerator struct {
+	baseURL     string
+	model       string
+	maxTokens   int
+	temperature *float64 // nil for the model's default, usually 0.8
+	client      *http.Client
+}
+
+func newOllamaGenerator(model string, maxTokens int) Generator {
+	return ollamaGenerator{
+		baseURL:   ollamaBaseURL(os.Getenv("OLLAMA_HOST")),
+		model:     model,
+		maxTokens: maxTokens,
+		client:    http.DefaultClient,
+	}
+}
+
+// ollamaBaseURL turns $OLLAMA_HOST, which Ollama itself accepts without a
+// scheme (e.g. 0.0.0.0:11434), into a URL
+func ollamaBaseURL(host string) string {
+	if host == "" {
+		return ollamaHost
+	}
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	return strings.TrimRight(host, "/")
+}
+
+type ollamaRequest struct {
+	Model   string         `json:"model"`
+	System  string         `json:"system,omitempty"`
+	Prompt  string         `json:"prompt,omitempty"`
+	Stream  bool           `json:"stream"`
+	Options map[string]any `json:"options,omitempty"`
+}
+
+// ollamaChunk is one line of a streamed reply, or the whole of an error
+type ollamaChunk struct {
+	Response   string `json:"response"`
+	Done       bool   `json:"done"`
+	DoneReason string `json:"done_reason"`
+	Error      string `json:"error"`
+	// Token counts, sent with the last chunk
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+// ollamaPrompt flattens a conversation into the single prompt the generate
+// API takes. A lone user message is sent as is.
+func ollamaPrompt(messages []Message) string {
+	if len(messages) == 1 && messages[0].Role == RoleUser {
+		return messages[0].Text
+	}
+	var prompt strings.Builder
+	for _, msg := range messages {
+		if msg.Role == RoleAssistant {
+			prompt.WriteString("Assistant: ")
+		} else {
+			prompt.WriteString("User: ")
+		}
+		prompt.WriteString(msg.Text)
+		prompt.WriteString("\n\n")
+	}
+	// There's no prefill either, so ask for the rest of a partial reply
+	if len(messages) > 0 && messages[len(messages)-1].Role == RoleAssistant {
+		prompt.WriteString("User: " + ContinueRequest + "\n\n")
+	}
+	prompt.WriteString("Assistant:")
+	return prompt.String()
+}
+
+// generate streams the reply, so a dropped connection keeps what arrived
+func (g ollamaGenerator) Generate(ctx context.Context, systemPrompt string, messages []Message, onText func(string)) (Generation, error) {
+	options := map[string]any{"num_predict": g.maxTokens}
+	if g.temperature != nil {
+		options["temperature"] = *g.temperature
+	}
+	resp, err := g.post(ctx, ollamaRequest{
+		Model:   g.model,
+		System:  systemPrompt,
+		Prompt:  ollamaPrompt(messages),
+		Stream:  true,
+		Options: options,
+	})
+	if err != nil {
+		return Generation{}, err
+	}
+	defer resp.Body.Close()
+	return readOllamaStream(resp.Body, onText)
+}
+
+// readOllamaStream collects a streamed reply, one JSON object per line,
+// passing each piece of text to onText if it's set. On failure the text so
+// far is returned with the error.
+func readOllamaStream(r io.Reader, onText func(string)) (Generation, error) {
+	var text strings.Builder
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return Generation{Text: text.String()}, fmt.Errorf("ollama: could not read reply: %w", err)
+		}
+		if chunk.Error != "" {
+			return Generation{Text: text.String()}, errors.New("ollama: " + chunk.Error)
+		}
+		text.WriteString(chunk.Response)
+		if onText != nil && chunk.Response != "" {
+			onText(chunk.Response)
+		}
+		if chunk.Done {
+			return Generation{
+				Text:         text.String(),
+				StopReason:   lengthStop(chunk.DoneReason),
+				InputTokens:  chunk.PromptEvalCount,
+				OutputTokens: chunk.EvalCount,
+			}, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Generation{Text: text.String()}, err
+	}
+	return Generation{Text: text.String()}, io.ErrUnexpectedEOF
+}
+
+func (g ollamaGenerator) WithTemperature(temperature float64) Generator {
+	g.temperature = &temperature
+	return g
+}
+
+func (g ollamaGenerator) Varied() Generator {
+	g.temperature = variedTemperature(g.temperature, 1.2)
+	return g
+}
+
+// warmUp loads the model into memory, which Ollama does for a request with
+// no prompt. That's the slow part of a first local generation.
+func (g ollamaGenerator) WarmUp(ctx context.Context) error {
+	resp, err := g.post(ctx, ollamaRequest{Model: g.model})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+// post sends a generate request, turning the usual failures into errors that
+// say what to do about them
+func (g ollamaGenerator) post(ctx context.Context, body ollamaRequest) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+"/api/generate", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not reach Ollama at %s (is `ollama serve` running?): %w", g.baseURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var chunk ollamaChunk
+		if json.NewDecoder(resp.Body).Decode(&chunk) == nil && chunk.Error != "" {
+			return nil, &StatusError{Status: resp.StatusCode, Message: fmt.Sprintf("ollama: %s (%s)", chunk.Error, resp.Status)}
+		}
+		return nil, &StatusError{Status: resp.StatusCode, Message: "ollama: " + resp.Status}
+	}
+	return resp, nil
+}
@@ -0,0 +1,91 @@
+package clippy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeOllama serves the generate API with body, recording the last request
+func fakeOllama(t *testing.T, status int, body string) (ollamaGenerator, *ollamaRequest) {
+	t.Helper()
+	var got ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/generate" {
+			t.Errorf("Expected a request to /api/generate, got %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return ollamaGenerator{baseURL: server.URL, model: "llama3", client: server.Client()}, &got
+}
+
+func TestOllamaGenerateStreamed(t *testing.T) {
+	g, got := fakeOllama(t, http.StatusOK, `{"response":"ls","done":false}
+{"response":" -la","done":false}
+{"response":"","done":true,"done_reason":"length","prompt_eval_count":26,"eval_count":3}
+`)
+
+	reply, err := g.Generate(context.Background(), "be brief", []Message{UserMessage("list files")}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if reply.StopReason != StopMaxTokens {
+		t.Errorf("Expected a length stop to be reported as %q, got %q", StopMaxTokens, reply.StopReason)
+	}
+	if reply.Text != "ls -la" {
+		t.Errorf("Expected the chunks joined, got %q", reply.Text)
+	}
+	if reply.InputTokens != 26 || reply.OutputTokens != 3 {
+		t.Errorf("Expected 26 tokens in and 3 out, got %d and %d", reply.InputTokens, reply.OutputTokens)
+	}
+	if got.Model != "llama3" || got.System != "be brief" || got.Prompt != "list files" || !got.Stream {
+		t.Errorf("Expected the model, system prompt and prompt to be sent, got %+v", got)
+	}
+}
+
+func TestOllamaGenerateErrors(t *testing.T) {
+	g, _ := fakeOllama(t, http.StatusNotFound, `{"error":"model \"llama3\" not found, try pulling it first"}`)
+	if _, err := g.Generate(context.Background(), "", []Message{UserMessage("list files")}, nil); err == nil || !strings.Contains(err.Error(), "try pulling it first") {
+		t.Errorf("Expected Ollama's error message, got %v", err)
+	}
+
+	// A stream that stops early keeps what arrived
+	g, _ = fakeOllama(t, http.StatusOK, `{"response":"find . -name","done":false}`)
+	reply, err := g.Generate(context.Background(), "", []Message{UserMessage("find go files")}, nil)
+	if err == nil || reply.Text != "find . -name" {
+		t.Errorf("Expected the partial reply and an error, got %q, %v", reply.Text, err)
+	}
+}
+
+func TestOllamaBaseURL(t *testing.T) {
+	tests := map[string]string{
+		"":                      "http://localhost:11434",
+		"0.0.0.0:11434":         "http://0.0.0.0:11434",
+		"https://gpu-box:8443/": "https://gpu-box:8443",
+	}
+	for host, want := range tests {
+		if got := ollamaBaseURL(host); got != want {
+			t.Errorf("Expected %q for %q, got %q", want, host, got)
+		}
+	}
+}
+
+func TestOllamaPromptConversation(t *testing.T) {
+	prompt := ollamaPrompt([]Message{
+		UserMessage("delete old logs"),
+		AssistantMessage("find . -name '*.log' -delete"),
+		UserMessage("Review the command above"),
+	})
+	if !strings.Contains(prompt, "User: delete old logs") || !strings.Contains(prompt, "Assistant: find . -name '*.log' -delete") {
+		t.Errorf("Expected the conversation to be flattened, got %q", prompt)
+	}
+	if !strings.HasSuffix(prompt, "Assistant:") {
+		t.Errorf("Expected the prompt to end with the assistant's turn, got %q", prompt)
+	}
+}
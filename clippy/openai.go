@@ -0,0 +1,174 @@
+package clippy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	// openAIModel is the chat model used with the OpenAI provider by default
+	openAIModel = "gpt-4o"
+	// openAIBaseURL is where requests go unless $OPENAI_BASE_URL says otherwise
+	openAIBaseURL = "https://api.openai.com/v1"
+)
+
+// openAIGenerator calls OpenAI's chat completions API directly, which saves
+// pulling in an SDK for a single endpoint. $OPENAI_BASE_URL points it at a
+// compatible server instead.
+type openAIGenerator struct {
+	apiKey      string
+	baseURL     string
+	model       string
+	maxTokens   int
+	temperature *float64 // nil for OpenAI's default of 1; it goes up to 2
+	client      *http.Client
+}
+
+func newOpenAIGenerator(model string, maxTokens int) Generator {
+	baseURL := os.Getenv("OPENAI_BASE_URL")
+	if baseURL == "" {
+		baseURL = openAIBaseURL
+	}
+	return openAIGenerator{
+		apiKey:    os.Getenv("OPENAI_API_KEY"),
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		model:     model,
+		maxTokens: maxTokens,
+		client:    http.DefaultClient,
+	}
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIRequest struct {
+	Model       string          `json:"model"`
+	MaxTokens   int             `json:"max_tokens"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	Messages    []openAIMessage `json:"messages"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// openAIMessages converts a conversation, with the system prompt first. A
+// conversation ending with the assistant's partial reply gets a request to
+// carry on from there.
+func openAIMessages(systemPrompt string, messages []Message) []openAIMessage {
+	converted := []openAIMessage{{Role: "system", Content: systemPrompt}}
+	for _, msg := range messages {
+		converted = append(converted, openAIMessage{Role: string(msg.Role), Content: msg.Text})
+	}
+	if len(messages) > 0 && messages[len(messages)-1].Role == RoleAssistant {
+		converted = append(converted, openAIMessage{Role: string(RoleUser), Content: ContinueRequest})
+	}
+	return converted
+}
+
+// generate waits for the whole reply, so onText gets it in one piece
+func (g openAIGenerator) Generate(ctx context.Context, systemPrompt string, messages []Message, onText func(string)) (Generation, error) {
+	body, err := json.Marshal(openAIRequest{
+		Model:       g.model,
+		MaxTokens:   g.maxTokens,
+		Temperature: g.temperature,
+		Messages:    openAIMessages(systemPrompt, messages),
+	})
+	if err != nil {
+		return Generation{}, err
+	}
+
+	resp, err := g.do(ctx, http.MethodPost, "/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return Generation{}, err
+	}
+	defer resp.Body.Close()
+
+	var reply openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return Generation{}, &StatusError{Status: resp.StatusCode, Message: "openai: " + resp.Status}
+		}
+		return Generation{}, fmt.Errorf("openai: could not read reply: %w", err)
+	}
+	if reply.Error != nil {
+		return Generation{}, &StatusError{Status: resp.StatusCode, Message: fmt.Sprintf("openai: %s (%s)", reply.Error.Message, resp.Status)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Generation{}, &StatusError{Status: resp.StatusCode, Message: "openai: " + resp.Status}
+	}
+	if len(reply.Choices) == 0 {
+		return Generation{}, errors.New("openai: reply had no choices")
+	}
+	choice := reply.Choices[0]
+	if onText != nil {
+		onText(choice.Message.Content)
+	}
+	return Generation{
+		Text:         choice.Message.Content,
+		StopReason:   lengthStop(choice.FinishReason),
+		InputTokens:  reply.Usage.PromptTokens,
+		OutputTokens: reply.Usage.CompletionTokens,
+	}, nil
+}
+
+// lengthStop maps the "length" stop reason OpenAI and Ollama use for hitting
+// the token limit to StopMaxTokens
+func lengthStop(reason string) string {
+	if reason == "length" {
+		return StopMaxTokens
+	}
+	return reason
+}
+
+func (g openAIGenerator) WithTemperature(temperature float64) Generator {
+	g.temperature = &temperature
+	return g
+}
+
+func (g openAIGenerator) Varied() Generator {
+	g.temperature = variedTemperature(g.temperature, 1.3)
+	return g
+}
+
+func (g openAIGenerator) WarmUp(ctx context.Context) error {
+	resp, err := g.do(ctx, http.MethodGet, "/models", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+// do sends an authenticated request to the API
+func (g openAIGenerator) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, g.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+g.apiKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return g.client.Do(req)
+}
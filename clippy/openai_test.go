@@ -0,0 +1,102 @@
+package clippy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeOpenAI serves chat completions with reply, recording the last request
+func fakeOpenAI(t *testing.T, status int, reply string) (openAIGenerator, *openAIRequest) {
+	t.Helper()
+	var got openAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" || r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Expected an authenticated request to /chat/completions, got %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+	return openAIGenerator{apiKey: "test-key", baseURL: server.URL, model: openAIModel, client: server.Client()}, &got
+}
+
+func TestOpenAIGenerate(t *testing.T) {
+	g, got := fakeOpenAI(t, http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"ls -la\n"}}],"usage":{"prompt_tokens":31,"completion_tokens":4}}`)
+
+	reply, err := g.Generate(context.Background(), "be brief", []Message{UserMessage("list files")}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if reply.Text != "ls -la\n" {
+		t.Errorf("Expected the reply untrimmed, got %q", reply.Text)
+	}
+	if reply.InputTokens != 31 || reply.OutputTokens != 4 {
+		t.Errorf("Expected 31 tokens in and 4 out, got %d and %d", reply.InputTokens, reply.OutputTokens)
+	}
+
+	if len(got.Messages) != 2 || got.Messages[0].Role != "system" || got.Messages[0].Content != "be brief" {
+		t.Errorf("Expected the system prompt to be sent first, got %+v", got.Messages)
+	}
+	if got.Messages[1].Role != "user" || got.Messages[1].Content != "list files" {
+		t.Errorf("Expected the user's prompt to follow, got %+v", got.Messages[1])
+	}
+	if got.Model != openAIModel {
+		t.Errorf("Expected model %q, got %q", openAIModel, got.Model)
+	}
+}
+
+func TestOpenAIVaried(t *testing.T) {
+	g, got := fakeOpenAI(t, http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"ls"}}]}`)
+
+	if _, err := g.Generate(context.Background(), "be brief", []Message{UserMessage("list files")}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got.Temperature != nil {
+		t.Errorf("Expected OpenAI's default temperature, got %v", *got.Temperature)
+	}
+	if _, err := Varied(WithTemperature(g, 0)).Generate(context.Background(), "be brief", []Message{UserMessage("list files")}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got.Temperature == nil || *got.Temperature <= 1 {
+		t.Errorf("Expected a temperature above the default of 1, got %v", got.Temperature)
+	}
+}
+
+func TestOpenAITemperature(t *testing.T) {
+	g, got := fakeOpenAI(t, http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"ls"}}]}`)
+
+	// Zero is a temperature to send, not a missing one
+	if _, err := WithTemperature(g, 0).Generate(context.Background(), "be brief", []Message{UserMessage("list files")}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got.Temperature == nil || *got.Temperature != 0 {
+		t.Errorf("Expected a temperature of 0 to be sent, got %v", got.Temperature)
+	}
+}
+
+func TestOpenAIGenerateError(t *testing.T) {
+	g, _ := fakeOpenAI(t, http.StatusUnauthorized, `{"error":{"message":"Incorrect API key provided"}}`)
+
+	_, err := g.Generate(context.Background(), "be brief", []Message{UserMessage("list files")}, nil)
+	if err == nil || !strings.Contains(err.Error(), "Incorrect API key provided") {
+		t.Errorf("Expected the API's error message, got %v", err)
+	}
+}
+
+func TestOpenAIMessagesContinuation(t *testing.T) {
+	messages := openAIMessages("be brief", []Message{UserMessage("find go files"), AssistantMessage("find . -name")})
+
+	// Without prefill, the partial reply needs a request to carry on
+	last := messages[len(messages)-1]
+	if last.Role != "user" || last.Content != ContinueRequest {
+		t.Errorf("Expected a request to continue, got %+v", last)
+	}
+	if messages[2].Role != "assistant" || messages[2].Content != "find . -name" {
+		t.Errorf("Expected the partial reply to be sent as the assistant's, got %+v", messages[2])
+	}
+}
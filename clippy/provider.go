@@ -0,0 +1,303 @@
+package clippy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+const (
+	// DefaultMaxTokens caps reply length unless a limit is given
+	DefaultMaxTokens = 1024
+	// StopMaxTokens is the stop reason for a reply cut off by the token limit.
+	// Providers that name it differently are mapped to it.
+	StopMaxTokens = "max_tokens"
+	// MaxTemperature is the highest sampling temperature that can be set,
+	// Claude's limit and within every provider's range
+	MaxTemperature = 1.0
+)
+
+// Role is who said a message in a conversation with the model
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message is one turn of a conversation, in a form every provider can send
+type Message struct {
+	Role Role
+	Text string
+}
+
+func UserMessage(text string) Message      { return Message{Role: RoleUser, Text: text} }
+func AssistantMessage(text string) Message { return Message{Role: RoleAssistant, Text: text} }
+
+// Generation is a model's reply, untrimmed, with why it stopped and how many
+// tokens it took
+type Generation struct {
+	Text         string
+	StopReason   string
+	InputTokens  int
+	OutputTokens int
+}
+
+// Generator sends conversations to a model provider. Callers only talk to
+// providers through it, so they don't care which one is in use.
+type Generator interface {
+	// Generate returns the reply to messages under systemPrompt, passing
+	// each piece of text to onText, if it's set, as it arrives. If the reply
+	// is cut off partway, the text received so far is returned along with
+	// the error.
+	Generate(ctx context.Context, systemPrompt string, messages []Message, onText func(string)) (Generation, error)
+	// WarmUp makes the cheapest request available, to set up the connection
+	WarmUp(ctx context.Context) error
+}
+
+// Variator is implemented by generators whose provider can be asked to
+// sample more freely, for a different reply to the same request
+type Variator interface {
+	// Varied returns a copy of the generator with a higher temperature
+	Varied() Generator
+}
+
+// Varied returns g with a higher temperature if its provider allows one, or
+// g itself if not
+func Varied(g Generator) Generator {
+	if v, ok := g.(Variator); ok {
+		return v.Varied()
+	}
+	return g
+}
+
+// Tempered is implemented by generators whose provider takes a sampling
+// temperature. Lower temperatures give more predictable replies.
+type Tempered interface {
+	// WithTemperature returns a copy of the generator sampling at temperature
+	WithTemperature(temperature float64) Generator
+}
+
+// WithTemperature returns g sampling at temperature if its provider takes
+// one, or g itself if not
+func WithTemperature(g Generator, temperature float64) Generator {
+	if t, ok := g.(Tempered); ok {
+		return t.WithTemperature(temperature)
+	}
+	return g
+}
+
+// ClampTemperature keeps temperature between 0 and MaxTemperature, returning
+// a note saying so when it had to be moved
+func ClampTemperature(temperature float64) (float64, string) {
+	clamped := min(max(temperature, 0), MaxTemperature)
+	if clamped != temperature {
+		return clamped, fmt.Sprintf("temperature must be between 0 and %g, so %g was changed to %g", MaxTemperature, temperature, clamped)
+	}
+	return temperature, ""
+}
+
+// variedTemperature returns the temperature a Varied generator samples at:
+// the provider's one for variety, unless a higher one was already set
+func variedTemperature(set *float64, varied float64) *float64 {
+	if set != nil && *set > varied {
+		return set
+	}
+	return &varied
+}
+
+// providerInfo describes a model provider ClippyCLI can use
+type providerInfo struct {
+	keyEnv       string // Environment variable holding the API key, if one is needed
+	defaultModel string
+	newGenerator func(model string, maxTokens int) Generator
+}
+
+var providers = map[string]providerInfo{
+	"anthropic": {keyEnv: "ANTHROPIC_API_KEY", defaultModel: string(anthropic.ModelClaudeSonnet4_20250514), newGenerator: newAnthropicGenerator},
+	"openai":    {keyEnv: "OPENAI_API_KEY", defaultModel: openAIModel, newGenerator: newOpenAIGenerator},
+	"ollama":    {defaultModel: ollamaModel, newGenerator: newOllamaGenerator},
+}
+
+// anthropicModels maps the short names a model can be given by to Claude models
+var anthropicModels = map[string]anthropic.Model{
+	"haiku":  anthropic.ModelClaude3_5HaikuLatest,
+	"sonnet": anthropic.ModelClaudeSonnet4_20250514,
+	"opus":   anthropic.ModelClaudeOpus4_20250514,
+}
+
+// KnownProvider reports whether name is a supported provider
+func KnownProvider(name string) bool {
+	_, ok := providers[name]
+	return ok
+}
+
+// KeyEnv returns the environment variable holding provider's API key, or
+// empty if it doesn't need one
+func KeyEnv(provider string) string {
+	return providers[provider].keyEnv
+}
+
+// ProviderNames lists the supported providers for error messages
+func ProviderNames() string {
+	var names []string
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// ResolveModel checks the model named for provider, expanding Claude's short
+// names. Other providers' model names are passed through, since what's
+// available depends on the account or the local install.
+func ResolveModel(provider, name string) (string, error) {
+	if provider != "anthropic" || name == "" {
+		return name, nil
+	}
+	if model, ok := anthropicModels[strings.ToLower(name)]; ok {
+		return string(model), nil
+	}
+	// Full model IDs are left for the API to check, so new models work
+	if strings.HasPrefix(name, "claude-") {
+		return name, nil
+	}
+	var aliases []string
+	for alias := range anthropicModels {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return "", fmt.Errorf("unknown model %q, expected %s, or a full Claude model ID like %s", name, strings.Join(aliases, ", "), anthropic.ModelClaudeSonnet4_20250514)
+}
+
+// modelTokenLimits are the documented output limits of known models. More
+// specific prefixes come first, since the first match wins.
+var modelTokenLimits = []struct {
+	prefix string
+	limit  int
+}{
+	{"claude-3-5-haiku", 8192},
+	{"claude-3-haiku", 4096},
+	{"claude-haiku-4", 64000},
+	{"claude-3-5-sonnet", 8192},
+	{"claude-3-7-sonnet", 64000},
+	{"claude-sonnet-4", 64000},
+	{"claude-3-opus", 4096},
+	{"claude-opus-4", 32000},
+	{"gpt-4o", 16384},
+	{"gpt-4.1", 32768},
+}
+
+// CapMaxTokens lowers maxTokens to what model can produce, returning a note
+// saying so when it does. Models not in modelTokenLimits are left to the API.
+func CapMaxTokens(model string, maxTokens int) (int, string) {
+	for _, l := range modelTokenLimits {
+		if strings.HasPrefix(model, l.prefix) {
+			if maxTokens > l.limit {
+				return l.limit, fmt.Sprintf("%s replies with at most %d tokens, so the limit of %d was lowered to match", model, l.limit, maxTokens)
+			}
+			break
+		}
+	}
+	return maxTokens, ""
+}
+
+// ModelFor returns model, or the provider's default if it's empty
+func ModelFor(provider, model string) string {
+	if model != "" {
+		return model
+	}
+	if info, ok := providers[provider]; ok {
+		return info.defaultModel
+	}
+	return providers["anthropic"].defaultModel
+}
+
+// NewGenerator returns a generator for the named provider, defaulting to
+// Anthropic. An empty model uses the provider's default, and a maxTokens of
+// zero uses DefaultMaxTokens.
+func NewGenerator(provider, model string, maxTokens int) Generator {
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+	model = ModelFor(provider, model)
+	if info, ok := providers[provider]; ok {
+		return info.newGenerator(model, maxTokens)
+	}
+	return newAnthropicGenerator(model, maxTokens)
+}
+
+// StatusError is an error reply from a provider's HTTP API, keeping the
+// status code so callers can tell failures apart
+type StatusError struct {
+	Status  int
+	Message string
+}
+
+func (e *StatusError) Error() string { return e.Message }
+
+// anthropicGenerator uses Claude through the Anthropic SDK, which reads
+// ANTHROPIC_API_KEY itself
+type anthropicGenerator struct {
+	client      *anthropic.Client
+	model       anthropic.Model
+	maxTokens   int
+	temperature *float64 // nil for Claude's default of 1
+}
+
+func newAnthropicGenerator(model string, maxTokens int) Generator {
+	// Callers retry, showing progress, so the SDK shouldn't too
+	client := anthropic.NewClient(option.WithMaxRetries(0))
+	return anthropicGenerator{client: &client, model: anthropic.Model(model), maxTokens: maxTokens}
+}
+
+// Generate streams the reply so a dropped connection keeps what already arrived
+func (g anthropicGenerator) Generate(ctx context.Context, systemPrompt string, messages []Message, onText func(string)) (Generation, error) {
+	params := anthropicParams(systemPrompt, messages)
+	params.Model = g.model
+	params.MaxTokens = int64(g.maxTokens)
+	if g.temperature != nil {
+		params.Temperature = anthropic.Float(*g.temperature)
+	}
+	return collectStream(g.client.Messages.NewStreaming(ctx, params), onText)
+}
+
+func (g anthropicGenerator) WithTemperature(temperature float64) Generator {
+	g.temperature = &temperature
+	return g
+}
+
+// Varied goes back to Claude's default, which is already its highest, so it
+// only changes anything when a lower temperature was set
+func (g anthropicGenerator) Varied() Generator {
+	g.temperature = variedTemperature(g.temperature, MaxTemperature)
+	return g
+}
+
+func (g anthropicGenerator) WarmUp(ctx context.Context) error {
+	_, err := g.client.Models.List(ctx, anthropic.ModelListParams{Limit: anthropic.Int(1)})
+	return err
+}
+
+// anthropicParams builds the API request for the system prompt and conversation
+func anthropicParams(systemPrompt string, messages []Message) anthropic.MessageNewParams {
+	params := anthropic.MessageNewParams{
+		Model:     anthropic.ModelClaudeSonnet4_20250514,
+		MaxTokens: DefaultMaxTokens,
+		System: []anthropic.TextBlockParam{
+			{Text: systemPrompt},
+		},
+	}
+	for _, msg := range messages {
+		if msg.Role == RoleAssistant {
+			params.Messages = append(params.Messages, anthropic.NewAssistantMessage(anthropic.NewTextBlock(msg.Text)))
+		} else {
+			params.Messages = append(params.Messages, anthropic.NewUserMessage(anthropic.NewTextBlock(msg.Text)))
+		}
+	}
+	return params
+}
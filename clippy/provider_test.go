@@ -0,0 +1,116 @@
+package clippy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestNewGeneratorPicksProvider(t *testing.T) {
+	if _, ok := NewGenerator("openai", "", 0).(openAIGenerator); !ok {
+		t.Error("Expected the openai provider to use openAIGenerator")
+	}
+	if g, ok := NewGenerator("ollama", "llama3", 0).(ollamaGenerator); !ok || g.model != "llama3" {
+		t.Error("Expected the ollama provider to use ollamaGenerator with the given model")
+	}
+	if g, ok := NewGenerator("", "", 0).(anthropicGenerator); !ok || g.model != anthropic.ModelClaudeSonnet4_20250514 || g.maxTokens != DefaultMaxTokens {
+		t.Error("Expected Anthropic with its default model and token limit to be the default")
+	}
+	if g := NewGenerator("openai", "", 4096).(openAIGenerator); g.maxTokens != 4096 {
+		t.Errorf("Expected --max-tokens to be passed on, got %d", g.maxTokens)
+	}
+}
+
+func TestAnthropicParams(t *testing.T) {
+	params := anthropicParams("be brief", []Message{UserMessage("list files"), AssistantMessage("ls")})
+
+	if params.System[0].Text != "be brief" {
+		t.Errorf("Expected the system prompt to be sent, got %q", params.System[0].Text)
+	}
+	if len(params.Messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(params.Messages))
+	}
+	if params.Messages[1].Role != anthropic.MessageParamRoleAssistant || params.Messages[1].Content[0].OfText.Text != "ls" {
+		t.Error("Expected the assistant's turn to be kept as the assistant's")
+	}
+}
+
+func TestResolveModel(t *testing.T) {
+	tests := []struct {
+		provider, name, want string
+	}{
+		{"anthropic", "", ""},
+		{"anthropic", "haiku", string(anthropic.ModelClaude3_5HaikuLatest)},
+		{"anthropic", "Opus", string(anthropic.ModelClaudeOpus4_20250514)},
+		{"anthropic", "claude-3-7-sonnet-latest", "claude-3-7-sonnet-latest"},
+		{"ollama", "llama3", "llama3"},
+		{"openai", "gpt-4o-mini", "gpt-4o-mini"},
+	}
+	for _, tt := range tests {
+		got, err := ResolveModel(tt.provider, tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("%s %q: Expected %q, got %q (err %v)", tt.provider, tt.name, tt.want, got, err)
+		}
+	}
+
+	_, err := ResolveModel("anthropic", "gpt-4o")
+	if err == nil {
+		t.Fatal("Expected an unknown Claude model to be rejected")
+	}
+	for _, alias := range []string{"haiku", "sonnet", "opus"} {
+		if !strings.Contains(err.Error(), alias) {
+			t.Errorf("Expected the error to list %q, got %v", alias, err)
+		}
+	}
+}
+
+func TestCapMaxTokens(t *testing.T) {
+	if n, note := CapMaxTokens("claude-3-5-haiku-latest", 20000); n != 8192 || !strings.Contains(note, "lowered") {
+		t.Errorf("Expected the limit to be capped with a note, got %d and %q", n, note)
+	}
+	if n, note := CapMaxTokens("claude-sonnet-4-20250514", 20000); n != 20000 || note != "" {
+		t.Errorf("Expected a limit within range to be kept, got %d and %q", n, note)
+	}
+	if n, note := CapMaxTokens("llama3", 1000000); n != 1000000 || note != "" {
+		t.Errorf("Expected unknown models to be left to the API, got %d and %q", n, note)
+	}
+}
+
+func TestClampTemperature(t *testing.T) {
+	tests := []struct {
+		in, want float64
+		noted    bool
+	}{
+		{0, 0, false},
+		{0.7, 0.7, false},
+		{1, 1, false},
+		{1.5, 1, true},
+		{-0.2, 0, true},
+	}
+	for _, tt := range tests {
+		got, note := ClampTemperature(tt.in)
+		if got != tt.want || (note != "") != tt.noted {
+			t.Errorf("%g: expected %g (noted %v), got %g and %q", tt.in, tt.want, tt.noted, got, note)
+		}
+	}
+}
+
+func TestAnthropicTemperature(t *testing.T) {
+	g := NewGenerator("anthropic", "", 0).(anthropicGenerator)
+	if g.temperature != nil {
+		t.Errorf("Expected Claude's default temperature, got %v", *g.temperature)
+	}
+
+	cold := WithTemperature(g, 0).(anthropicGenerator)
+	if cold.temperature == nil || *cold.temperature != 0 {
+		t.Fatalf("Expected a temperature of 0, got %v", cold.temperature)
+	}
+	// Regenerating raises a lowered temperature back up for variety
+	if varied := Varied(cold).(anthropicGenerator); varied.temperature == nil || *varied.temperature != MaxTemperature {
+		t.Errorf("Expected regeneration to sample at %g, got %v", MaxTemperature, varied.temperature)
+	}
+	if *cold.temperature != 0 {
+		t.Error("Expected Varied to leave the original generator as it was")
+	}
+}
@@ -0,0 +1,48 @@
+package clippy
+
+import (
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// messageStream is the part of the SDK's event stream used to read a reply,
+// so tests can stand in a stream that fails partway through
+type messageStream interface {
+	Next() bool
+	Current() anthropic.MessageStreamEventUnion
+	Err() error
+	Close() error
+}
+
+// collectStream reads the reply's text, stop reason and token usage from
+// stream, passing each piece of text to onText if it's set. When the stream
+// fails, the text received before the failure is returned along with the
+// error so it isn't lost.
+func collectStream(stream messageStream, onText func(string)) (Generation, error) {
+	defer stream.Close()
+
+	var text strings.Builder
+	var reply Generation
+	for stream.Next() {
+		switch event := stream.Current().AsAny().(type) {
+		case anthropic.MessageStartEvent:
+			reply.InputTokens = int(event.Message.Usage.InputTokens)
+			reply.OutputTokens = int(event.Message.Usage.OutputTokens)
+		case anthropic.ContentBlockDeltaEvent:
+			if delta, ok := event.Delta.AsAny().(anthropic.TextDelta); ok {
+				text.WriteString(delta.Text)
+				if onText != nil {
+					onText(delta.Text)
+				}
+			}
+		case anthropic.MessageDeltaEvent:
+			reply.StopReason = string(event.Delta.StopReason)
+			// Delta counts are cumulative, and input may be left at zero
+			reply.InputTokens = max(reply.InputTokens, int(event.Usage.InputTokens))
+			reply.OutputTokens = int(event.Usage.OutputTokens)
+		}
+	}
+	reply.Text = text.String()
+	return reply, stream.Err()
+}
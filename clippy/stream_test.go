@@ -0,0 +1,101 @@
+package clippy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// fakeStream replays events, then fails with err
+type fakeStream struct {
+	events []anthropic.MessageStreamEventUnion
+	err    error
+	pos    int
+	closed bool
+}
+
+func (s *fakeStream) Next() bool {
+	if s.pos >= len(s.events) {
+		return false
+	}
+	s.pos++
+	return true
+}
+
+func (s *fakeStream) Current() anthropic.MessageStreamEventUnion { return s.events[s.pos-1] }
+func (s *fakeStream) Err() error                                 { return s.err }
+func (s *fakeStream) Close() error                               { s.closed = true; return nil }
+
+// textChunks builds the stream events for a reply arriving in chunks
+func textChunks(t *testing.T, chunks ...string) []anthropic.MessageStreamEventUnion {
+	t.Helper()
+	raw := []string{`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}`}
+	for _, chunk := range chunks {
+		text, _ := json.Marshal(chunk)
+		raw = append(raw, fmt.Sprintf(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":%s}}`, text))
+	}
+
+	events := make([]anthropic.MessageStreamEventUnion, len(raw))
+	for i, r := range raw {
+		if err := json.Unmarshal([]byte(r), &events[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return events
+}
+
+func TestCollectStream(t *testing.T) {
+	stream := &fakeStream{events: textChunks(t, "find . ", "-name '*.go'")}
+	reply, err := collectStream(stream, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Text != "find . -name '*.go'" {
+		t.Errorf("Expected the chunks to be joined, got %q", reply.Text)
+	}
+	if !stream.closed {
+		t.Error("Expected the stream to be closed")
+	}
+}
+
+func TestCollectStreamFailureWithoutOutput(t *testing.T) {
+	reply, err := collectStream(&fakeStream{err: io.ErrUnexpectedEOF}, nil)
+	if reply.Text != "" || err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected no text and the stream's error, got %q and %v", reply.Text, err)
+	}
+}
+
+func TestCollectStreamStopReason(t *testing.T) {
+	events := textChunks(t, "find . -name")
+	var delta anthropic.MessageStreamEventUnion
+	if err := json.Unmarshal([]byte(`{"type":"message_delta","delta":{"stop_reason":"max_tokens","stop_sequence":null},"usage":{"output_tokens":1024}}`), &delta); err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := collectStream(&fakeStream{events: append(events, delta)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.StopReason != StopMaxTokens {
+		t.Errorf("Expected stop reason %q, got %q", StopMaxTokens, reply.StopReason)
+	}
+	if reply.OutputTokens != 1024 {
+		t.Errorf("Expected 1024 output tokens, got %d", reply.OutputTokens)
+	}
+}
+
+func TestCollectStreamPassesText(t *testing.T) {
+	var pieces []string
+	_, err := collectStream(&fakeStream{events: textChunks(t, "find . ", "-name '*.go'")}, func(text string) {
+		pieces = append(pieces, text)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pieces) != 2 || pieces[1] != "-name '*.go'" {
+		t.Errorf("Expected each chunk to be passed on as it arrived, got %q", pieces)
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// completionFlag is one of clippycli's flags as shell completion offers it
+type completionFlag struct {
+	names  []string // Short name first, if there is one
+	arg    string   // What the flag takes, like N or PATH; empty for a switch
+	values []string // The choices for arg, when there's a fixed set
+	repeat bool     // Can be given more than once
+	help   string
+}
+
+// completionFlags are the flags `clippycli completion` offers. Adding a flag
+// here is all it takes for every shell to complete it.
+var completionFlags = []completionFlag{
+	{names: []string{"-h", "--help"}, help: "Show the help message"},
+	{names: []string{"-V", "--version"}, help: "Show the version, commit, and build date"},
+	{names: []string{"-v"}, help: "Show the full prompt sent to the AI"},
+	{names: []string{"-x", "--execute"}, help: "Press Shift+R on the result to run the command"},
+	{names: []string{"-q", "--quiet"}, help: "Copy without printing the banner afterwards"},
+	{names: []string{"--system-stats"}, help: "Include CPU, memory, and disk stats in the prompt"},
+	{names: []string{"--notify"}, help: "Show a desktop notification when the command is ready"},
+	{names: []string{"--with-undo"}, help: "Also generate a command that reverses the result"},
+	{names: []string{"--with-verify"}, help: "Also generate a command that checks the result worked"},
+	{names: []string{"--ask-inputs"}, help: "Prompt for values only you know"},
+	{names: []string{"--as-script"}, help: "Generate a reusable script with argument parsing"},
+	{names: []string{"--single-line"}, help: "Ask for a one-line command"},
+	{names: []string{"--count"}, arg: "N", help: "Generate N alternative commands to choose from"},
+	{names: []string{"--comment-style"}, arg: "STYLE", values: []string{"none", "minimal", "verbose"}, help: "How much to comment scripts"},
+	{names: []string{"--lang"}, arg: "LANG", help: "Write explanations in LANG"},
+	{names: []string{"--strict-confirm"}, help: "Type the tool name to confirm dangerous commands"},
+	{names: []string{"--yes"}, help: "Copy the command without reviewing it, unless it's dangerous"},
+	{names: []string{"--widget"}, help: "Print only the command, for shell key bindings"},
+	{names: []string{"--print"}, help: "Print only the command to stdout, for scripts"},
+	{names: []string{"--json"}, help: "Print the command, model and tokens as JSON, for programs"},
+	{names: []string{"--dry-run"}, help: "Print the prompt that would be sent, without calling the API"},
+	{names: []string{"--update-check"}, help: "Check for a newer release at most once a day"},
+	{names: []string{"--no-update-check"}, help: "Don't check for a newer release this run"},
+	{names: []string{"--provider"}, arg: "NAME", values: []string{"anthropic", "ollama", "openai"}, help: "Model provider"},
+	{names: []string{"--model"}, arg: "NAME", values: []string{"haiku", "sonnet", "opus"}, help: "Model to use"},
+	{names: []string{"--max-tokens"}, arg: "N", help: "Limit replies to N tokens"},
+	{names: []string{"--temperature"}, arg: "T", help: "Sample at T, from 0 (most predictable) to 1"},
+	{names: []string{"--max-width"}, arg: "COLUMNS", help: "Draw the UI at most COLUMNS wide"},
+	{names: []string{"--theme"}, arg: "NAME", values: []string{"dark", "light"}, help: "Color theme"},
+	{names: []string{"--theme-color"}, repeat: true, arg: "NAME=COLOR", help: "Override one theme color"},
+	{names: []string{"--no-highlight"}, help: "Show the command without syntax highlighting"},
+	{names: []string{"--no-loading-hints"}, help: "Just say Thinking... while waiting"},
+	{names: []string{"--timeout"}, arg: "SECONDS", help: "Give up on a generation after SECONDS"},
+	{names: []string{"--retries"}, arg: "N", help: "Retry busy or failing API requests N times"},
+	{names: []string{"--retry-delay"}, arg: "MS", help: "Wait MS milliseconds before the first retry"},
+	{names: []string{"--legacy-keys"}, help: "Quit on any unrecognized key in the result view"},
+	{names: []string{"--from-clipboard"}, help: "Start with the clipboard's text as the prompt"},
+	{names: []string{"--idle-timeout"}, arg: "SECONDS", help: "Quit without copying after SECONDS with no keypress"},
+	{names: []string{"--export-make"}, arg: "PATH", help: "Write the steps as Makefile targets to PATH"},
+	{names: []string{"--export-just"}, arg: "PATH", help: "Write the steps as justfile recipes to PATH"},
+	{names: []string{"--audit-log"}, arg: "PATH", help: "Log overrides of safety warnings to PATH"},
+	{names: []string{"--url-encode"}, help: "Copy an explainshell.com share link instead"},
+	{names: []string{"--clipboard-targets"}, arg: "LIST", values: clipboardTargetNames, help: "Copy to each of primary,clipboard"},
+	{names: []string{"--osc52"}, help: "Copy through the terminal"},
+	{names: []string{"--tool-version"}, repeat: true, arg: "TOOL=VERSION", help: "Target a specific tool version"},
+	{names: []string{"--context"}, repeat: true, arg: "TEXT", help: "Add TEXT to every prompt"},
+	{names: []string{"--exec-allow"}, repeat: true, arg: "PATTERN", help: "Only ever run commands matching PATTERN"},
+	{names: []string{"--exec-deny"}, repeat: true, arg: "PATTERN", help: "Never run commands matching PATTERN"},
+	{names: []string{"--detect-versions"}, help: "Detect versions of tools mentioned in the prompt"},
+	{names: []string{"--git-context"}, help: "Include git status and a diff summary in the prompt"},
+	{names: []string{"--no-env"}, help: "Don't send environment variable names"},
+	{names: []string{"--always-fresh"}, help: "Always call the API, never reuse cached results"},
+	{names: []string{"--no-cache"}, help: "Call the API even if the same request was just made"},
+	{names: []string{"--history"}, help: "Print the last generated commands"},
+	{names: []string{"--favorites"}, help: "Pick a favorite to copy, without the API"},
+	{names: []string{"--replay"}, arg: "N", help: "Regenerate the Nth most recent prompt in the history"},
+}
+
+// completionSubcommands are the words that start a subcommand instead of a
+// prompt, with what each takes after it
+var completionSubcommands = []struct {
+	name string
+	args []string
+}{
+	{"rules", nil},
+	{"daemon", []string{"trigger"}},
+	{"install-widget", []string{"bash", "zsh"}},
+	{"completion", []string{"bash", "zsh", "fish"}},
+}
+
+// allFlagNames lists every flag name, for shells that complete from a word list
+func allFlagNames() []string {
+	var names []string
+	for _, f := range completionFlags {
+		names = append(names, f.names...)
+	}
+	return names
+}
+
+// bashCompletion completes flags, their fixed values and paths, and the
+// subcommands, with compgen
+func bashCompletion() string {
+	var b strings.Builder
+	b.WriteString("# ClippyCLI completion for bash\n_clippycli() {\n")
+	b.WriteString("  local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}\n")
+	b.WriteString("  case $prev in\n")
+	var free []string
+	for _, f := range completionFlags {
+		switch {
+		case f.arg == "":
+		case len(f.values) > 0:
+			fmt.Fprintf(&b, "    %s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", strings.Join(f.names, "|"), strings.Join(f.values, " "))
+		case f.arg == "PATH":
+			fmt.Fprintf(&b, "    %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", strings.Join(f.names, "|"))
+		default:
+			free = append(free, f.names...)
+		}
+	}
+	// Nothing sensible to suggest, but a flag name would be wrong
+	fmt.Fprintf(&b, "    %s) return ;;\n", strings.Join(free, "|"))
+	b.WriteString("  esac\n")
+	b.WriteString("  if [[ $COMP_CWORD -eq 2 ]]; then\n    case ${COMP_WORDS[1]} in\n")
+	for _, sub := range completionSubcommands {
+		if len(sub.args) > 0 {
+			fmt.Fprintf(&b, "      %s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", sub.name, strings.Join(sub.args, " "))
+		}
+	}
+	b.WriteString("    esac\n  fi\n")
+	var subs []string
+	for _, sub := range completionSubcommands {
+		subs = append(subs, sub.name)
+	}
+	fmt.Fprintf(&b, "  if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then\n    COMPREPLY=($(compgen -W %q -- \"$cur\"))\n    return\n  fi\n", strings.Join(subs, " "))
+	fmt.Fprintf(&b, "  COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(allFlagNames(), " "))
+	b.WriteString("}\ncomplete -o default -F _clippycli clippycli\n")
+	return b.String()
+}
+
+// zshDescription escapes text for an _arguments description in brackets
+func zshDescription(s string) string {
+	return strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`).Replace(s)
+}
+
+// zshCompletion describes the flags to _arguments. It works both from a
+// file on $fpath and sourced directly.
+func zshCompletion() string {
+	var b strings.Builder
+	b.WriteString("#compdef clippycli\n# ClippyCLI completion for zsh\n_clippycli() {\n  _arguments -s \\\n")
+	for _, f := range completionFlags {
+		names := f.names[0]
+		exclusive := ""
+		if len(f.names) > 1 {
+			names = "{" + strings.Join(f.names, ",") + "}"
+			exclusive = "'(" + strings.Join(f.names, " ") + ")'"
+		}
+		// Flags that can be given more than once may be offered again
+		if f.repeat {
+			exclusive = "'*'"
+		}
+		spec := "'[" + zshDescription(f.help) + "]"
+		switch {
+		case f.arg == "":
+		case len(f.values) > 0:
+			spec += ":" + f.arg + ":(" + strings.Join(f.values, " ") + ")"
+		case f.arg == "PATH":
+			spec += ":" + f.arg + ":_files"
+		default:
+			spec += ":" + f.arg + ": "
+		}
+		fmt.Fprintf(&b, "    %s%s%s' \\\n", exclusive, names, spec)
+	}
+	var subs []string
+	for _, sub := range completionSubcommands {
+		subs = append(subs, sub.name)
+	}
+	fmt.Fprintf(&b, "    '1:subcommand or prompt:(%s)' \\\n", strings.Join(subs, " "))
+	b.WriteString("    '*:prompt: '\n}\n")
+	b.WriteString("if [[ $funcstack[1] == _clippycli ]]; then\n  _clippycli \"$@\"\nelse\n  compdef _clippycli clippycli\nfi\n")
+	return b.String()
+}
+
+// fishQuote quotes s as a single fish argument
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// fishCompletion registers each flag with complete, offering the
+// subcommands only before anything else is typed
+func fishCompletion() string {
+	var b strings.Builder
+	b.WriteString("# ClippyCLI completion for fish\ncomplete -c clippycli -f\n")
+	for _, sub := range completionSubcommands {
+		fmt.Fprintf(&b, "complete -c clippycli -n __fish_use_subcommand -a %s\n", sub.name)
+		if len(sub.args) > 0 {
+			fmt.Fprintf(&b, "complete -c clippycli -n '__fish_seen_subcommand_from %s' -a %s\n", sub.name, fishQuote(strings.Join(sub.args, " ")))
+		}
+	}
+	for _, f := range completionFlags {
+		b.WriteString("complete -c clippycli")
+		for _, name := range f.names {
+			if long, ok := strings.CutPrefix(name, "--"); ok {
+				b.WriteString(" -l " + long)
+			} else {
+				b.WriteString(" -s " + strings.TrimPrefix(name, "-"))
+			}
+		}
+		switch {
+		case f.arg == "":
+		case len(f.values) > 0:
+			b.WriteString(" -x -a " + fishQuote(strings.Join(f.values, " ")))
+		case f.arg == "PATH":
+			b.WriteString(" -r -F")
+		default:
+			b.WriteString(" -x")
+		}
+		b.WriteString(" -d " + fishQuote(f.help) + "\n")
+	}
+	return b.String()
+}
+
+// completionScripts generate the completion script for each shell
+var completionScripts = map[string]func() string{
+	"bash": bashCompletion,
+	"zsh":  zshCompletion,
+	"fish": fishCompletion,
+}
+
+// runCompletion implements `clippycli completion [bash|zsh|fish]`, printing
+// the completion script for the shell, or the login shell if none is given.
+// It returns the process exit code.
+func runCompletion(args []string, stdout, stderr io.Writer) int {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	switch len(args) {
+	case 0:
+	case 1:
+		shell = args[0]
+	default:
+		fmt.Fprintln(stderr, "Error: completion takes one shell: bash, zsh or fish")
+		return 1
+	}
+
+	script, ok := completionScripts[shell]
+	if !ok {
+		fmt.Fprintf(stderr, "Error: unsupported shell %q, expected bash, zsh or fish\n", shell)
+		return 1
+	}
+	fmt.Fprint(stdout, script())
+	return 0
+}
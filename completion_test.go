@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// parsedFlags returns the flags parseArgs has a case for, read from its source
+func parsedFlags(t *testing.T) []string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "main.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var flags []string
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "parseArgs" {
+			continue
+		}
+		ast.Inspect(fn, func(n ast.Node) bool {
+			clause, ok := n.(*ast.CaseClause)
+			if !ok {
+				return true
+			}
+			for _, expr := range clause.List {
+				if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					if s, _ := strconv.Unquote(lit.Value); strings.HasPrefix(s, "-") {
+						flags = append(flags, s)
+					}
+				}
+			}
+			return true
+		})
+	}
+	return flags
+}
+
+func TestCompletionCoversParsedFlags(t *testing.T) {
+	flags := parsedFlags(t)
+	if len(flags) == 0 {
+		t.Fatal("Expected to find the flags parseArgs handles")
+	}
+	names := allFlagNames()
+	for _, flag := range flags {
+		if !slices.Contains(names, flag) {
+			t.Errorf("Expected %s to be in completionFlags", flag)
+		}
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	tests := []struct {
+		shell    string
+		expected []string
+	}{
+		{"bash", []string{"complete -o default -F _clippycli clippycli", "--provider) COMPREPLY=($(compgen -W \"anthropic ollama openai\"", "--audit-log) COMPREPLY=($(compgen -f", "completion) COMPREPLY=($(compgen -W \"bash zsh fish\""}},
+		{"zsh", []string{"#compdef clippycli", "'(-q --quiet)'{-q,--quiet}'[Copy without printing the banner afterwards]'", "--provider'[Model provider]:NAME:(anthropic ollama openai)'", "--audit-log'[Log overrides of safety warnings to PATH]:PATH:_files'", "'*'--exec-allow", "compdef _clippycli clippycli"}},
+		{"fish", []string{"complete -c clippycli -s q -l quiet -d 'Copy without printing the banner afterwards'", "-l provider -x -a 'anthropic ollama openai'", "-l audit-log -r -F", "-l yes -d 'Copy the command without reviewing it, unless it\\'s dangerous'"}},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := runCompletion([]string{tt.shell}, &stdout, &stderr); code != 0 {
+			t.Fatalf("%s: expected success, got %d: %s", tt.shell, code, stderr.String())
+		}
+		for _, want := range tt.expected {
+			if !strings.Contains(stdout.String(), want) {
+				t.Errorf("%s: expected the script to contain %q, got:\n%s", tt.shell, want, stdout.String())
+			}
+		}
+	}
+}
+
+func TestRunCompletionRejectsUnknownShell(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runCompletion([]string{"tcsh"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected an unsupported shell to fail, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unsupported shell "tcsh"`) || stdout.Len() != 0 {
+		t.Errorf("Expected only an error, got stdout %q, stderr %q", stdout.String(), stderr.String())
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// configFileName is the user-wide defaults file in the config directory
+const configFileName = "config.toml"
+
+// settingType is the TOML type a config key takes
+type settingType int
+
+const (
+	settingBool   settingType = iota // true adds the flag, false leaves it off
+	settingString                    // Passed as the flag's value
+	settingInt                       // Passed as the flag's value
+	settingFloat                     // Passed as the flag's value
+	settingList                      // An array of strings, each passed to a repeatable flag
+)
+
+// configSetting maps a config key onto the command-line flag it sets
+type configSetting struct {
+	flag string
+	typ  settingType
+	env  string // Environment variable that takes precedence, if any
+}
+
+var configSettings = map[string]configSetting{
+	"model":            {flag: "--model", typ: settingString, env: modelEnv},
+	"provider":         {flag: "--provider", typ: settingString, env: providerEnv},
+	"verbose":          {flag: "-v", typ: settingBool},
+	"max_tokens":       {flag: "--max-tokens", typ: settingInt, env: maxTokensEnv},
+	"temperature":      {flag: "--temperature", typ: settingFloat},
+	"max_width":        {flag: "--max-width", typ: settingInt},
+	"retries":          {flag: "--retries", typ: settingInt},
+	"timeout":          {flag: "--timeout", typ: settingInt},
+	"retry_delay":      {flag: "--retry-delay", typ: settingInt},
+	"system_stats":     {flag: "--system-stats", typ: settingBool},
+	"git_context":      {flag: "--git-context", typ: settingBool},
+	"no_env":           {flag: "--no-env", typ: settingBool},
+	"notify":           {flag: "--notify", typ: settingBool},
+	"strict_confirm":   {flag: "--strict-confirm", typ: settingBool},
+	"legacy_keys":      {flag: "--legacy-keys", typ: settingBool},
+	"comment_style":    {flag: "--comment-style", typ: settingString},
+	"tool_versions":    {flag: "--tool-version", typ: settingList},
+	"context":          {flag: "--context", typ: settingList},
+	"exec_allow":       {flag: "--exec-allow", typ: settingList},
+	"exec_deny":        {flag: "--exec-deny", typ: settingList},
+	"theme":            {flag: "--theme", typ: settingString},
+	"lang":             {flag: "--lang", typ: settingString},
+	"no_highlight":     {flag: "--no-highlight", typ: settingBool},
+	"no_loading_hints": {flag: "--no-loading-hints", typ: settingBool},
+	"quiet":            {flag: "--quiet", typ: settingBool},
+	"osc52":            {flag: "--osc52", typ: settingBool},
+	"single_line":      {flag: "--single-line", typ: settingBool},
+	"no_cache":         {flag: "--no-cache", typ: settingBool},
+	"update_check":     {flag: "--update-check", typ: settingBool},
+}
+
+// themeTable is the one table allowed, holding name = "color" overrides
+const themeTable = "theme"
+
+// defaultConfigPath returns where the user-wide defaults are read from
+func defaultConfigPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// readConfig turns the config file at path into flags. It understands the
+// part of TOML a flat settings file needs: key = value lines holding strings,
+// integers, booleans or one-line arrays of strings, # comments, and a [theme]
+// table of colors. A key whose environment variable is set is skipped, since
+// the variable wins.
+func readConfig(path string, getenv func(string) string) ([]string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var args []string
+	table := ""
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		var flags []string
+		var err error
+		switch {
+		case strings.HasPrefix(line, "["):
+			table, err = configTable(line)
+		case table == themeTable:
+			flags, err = themeLine(line)
+		default:
+			flags, err = configLine(line, getenv)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		args = append(args, flags...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Catch values the flags themselves reject, like an unknown provider
+	if _, err := parseArgs(args); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return args, nil
+}
+
+// configLine turns one key = value line into the flags it stands for
+func configLine(line string, getenv func(string) string) ([]string, error) {
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return nil, fmt.Errorf("expected key = value, got %q", line)
+	}
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+
+	setting, ok := configSettings[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown setting %q", key)
+	}
+
+	switch setting.typ {
+	case settingBool:
+		switch value {
+		case "true":
+			return []string{setting.flag}, nil
+		case "false":
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s must be true or false, got %s", key, value)
+	case settingInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("%s must be a number, got %s", key, value)
+		}
+		if setting.env != "" && getenv(setting.env) != "" {
+			return nil, nil
+		}
+		return []string{setting.flag, value}, nil
+	case settingFloat:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("%s must be a number, got %s", key, value)
+		}
+		return []string{setting.flag, value}, nil
+	case settingList:
+		items, err := parseTOMLArray(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		var flags []string
+		for _, item := range items {
+			flags = append(flags, setting.flag, item)
+		}
+		return flags, nil
+	}
+
+	s, rest, err := parseTOMLString(value)
+	if err != nil || rest != "" {
+		return nil, fmt.Errorf("%s must be a quoted string, got %s", key, value)
+	}
+	if setting.env != "" && getenv(setting.env) != "" {
+		return nil, nil
+	}
+	return []string{setting.flag, s}, nil
+}
+
+// configTable reads a [table] header, returning the table's name
+func configTable(line string) (string, error) {
+	name := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "["), "]"))
+	if name != themeTable || !strings.HasSuffix(line, "]") {
+		return "", fmt.Errorf("unknown table %s; tables aren't supported apart from [theme], put settings at the top level", line)
+	}
+	return name, nil
+}
+
+// themeLine turns a name = "color" line in the [theme] table into a flag
+func themeLine(line string) ([]string, error) {
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return nil, fmt.Errorf("expected name = \"color\", got %q", line)
+	}
+	key = strings.TrimSpace(key)
+	color, rest, err := parseTOMLString(strings.TrimSpace(value))
+	if err != nil || rest != "" {
+		return nil, fmt.Errorf("theme.%s must be a quoted color, got %s", key, strings.TrimSpace(value))
+	}
+	return []string{"--theme-color", key + "=" + color}, nil
+}
+
+// stripComment removes a # comment, leaving any # inside a string alone
+func stripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseTOMLString reads a basic "string" or literal 'string' from the start
+// of s, returning it along with whatever follows
+func parseTOMLString(s string) (string, string, error) {
+	if strings.HasPrefix(s, "'") {
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", errors.New("unterminated string")
+		}
+		return s[1 : end+1], strings.TrimSpace(s[end+2:]), nil
+	}
+	if !strings.HasPrefix(s, `"`) {
+		return "", "", errors.New("expected a quoted string")
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			value, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", err
+			}
+			return value, strings.TrimSpace(s[i+1:]), nil
+		}
+	}
+	return "", "", errors.New("unterminated string")
+}
+
+// parseTOMLArray reads a one-line array of strings, like ["a", "b"]
+func parseTOMLArray(s string) ([]string, error) {
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return nil, errors.New("expected an array of strings on one line")
+	}
+	rest := strings.TrimSpace(s[1 : len(s)-1])
+	var items []string
+	for rest != "" {
+		item, after, err := parseTOMLString(rest)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if after == "" {
+			break
+		}
+		if !strings.HasPrefix(after, ",") {
+			return nil, fmt.Errorf("expected a comma after %q", item)
+		}
+		rest = strings.TrimSpace(after[1:])
+	}
+	return items, nil
+}
+
+// withConfigDefaults prepends the flags from the config file at path to args,
+// so that anything more specific takes precedence
+func withConfigDefaults(path string, args []string, getenv func(string) string) ([]string, error) {
+	defaults, err := readConfig(path, getenv)
+	if err != nil {
+		return nil, err
+	}
+	return append(defaults, args...), nil
+}
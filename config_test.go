@@ -0,0 +1,136 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), configFileName)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func noEnv(string) string { return "" }
+
+func TestConfigDefaults(t *testing.T) {
+	path := writeConfig(t, `# my defaults
+model = "haiku"
+verbose = true
+notify = false
+max_tokens = 2048 # room for scripts
+temperature = 0.0
+exec_allow = ["git status", 'ls *']
+`)
+
+	args, err := withConfigDefaults(path, []string{"list files"}, noEnv)
+	if err != nil {
+		t.Fatalf("Expected the config to load, got %v", err)
+	}
+	opts, err := parseArgs(args)
+	if err != nil {
+		t.Fatalf("parseArgs failed: %v", err)
+	}
+	if opts.model != "haiku" || !opts.verbose || opts.notify || opts.maxTokens != 2048 {
+		t.Errorf("Expected the config values, got model %q verbose %v notify %v max tokens %d", opts.model, opts.verbose, opts.notify, opts.maxTokens)
+	}
+	if opts.temperature == nil || *opts.temperature != 0 {
+		t.Errorf("Expected a temperature of 0, got %v", opts.temperature)
+	}
+	if strings.Join(opts.execAllow, "|") != "git status|ls *" {
+		t.Errorf("Expected both allow patterns, got %q", opts.execAllow)
+	}
+	if opts.prompt != "list files" {
+		t.Errorf("Expected prompt %q, got %q", "list files", opts.prompt)
+	}
+}
+
+func TestConfigUpdateCheck(t *testing.T) {
+	args, err := withConfigDefaults(writeConfig(t, "update_check = true\n"), nil, noEnv)
+	if err != nil {
+		t.Fatalf("Expected the config to load, got %v", err)
+	}
+	opts, err := parseArgs(args)
+	if err != nil || !opts.updateCheck {
+		t.Errorf("Expected update_check to opt in to update checks, got %v (err %v)", opts.updateCheck, err)
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	path := writeConfig(t, "model = \"haiku\"\nmax_tokens = 2048\nprovider = \"openai\"\n")
+
+	// Flags beat the config
+	args, err := withConfigDefaults(path, []string{"--max-tokens", "512"}, noEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts, _ := parseArgs(args); opts.maxTokens != 512 {
+		t.Errorf("Expected the flag to win, got %d", opts.maxTokens)
+	}
+
+	// Environment variables beat the config too
+	env := map[string]string{modelEnv: "opus"}
+	args, err = withConfigDefaults(path, nil, func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, _ := parseArgs(args)
+	if opts.model != "" || opts.provider != "openai" {
+		t.Errorf("Expected $%s to override the config's model only, got model %q provider %q", modelEnv, opts.model, opts.provider)
+	}
+}
+
+func TestConfigMissingIsNoOp(t *testing.T) {
+	args, err := withConfigDefaults(filepath.Join(t.TempDir(), configFileName), []string{"-v"}, noEnv)
+	if err != nil || len(args) != 1 || args[0] != "-v" {
+		t.Errorf("Expected the args unchanged without a config, got %q (err %v)", args, err)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"colour = \"red\"\n", `:1: unknown setting "colour"`},
+		{"\nverbose = yes\n", ":2: verbose must be true or false"},
+		{"max_tokens = \"lots\"\n", "max_tokens must be a number"},
+		{"temperature = \"cold\"\n", "temperature must be a number"},
+		{"model = haiku\n", "model must be a quoted string"},
+		{"[defaults]\n", "tables aren't supported"},
+		{"[theme]\nhelp = \"grey\"\n", "must be a hex color"},
+		{"[theme]\nhelp = #333333\n", "theme.help must be a quoted color"},
+		{"exec_deny = [\"rm *\" \"dd *\"]\n", "expected a comma"},
+		{"provider = \"acme\"\n", "--provider must be"},
+	}
+	for _, tt := range tests {
+		_, err := readConfig(writeConfig(t, tt.content), noEnv)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: expected an error containing %q, got %v", tt.content, tt.want, err)
+		}
+	}
+}
+
+func TestConfigTheme(t *testing.T) {
+	path := writeConfig(t, "theme = \"light\"\n\n[theme]\nhelp = \"#333333\" # easier to read\n")
+
+	args, err := readConfig(path, noEnv)
+	if err != nil {
+		t.Fatalf("Expected the config to load, got %v", err)
+	}
+	opts, _ := parseArgs(args)
+	if opts.theme != "light" || len(opts.themeColors) != 1 || opts.themeColors[0] != "help=#333333" {
+		t.Errorf("Expected the light theme with the help color overridden, got %q %q", opts.theme, opts.themeColors)
+	}
+}
+
+func TestStripComment(t *testing.T) {
+	if got := stripComment(`model = "a#b" # note`); got != `model = "a#b" ` {
+		t.Errorf("Expected the # inside the string to be kept, got %q", got)
+	}
+}
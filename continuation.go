@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/benmyles/clippycli/clippy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// generationInterruptedMsg reports a generation that stopped partway through,
+// keeping whatever output had already arrived
+type generationInterruptedMsg struct {
+	partial string
+	err     error
+}
+
+// continuationMessages builds a conversation that asks the model to pick up
+// exactly where the partial output left off, by prefilling its reply
+func continuationMessages(prompt, partial string) []clippy.Message {
+	return []clippy.Message{
+		clippy.UserMessage(prompt),
+		// The API rejects prefills that end in whitespace
+		clippy.AssistantMessage(strings.TrimRight(partial, " \t\r\n")),
+	}
+}
+
+// assembleContinuation joins the partial output with the model's continuation
+func assembleContinuation(partial, continuation string) string {
+	return strings.TrimSpace(strings.TrimRight(partial, " \t\r\n") + continuation)
+}
+
+// continueGeneration asks the model to complete an interrupted generation
+func (m model) continueGeneration() tea.Cmd {
+	partial := m.partialCmd
+	return func() tea.Msg {
+		ctx, onText := m.live.begin()
+		defer m.live.end()
+
+		systemPrompt := m.systemPrompt(getEnvironmentInfo(m.envOptions()))
+		fullPrompt := fmt.Sprintf("System: %s\n\nUser: %s\n\nAssistant (partial): %s", systemPrompt, m.prompt, partial)
+
+		// Show the continuation growing from what already arrived
+		if m.responseFormat() != "" {
+			onText = nil
+		} else {
+			onText(strings.TrimRight(partial, " \t\r\n"))
+		}
+
+		// A stalled continuation shouldn't leave the spinner going forever
+		ctx, cancel := m.withTimeout(ctx)
+		defer cancel()
+
+		// The continuation's leading whitespace matters, so it isn't trimmed
+		continuation, err := m.generator.Generate(ctx, systemPrompt, continuationMessages(m.prompt, partial), onText)
+		if err = m.timeoutError(ctx, err); err != nil {
+			return generationInterruptedMsg{partial: partial, err: err}
+		}
+
+		msg := m.finishGeneration(assembleContinuation(partial, continuation.Text), fullPrompt)
+		msg.stopReason = continuation.StopReason
+		msg.inputTokens = continuation.InputTokens
+		msg.outputTokens = continuation.OutputTokens
+		return msg
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/benmyles/clippycli/clippy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestContinuationMessages(t *testing.T) {
+	messages := continuationMessages("find go files", "find . -name \"*.go\" -mtime ")
+
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+
+	if messages[0].Role != clippy.RoleUser || messages[0].Text != "find go files" {
+		t.Error("Expected the first message to be the user's prompt")
+	}
+
+	// The partial output should be sent back as the start of the model's reply
+	if messages[1].Role != clippy.RoleAssistant {
+		t.Errorf("Expected the second message to be from the assistant, got %v", messages[1].Role)
+	}
+	if messages[1].Text != "find . -name \"*.go\" -mtime" {
+		t.Errorf("Expected the partial output without trailing whitespace, got %q", messages[1].Text)
+	}
+}
+
+func TestAssembleContinuation(t *testing.T) {
+	tests := []struct {
+		partial      string
+		continuation string
+		expected     string
+	}{
+		{"find . -name", ` "*.go"`, `find . -name "*.go"`},
+		{"find . -name ", ` "*.go"`, `find . -name "*.go"`},
+		{"tar -cz", "f out.tgz src\n", "tar -czf out.tgz src"},
+	}
+
+	for _, tt := range tests {
+		if result := assembleContinuation(tt.partial, tt.continuation); result != tt.expected {
+			t.Errorf("assembleContinuation(%q, %q) = %q; want %q", tt.partial, tt.continuation, result, tt.expected)
+		}
+	}
+}
+
+func TestInterruptedGenerationChoice(t *testing.T) {
+	testModel := initialModel("find go files", false)
+
+	updatedModel, _ := testModel.Update(generationInterruptedMsg{partial: "find . -na", err: errors.New("connection reset")})
+	m := updatedModel.(model)
+	if m.state != stateInterrupted {
+		t.Fatalf("Expected state to be stateInterrupted, got %v", m.state)
+	}
+	if m.partialCmd != "find . -na" {
+		t.Errorf("Expected partial output to be kept, got %q", m.partialCmd)
+	}
+	if view := m.View(); !strings.Contains(view, "continue") || !strings.Contains(view, "start over") {
+		t.Error("Expected the view to offer continue and start over")
+	}
+
+	// Continuing keeps the partial output for the continuation request
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	m = updatedModel.(model)
+	if m.state != stateLoading || cmd == nil {
+		t.Error("Expected pressing c to continue generating")
+	}
+	if m.partialCmd != "find . -na" {
+		t.Error("Expected continuing to keep the partial output")
+	}
+}
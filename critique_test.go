@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/benmyles/clippycli/clippy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCritiqueMessages(t *testing.T) {
+	messages := critiqueMessages("delete old logs", "find . -name '*.log' -delete")
+
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(messages))
+	}
+	if messages[0].Text != "delete old logs" {
+		t.Error("Expected the first message to be the user's prompt")
+	}
+
+	// The command under review should be the model's own earlier reply
+	if messages[1].Role != clippy.RoleAssistant {
+		t.Errorf("Expected the command to be sent as the assistant's reply, got %v", messages[1].Role)
+	}
+	if messages[1].Text != "find . -name '*.log' -delete" {
+		t.Errorf("Expected the current command to be included, got %q", messages[1].Text)
+	}
+
+	if messages[2].Role != clippy.RoleUser || !strings.Contains(messages[2].Text, "Review") {
+		t.Error("Expected the last message to ask for a critique")
+	}
+}
+
+func TestCritiqueKeyStartsReview(t *testing.T) {
+	var m tea.Model = initialModel("delete old logs", false)
+	m, _ = m.Update(cmdGeneratedMsg{cmd: "find . -name '*.log' -delete"})
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
+	if !m.(model).critiquing {
+		t.Error("Expected K to start a critique")
+	}
+	if m.(model).state != stateResult {
+		t.Error("Expected to stay on the result screen while critiquing")
+	}
+	if cmd == nil {
+		t.Error("Expected a command to request the critique")
+	}
+}
+
+func TestCritiqueResponse(t *testing.T) {
+	var m tea.Model = initialModel("delete old logs", false)
+	m, _ = m.Update(cmdGeneratedMsg{cmd: "find . -name '*.log' -delete"})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'k'}})
+
+	m, _ = m.Update(critiqueMsg{text: "- Also matches logs in subdirectories"})
+	result := m.(model)
+	if result.critiquing {
+		t.Error("Expected critiquing to stop once the response arrives")
+	}
+	if result.critique != "- Also matches logs in subdirectories" {
+		t.Errorf("Expected the response in the critique field, got %q", result.critique)
+	}
+	if result.generatedCmd != "find . -name '*.log' -delete" {
+		t.Error("Expected the command to be left unchanged by a critique")
+	}
+	if !strings.Contains(result.View(), "Also matches logs in subdirectories") {
+		t.Error("Expected the critique to be shown on the result screen")
+	}
+
+	m, _ = m.Update(critiqueMsg{err: errors.New("overloaded")})
+	if m.(model).critiqueErr == nil || !strings.Contains(m.(model).View(), "could not get a critique") {
+		t.Error("Expected a failed critique to be reported")
+	}
+
+	// A new command invalidates the old critique
+	m, _ = m.Update(cmdGeneratedMsg{cmd: "ls"})
+	if m.(model).critique != "" || m.(model).critiqueErr != nil {
+		t.Error("Expected the critique to be cleared for a new command")
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+// daemonDialogText is the question in the pop-up prompt
+const daemonDialogText = "Describe the command you need:"
+
+// daemon serves hotkey presses for `clippycli daemon`: each press asks for a
+// prompt in a small dialog, generates a command the same way --widget does,
+// and copies it
+type daemon struct {
+	m       model
+	ask     func(ctx context.Context) (prompt string, ok bool, err error)
+	confirm func(ctx context.Context, question string) (ok bool, err error)
+	copy    func(cmd string) error
+	notify  func(title, body string)
+}
+
+// run handles triggers one at a time until ctx is cancelled
+func (d daemon) run(ctx context.Context, triggers <-chan struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-triggers:
+			d.handle(ctx)
+		}
+	}
+}
+
+// handle serves a single hotkey press. Problems are reported as
+// notifications, since there's no terminal to show them in.
+func (d daemon) handle(ctx context.Context) {
+	prompt, ok, err := d.ask(ctx)
+	if err != nil {
+		d.notify("ClippyCLI: could not ask for a prompt", err.Error())
+		return
+	}
+	prompt = strings.TrimSpace(prompt)
+	if !ok || prompt == "" {
+		return
+	}
+
+	m := d.m
+	m.prompt = prompt
+	cmd, err := generationResult(m.generateCommand()())
+	if err != nil {
+		d.notify("ClippyCLI: generation failed", err.Error())
+		return
+	}
+
+	// There's no review screen, so a command that needs a second look is
+	// only copied once it's been agreed to in a dialog
+	if question := daemonConfirmQuestion(cmd); question != "" {
+		ok, err := d.confirm(ctx, question)
+		if err != nil {
+			d.notify("ClippyCLI: could not ask for confirmation", err.Error())
+			return
+		}
+		if !ok {
+			d.notify("ClippyCLI: command not copied", cmd)
+			return
+		}
+	}
+
+	if err := d.copy(cmd); err != nil {
+		d.notify("ClippyCLI: could not copy the command", err.Error())
+		return
+	}
+
+	// Say what it does once more, since the clipboard doesn't show it
+	if dangerous, reason := isDangerous(cmd); dangerous {
+		d.notify("ClippyCLI: copied a dangerous command", "This command "+reason+":\n"+cmd)
+		return
+	}
+	d.notify("ClippyCLI: command copied", cmd)
+}
+
+// daemonConfirmQuestion asks whether to copy cmd anyway when it runs a
+// downloaded script or is flagged as dangerous, or returns "" when it needs
+// no confirmation
+func daemonConfirmQuestion(cmd string) string {
+	reason := pipeToShellWarning(cmd)
+	if reason == "" {
+		var dangerous bool
+		if dangerous, reason = isDangerous(cmd); !dangerous {
+			return ""
+		}
+		reason = "this command " + reason
+	}
+	return "Warning: " + reason + ":\n\n" + cmd + "\n\nCopy it anyway?"
+}
+
+// promptDialogCommand returns a command that shows a dialog asking for a
+// prompt and prints the answer, or nil if no dialog tool is available
+func promptDialogCommand(ctx context.Context, goos string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		script := "text returned of (display dialog " + appleScriptString(daemonDialogText) + ` default answer "" with title "ClippyCLI")`
+		return exec.CommandContext(ctx, "osascript", "-e", script)
+	case "windows":
+		script := "Add-Type -AssemblyName Microsoft.VisualBasic; [Microsoft.VisualBasic.Interaction]::InputBox(" + powerShellString(daemonDialogText) + ", 'ClippyCLI')"
+		return exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	}
+	if _, err := exec.LookPath("zenity"); err == nil {
+		return exec.CommandContext(ctx, "zenity", "--entry", "--title=ClippyCLI", "--text="+daemonDialogText)
+	}
+	if _, err := exec.LookPath("kdialog"); err == nil {
+		return exec.CommandContext(ctx, "kdialog", "--title", "ClippyCLI", "--inputbox", daemonDialogText)
+	}
+	return nil
+}
+
+// confirmDialogCommand returns a command that shows question with buttons to
+// go ahead or cancel, exiting non-zero unless the user goes ahead, or nil if
+// no dialog tool is available
+func confirmDialogCommand(ctx context.Context, goos, question string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		script := "display dialog " + appleScriptString(question) + ` buttons {"Cancel", "Copy"} default button "Cancel" with title "ClippyCLI" with icon caution`
+		return exec.CommandContext(ctx, "osascript", "-e", script)
+	case "windows":
+		script := "Add-Type -AssemblyName PresentationFramework; if ([System.Windows.MessageBox]::Show(" + powerShellString(question) + ", 'ClippyCLI', 'YesNo', 'Warning') -ne 'Yes') { exit 1 }"
+		return exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	}
+	if _, err := exec.LookPath("zenity"); err == nil {
+		return exec.CommandContext(ctx, "zenity", "--question", "--no-markup", "--title=ClippyCLI", "--text="+question)
+	}
+	if _, err := exec.LookPath("kdialog"); err == nil {
+		return exec.CommandContext(ctx, "kdialog", "--title", "ClippyCLI", "--warningyesno", question)
+	}
+	return nil
+}
+
+// askConfirm shows the confirmation dialog, reporting whether the user
+// agreed. Without a dialog tool nothing can be confirmed, so it's an error.
+func askConfirm(ctx context.Context, question string) (bool, error) {
+	cmd := confirmDialogCommand(ctx, runtime.GOOS, question)
+	if cmd == nil {
+		return false, errors.New("no dialog tool found, install zenity or kdialog")
+	}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// askPrompt shows the prompt dialog, reporting false if it was cancelled
+func askPrompt(ctx context.Context) (string, bool, error) {
+	cmd := promptDialogCommand(ctx, runtime.GOOS)
+	if cmd == nil {
+		return "", false, errors.New("no dialog tool found, install zenity or kdialog")
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		// Dialog tools exit non-zero when cancelled
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return string(out), true, nil
+}
+
+// runDaemon implements `clippycli daemon`: it waits for the global hotkey
+// until interrupted, returning the process exit code
+func runDaemon(m model, stdout, stderr io.Writer) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// One pending press is enough; more while a prompt is open are dropped
+	triggers := make(chan struct{}, 1)
+	listenErr := make(chan error, 1)
+	go func() { listenErr <- listenHotkey(ctx, triggers) }()
+
+	d := daemon{m: m, ask: askPrompt, confirm: askConfirm, copy: copyToClipboard, notify: notify}
+	done := make(chan struct{})
+	go func() {
+		d.run(ctx, triggers)
+		close(done)
+	}()
+
+	fmt.Fprintf(stdout, "ClippyCLI daemon running. %s Press Ctrl+C to stop.\n", daemonHotkeyHelp)
+
+	// The listener stops on a signal, or fails to start
+	err := <-listenErr
+	stop()
+	<-done
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
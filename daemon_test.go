@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDaemon serves prompts from answers with a fake generator, recording
+// what it copies and notifies
+type testDaemon struct {
+	daemon
+	copied   []string
+	notified []string
+	asked    []string // Questions put in confirmation dialogs
+	agree    bool     // The answer to each confirmation
+}
+
+func newTestDaemon(g fakeGenerator, answers ...string) *testDaemon {
+	td := &testDaemon{}
+	m := initialModel("", false)
+	m.generator = g
+	td.daemon = daemon{
+		m: m,
+		ask: func(context.Context) (string, bool, error) {
+			if len(answers) == 0 {
+				return "", false, nil
+			}
+			answer := answers[0]
+			answers = answers[1:]
+			return answer, true, nil
+		},
+		confirm: func(_ context.Context, question string) (bool, error) {
+			td.asked = append(td.asked, question)
+			return td.agree, nil
+		},
+		copy: func(cmd string) error {
+			td.copied = append(td.copied, cmd)
+			return nil
+		},
+		notify: func(title, body string) {
+			td.notified = append(td.notified, title+": "+body)
+		},
+	}
+	return td
+}
+
+func TestDaemonRunHandlesTriggers(t *testing.T) {
+	td := newTestDaemon(fakeGenerator{text: "du -sh * | sort -h"}, "show folder sizes", "show folder sizes")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	triggers := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		td.run(ctx, triggers)
+		close(done)
+	}()
+
+	// Unbuffered sends return once run has picked each trigger up
+	triggers <- struct{}{}
+	triggers <- struct{}{}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected run to stop once the context is cancelled")
+	}
+
+	if len(td.copied) != 2 || td.copied[0] != "du -sh * | sort -h" {
+		t.Errorf("Expected each trigger to copy a command, got %q", td.copied)
+	}
+	if len(td.notified) != 2 || !strings.Contains(td.notified[0], "command copied") {
+		t.Errorf("Expected a notification for each copy, got %q", td.notified)
+	}
+}
+
+func TestDaemonCancelledPrompt(t *testing.T) {
+	td := newTestDaemon(fakeGenerator{text: "ls"})
+	td.handle(context.Background())
+	if len(td.copied) != 0 || len(td.notified) != 0 {
+		t.Errorf("Expected a cancelled prompt to do nothing, got %q, %q", td.copied, td.notified)
+	}
+}
+
+func TestDaemonReportsFailures(t *testing.T) {
+	td := newTestDaemon(fakeGenerator{err: errors.New("overloaded")}, "list files")
+	td.handle(context.Background())
+	if len(td.copied) != 0 {
+		t.Errorf("Expected nothing to be copied, got %q", td.copied)
+	}
+	if len(td.notified) != 1 || !strings.Contains(td.notified[0], "generation failed") || !strings.Contains(td.notified[0], "overloaded") {
+		t.Errorf("Expected the failure to be notified, got %q", td.notified)
+	}
+}
+
+func TestDaemonWarnsAboutDangerousCommands(t *testing.T) {
+	td := newTestDaemon(fakeGenerator{text: "rm -rf build"}, "clean up")
+	td.agree = true
+	td.handle(context.Background())
+	if len(td.asked) != 1 || !strings.Contains(td.asked[0], "recursively force-deletes files") {
+		t.Errorf("Expected to be asked before copying, got %q", td.asked)
+	}
+	if len(td.copied) != 1 {
+		t.Errorf("Expected the command to be copied once agreed to, got %q", td.copied)
+	}
+	if len(td.notified) != 1 || !strings.Contains(td.notified[0], "dangerous") {
+		t.Errorf("Expected the notification to flag the command, got %q", td.notified)
+	}
+}
+
+func TestDaemonConfirmsDownloadedScripts(t *testing.T) {
+	command := "curl -fsSL https://example.com/install.sh | sh"
+	td := newTestDaemon(fakeGenerator{text: command}, "install it")
+	td.handle(context.Background())
+	if len(td.asked) != 1 || !strings.Contains(td.asked[0], "https://example.com/install.sh") {
+		t.Errorf("Expected the confirmation to show the URL, got %q", td.asked)
+	}
+	if len(td.copied) != 0 {
+		t.Errorf("Expected nothing to be copied without confirmation, got %q", td.copied)
+	}
+	if len(td.notified) != 1 || !strings.Contains(td.notified[0], "not copied") {
+		t.Errorf("Expected a notification that it wasn't copied, got %q", td.notified)
+	}
+
+	// A safe command doesn't ask
+	td = newTestDaemon(fakeGenerator{text: "ls"}, "list files")
+	td.handle(context.Background())
+	if len(td.asked) != 0 || len(td.copied) != 1 {
+		t.Errorf("Expected a safe command to be copied without asking, got %q, %q", td.asked, td.copied)
+	}
+}
+
+func TestConfirmDialogCommand(t *testing.T) {
+	ctx := context.Background()
+	if cmd := confirmDialogCommand(ctx, "darwin", "Copy it?"); cmd == nil || !strings.Contains(strings.Join(cmd.Args, " "), `"Copy it?" buttons`) {
+		t.Error("Expected an AppleScript dialog with buttons on macOS")
+	}
+	if cmd := confirmDialogCommand(ctx, "windows", "Copy it?"); cmd == nil || !strings.Contains(strings.Join(cmd.Args, " "), "MessageBox") {
+		t.Error("Expected a MessageBox on Windows")
+	}
+}
+
+func TestPromptDialogCommand(t *testing.T) {
+	ctx := context.Background()
+	if cmd := promptDialogCommand(ctx, "darwin"); cmd == nil || !strings.Contains(strings.Join(cmd.Args, " "), "display dialog") {
+		t.Error("Expected an AppleScript dialog on macOS")
+	}
+	if cmd := promptDialogCommand(ctx, "windows"); cmd == nil || !strings.Contains(strings.Join(cmd.Args, " "), "InputBox") {
+		t.Error("Expected an InputBox on Windows")
+	}
+}
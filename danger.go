@@ -0,0 +1,144 @@
+package main
+
+import (
+	"regexp"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dangerPattern flags a class of destructive command
+type dangerPattern struct {
+	re     *regexp.Regexp
+	reason string
+}
+
+// rmRecursiveForce matches rm given both a recursive flag (-r, -R or
+// --recursive) and a force flag (-f or --force) anywhere in the same command.
+// Short flags may be combined (-rf) or given separately, and mixed with long
+// ones (-r --force). Flags on a later line belong to another command.
+var rmRecursiveForce = func() string {
+	const (
+		args      = `[^;&|\n]*?`
+		recursive = `[ \t](-[a-zA-Z]*[rR][a-zA-Z]*|--recursive)\b`
+		force     = `[ \t](-[a-zA-Z]*f[a-zA-Z]*|--force)\b`
+		combined  = `[ \t]-[a-zA-Z]*([rR][a-zA-Z]*f|f[a-zA-Z]*[rR])[a-zA-Z]*\b`
+	)
+	return `\brm\b` + args + `(` + combined + `|` + recursive + args + force + `|` + force + args + recursive + `)`
+}()
+
+var dangerPatterns = []dangerPattern{
+	{regexp.MustCompile(rmRecursiveForce), "recursively force-deletes files"},
+	{regexp.MustCompile(`\bdd\b.*\bof=`), "writes raw data to a file or device with dd"},
+	{regexp.MustCompile(`\bmkfs(\.[a-z0-9]+)?\b`), "formats a filesystem"},
+	{regexp.MustCompile(`:\(\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`), "is a fork bomb that will hang the system"},
+	{regexp.MustCompile(`\b(curl|wget)\b[^|]*\|\s*(sudo\s+)?(ba|z|k|da|fi)?sh\b`), "downloads and runs a script without review"},
+	{regexp.MustCompile(`>\s*/dev/(sd[a-z]|nvme\d|disk\d|hd[a-z]|mmcblk\d)`), "overwrites a disk device"},
+}
+
+// isDangerous reports whether cmd matches a known destructive pattern, along
+// with a human-readable reason. Every line of a multi-line command is checked,
+// with lines continued by a backslash read as one.
+func isDangerous(cmd string) (bool, string) {
+	return matchPatterns(dangerPatterns, joinContinuations(cmd))
+}
+
+// joinContinuations joins lines ending in a backslash onto the next, as the
+// shell does
+func joinContinuations(cmd string) string {
+	return continuationRe.ReplaceAllString(cmd, " ")
+}
+
+// continuationRe matches a backslash ending a line, and the line break
+var continuationRe = regexp.MustCompile(`\\\r?\n[ \t]*`)
+
+// matchPatterns reports whether s matches any of patterns, along with the
+// reason of the first that does
+func matchPatterns(patterns []dangerPattern, s string) (bool, string) {
+	for _, p := range patterns {
+		if p.re.MatchString(s) {
+			return true, p.reason
+		}
+	}
+	return false, ""
+}
+
+// copyOrConfirm copies the generated command, or first shows whichever
+// confirmation screen it needs
+func (m model) copyOrConfirm() (model, tea.Cmd) {
+	// Running a downloaded script always needs a look at the URL first
+	if _, found := detectPipeToShell(m.generatedCmd); found {
+		m.state = statePipeConfirm
+		return m, nil
+	}
+	// Strict mode shows the explanation with its own prompt
+	if dangerous, _ := isDangerous(m.generatedCmd); dangerous && !m.opts.strictConfirm {
+		m.state = stateExplainConfirm
+		return m, nil
+	}
+	return m.copyWithConfirmation()
+}
+
+// autoCopy copies the finished command without waiting for Enter when --yes
+// is set, after running before, so the TUI doesn't quit ahead of it. Dangerous
+// commands still stop at their confirmation, and alternatives still need one
+// picking.
+func (m model) autoCopy(before tea.Cmd) (model, tea.Cmd) {
+	if !m.opts.yes || m.generatedCmd == "" || len(m.generatedCmds) > 1 {
+		return m, before
+	}
+	m, cmd := m.copyOrConfirm()
+	return m, tea.Sequence(before, cmd)
+}
+
+// copyWithConfirmation copies the generated command, first asking for the
+// confirmation phrase if it's dangerous and --strict-confirm is set
+func (m model) copyWithConfirmation() (model, tea.Cmd) {
+	// The riskiest commands need the confirmation phrase typed out
+	if dangerous, _ := isDangerous(m.generatedCmd); dangerous && m.opts.strictConfirm {
+		m.state = stateStrictConfirm
+		m.confirmMismatch = false
+		m.textarea.SetValue("")
+		m.textarea.Focus()
+		return m, textarea.Blink
+	}
+	return m, m.executeCommand()
+}
+
+var (
+	// pipeToShellRe matches a download piped into a shell, e.g. curl URL | sudo bash
+	pipeToShellRe = regexp.MustCompile(`\b(curl|wget|fetch)\b[^|;&]*\|\s*(sudo\s+(-\S+\s+)*)?(env\s+)?(/\S*/)?(ba|z|k|da|fi|c|tc|a)?sh\b`)
+
+	// shellOfDownloadRe matches a shell running a download directly, e.g.
+	// bash <(curl URL) or sh -c "$(wget -O- URL)"
+	shellOfDownloadRe = regexp.MustCompile(`\b(ba|z|k|da|fi|c|tc|a)?sh\b\s+(-\S+\s+)*(<\(|["']?\$\()\s*(curl|wget|fetch)\b[^)]*\)`)
+
+	downloadURLRe = regexp.MustCompile(`(?:https?|ftp)://[^\s'"|;&()<>]+`)
+)
+
+// pipeToShellWarning says which URL a command that runs a downloaded script
+// fetches, for outputs that have no confirmation screen to show it on. It's
+// empty for any other command.
+func pipeToShellWarning(cmd string) string {
+	url, found := detectPipeToShell(cmd)
+	if !found {
+		return ""
+	}
+	if url == "" {
+		url = "an unknown URL"
+	}
+	return "this command downloads a script and runs it straight away, fetching and executing " + url
+}
+
+// detectPipeToShell reports whether cmd downloads a script and runs it without
+// saving it first, returning the URL being fetched when it can be found
+func detectPipeToShell(cmd string) (url string, found bool) {
+	match := pipeToShellRe.FindString(cmd)
+	if match == "" {
+		match = shellOfDownloadRe.FindString(cmd)
+	}
+	if match == "" {
+		return "", false
+	}
+	return downloadURLRe.FindString(match), true
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestIsDangerousBasics(t *testing.T) {
+	if dangerous, reason := isDangerous("rm -rf build"); !dangerous || reason == "" {
+		t.Error("Expected rm -rf to be flagged with a reason")
+	}
+	if dangerous, _ := isDangerous("ls -la"); dangerous {
+		t.Error("Expected ls -la not to be flagged")
+	}
+}
+
+func TestIsDangerous(t *testing.T) {
+	tests := []struct {
+		cmd       string
+		dangerous bool
+	}{
+		{"rm -rf /tmp/build", true},
+		{"rm -fr ~/old", true},
+		{"rm -r -f logs", true},
+		{"rm -f -r logs", true},
+		{"rm --recursive --force dist", true},
+		{"rm -Rf /", true},
+		{"rm -fR build", true},
+		{"rm -R -f /", true},
+		{"rm -r --force x", true},
+		{"rm --recursive -f x", true},
+		{"rm -f --recursive x", true},
+		{"rm --force -R x", true},
+		{"rm build -rf", true},
+		{"ls && rm -v -R -i -f logs", true},
+		{"sudo dd if=ubuntu.iso of=/dev/sdb bs=4M", true},
+		{"mkfs.ext4 /dev/sdb1", true},
+		{":(){ :|:& };:", true},
+		{"curl -fsSL https://example.com/install.sh | sh", true},
+		{"wget -qO- https://example.com/setup | sudo bash", true},
+		{"cat image.img > /dev/sda", true},
+		{"ls -la", false},
+		{"rm old.log", false},
+		{"rm -r empty_dir", false},
+		{"rm -R empty_dir", false},
+		{"rm -f stale.lock", false},
+		{"rm --force my-file", false},
+		{"rm -r build; touch -f x", false},
+		{"rm -r old-f", false},
+		{"perm -rf", false},
+		{"dd if=/dev/zero bs=1M count=1", false},
+		{"curl -o install.sh https://example.com/install.sh", false},
+		{"echo done > /dev/null", false},
+		{"grep -rf patterns.txt src", false},
+	}
+	for _, tt := range tests {
+		dangerous, reason := isDangerous(tt.cmd)
+		if dangerous != tt.dangerous {
+			t.Errorf("%q: expected dangerous=%v, got %v", tt.cmd, tt.dangerous, dangerous)
+		}
+		if dangerous && reason == "" {
+			t.Errorf("%q: expected a reason", tt.cmd)
+		}
+	}
+}
+
+func TestIsDangerousMultiLine(t *testing.T) {
+	for _, cmd := range []string{
+		"mkdir -p backup\nrm -rf build",
+		"rm \\\n  -rf build",
+		"rm -r \\\r\n  -f build",
+	} {
+		if dangerous, _ := isDangerous(cmd); !dangerous {
+			t.Errorf("Expected %q to be flagged", cmd)
+		}
+	}
+	if dangerous, _ := isDangerous("rm -r \\\nbuild\n-f"); dangerous {
+		t.Error("Expected a flag on a separate command not to count")
+	}
+}
+
+func TestResultWarnsAboutDangerousCommand(t *testing.T) {
+	m := initialModel("clean up", false)
+	m.state = stateResult
+	m.generatedCmd = "rm -rf build"
+	if !strings.Contains(m.View(), "Warning: this command recursively force-deletes files") {
+		t.Error("Expected the result screen to warn before anything is copied")
+	}
+
+	m.generatedCmd = "ls"
+	if strings.Contains(m.View(), "Warning: this command") {
+		t.Error("Expected no warning for a safe command")
+	}
+}
+
+func typeText(m tea.Model, text string) tea.Model {
+	for _, r := range text {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return m
+}
+
+func TestStrictConfirmMatch(t *testing.T) {
+	written := stubClipboard(t)
+
+	testModel := initialModel("clean up", false)
+	testModel.opts.strictConfirm = true
+	var updatedModel tea.Model = testModel
+	updatedModel, _ = updatedModel.Update(cmdGeneratedMsg{cmd: "rm -rf build"})
+
+	// Enter on a dangerous command asks for the confirmation phrase
+	updatedModel, cmd := updatedModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m := updatedModel.(model)
+	if m.state != stateStrictConfirm {
+		t.Fatalf("Expected state to be stateStrictConfirm, got %v", m.state)
+	}
+	if cmd != nil {
+		if _, copied := cmd().(cmdCopiedMsg); copied {
+			t.Fatal("Expected no copy before the phrase is typed")
+		}
+	}
+
+	// A mismatched phrase is rejected
+	updatedModel = typeText(updatedModel, "r")
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updatedModel.(model)
+	if m.state != stateStrictConfirm || !m.confirmMismatch {
+		t.Fatal("Expected a mismatched phrase to be rejected")
+	}
+
+	// The exact phrase proceeds to copy
+	updatedModel = typeText(updatedModel, "m")
+	updatedModel, cmd = updatedModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("Expected the exact phrase to proceed")
+	}
+	if msg, ok := cmd().(cmdCopiedMsg); !ok || msg.cmd != "rm -rf build" || *written != "rm -rf build" {
+		t.Error("Expected the dangerous command to be copied after confirming")
+	}
+}
+
+func TestStrictConfirmSkipsSafeCommands(t *testing.T) {
+	stubClipboard(t)
+
+	testModel := initialModel("list files", false)
+	testModel.opts.strictConfirm = true
+	updatedModel, _ := testModel.Update(cmdGeneratedMsg{cmd: "ls -la"})
+
+	_, cmd := updatedModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("Expected safe commands to copy immediately")
+	}
+	if _, ok := cmd().(cmdCopiedMsg); !ok {
+		t.Error("Expected a cmdCopiedMsg for a safe command")
+	}
+}
+
+func TestDetectPipeToShell(t *testing.T) {
+	tests := []struct {
+		cmd   string
+		found bool
+		url   string
+	}{
+		{"curl -fsSL https://get.docker.com | sh", true, "https://get.docker.com"},
+		{"curl -sSf https://sh.rustup.rs | bash -s -- -y", true, "https://sh.rustup.rs"},
+		{"wget -qO- https://example.com/install.sh | sudo bash", true, "https://example.com/install.sh"},
+		{"wget -O - 'https://example.com/i.sh' | sudo -E zsh", true, "https://example.com/i.sh"},
+		{"curl -L https://example.com/setup.fish | fish", true, "https://example.com/setup.fish"},
+		{"curl https://example.com/x.sh | /bin/bash", true, "https://example.com/x.sh"},
+		{"curl http://example.com/x.sh | dash", true, "http://example.com/x.sh"},
+		{`bash <(curl -s https://example.com/x.sh)`, true, "https://example.com/x.sh"},
+		{`sh -c "$(wget -qO- https://example.com/x.sh)"`, true, "https://example.com/x.sh"},
+		{"curl $INSTALL_URL | sh", true, ""},
+		{"curl -o install.sh https://example.com/install.sh", false, ""},
+		{"curl -s https://api.github.com/repos/foo/bar | jq .stars", false, ""},
+		{"curl https://example.com/keys | ssh-add -", false, ""},
+		{"ls | shasum", false, ""},
+	}
+
+	for _, test := range tests {
+		url, found := detectPipeToShell(test.cmd)
+		if found != test.found || url != test.url {
+			t.Errorf("detectPipeToShell(%q): expected (%q, %v), got (%q, %v)", test.cmd, test.url, test.found, url, found)
+		}
+	}
+}
+
+func TestPipeToShellAlwaysConfirms(t *testing.T) {
+	written := stubClipboard(t)
+
+	// No other confirmation is enabled, but the URL must still be shown first
+	var m tea.Model = initialModel("install docker", false)
+	m, _ = m.Update(cmdGeneratedMsg{cmd: "curl -fsSL https://get.docker.com | sh"})
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.(model).state != statePipeConfirm {
+		t.Fatalf("Expected state to be statePipeConfirm, got %v", m.(model).state)
+	}
+	if cmd != nil {
+		t.Error("Expected nothing to be copied before confirming")
+	}
+	if !strings.Contains(m.(model).View(), "https://get.docker.com") {
+		t.Error("Expected the confirmation to show the URL being fetched")
+	}
+
+	// Any other key goes back without copying
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if m.(model).state != stateResult {
+		t.Errorf("Expected to return to the result, got %v", m.(model).state)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if cmd == nil {
+		t.Fatal("Expected Y to copy the command")
+	}
+	if msg, ok := cmd().(cmdCopiedMsg); !ok || msg.cmd != "curl -fsSL https://get.docker.com | sh" {
+		t.Error("Expected the command to be copied after confirming")
+	}
+	if *written != "curl -fsSL https://get.docker.com | sh" {
+		t.Errorf("Expected the clipboard to hold the command, got %q", *written)
+	}
+}
+
+// collectMsgs runs cmd and any batched or sequenced commands it returns,
+// collecting the messages they produce
+func collectMsgs(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, c := range batch {
+			msgs = append(msgs, collectMsgs(c)...)
+		}
+		return msgs
+	}
+	// tea.Sequence's message is an unexported slice of commands
+	if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeOf(tea.Cmd(nil)) {
+		var msgs []tea.Msg
+		for i := range v.Len() {
+			msgs = append(msgs, collectMsgs(v.Index(i).Interface().(tea.Cmd))...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func TestYesCopiesWithoutReview(t *testing.T) {
+	written := stubClipboard(t)
+	m := newModel(options{prompt: "compress this folder", yes: true})
+
+	updated, cmd := m.Update(cmdGeneratedMsg{cmd: "tar czf folder.tgz folder"})
+	var copied bool
+	for _, msg := range collectMsgs(cmd) {
+		if msg, ok := msg.(cmdCopiedMsg); ok && msg.cmd == "tar czf folder.tgz folder" {
+			copied = true
+		}
+	}
+	if !copied || *written != "tar czf folder.tgz folder" {
+		t.Errorf("Expected the command to be copied straight away, got %q", *written)
+	}
+	if updated.(model).state != stateResult {
+		t.Errorf("Expected the result state until the copy finishes, got %v", updated.(model).state)
+	}
+}
+
+func TestYesStillConfirmsDangerous(t *testing.T) {
+	written := stubClipboard(t)
+	m := newModel(options{prompt: "clean up", yes: true})
+	m.generator = fakeGenerator{text: "It deletes everything."}
+
+	updated, cmd := m.Update(cmdGeneratedMsg{cmd: "rm -rf build"})
+	collectMsgs(cmd)
+	if m = updated.(model); m.state != stateExplainConfirm || *written != "" {
+		t.Errorf("Expected a dangerous command to wait for confirmation, got state %v and %q copied", m.state, *written)
+	}
+
+	// Nor does it skip choosing between alternatives
+	m = newModel(options{prompt: "list files", yes: true})
+	updated, cmd = m.Update(cmdGeneratedMsg{cmd: "ls", alts: []string{"ls", "ls -A"}})
+	collectMsgs(cmd)
+	if updated.(model).state != stateResult || *written != "" {
+		t.Error("Expected alternatives to wait for a choice")
+	}
+}
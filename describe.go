@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/benmyles/clippycli/clippy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// describeRequest asks for a walkthrough of a command before it's run
+const describeRequest = "Explain the command you just gave me in plain English for someone who hasn't seen these tools before. Go through it piece by piece: what each program, option and operator does, then what the whole command will do when run here. Keep it short and don't suggest alternatives."
+
+// descriptionMsg carries the plain-English explanation shown on the explain
+// screen
+type descriptionMsg struct {
+	cmd  string // The command described, so a stale reply can be ignored
+	text string
+	err  error
+}
+
+// describeCommand asks the model to explain the current command. Unlike
+// explainCommand, it's only called when the user asks.
+func (m model) describeCommand() tea.Cmd {
+	cmd := m.generatedCmd
+	return func() tea.Msg {
+		messages := critiqueMessages(m.prompt, cmd)
+		messages[len(messages)-1] = clippy.UserMessage(describeRequest)
+
+		ctx, cancel := m.withTimeout(m.live.context())
+		defer cancel()
+
+		reply, err := m.generator.Generate(ctx,
+			critiqueSystemPrompt(getEnvironmentInfo(m.envOptions()), m.opts.lang),
+			messages,
+			nil,
+		)
+		if err = m.timeoutError(ctx, err); err != nil {
+			return descriptionMsg{cmd: cmd, err: err}
+		}
+		return descriptionMsg{cmd: cmd, text: strings.TrimSpace(reply.Text)}
+	}
+}
+
+// startDescription opens the explain screen, fetching an explanation unless
+// the current command already has one or is getting one
+func (m model) startDescription() (model, tea.Cmd) {
+	m.state = stateExplain
+	if m.describedCmd == m.generatedCmd && (m.description != "" || m.describing) {
+		return m, nil
+	}
+	m.describedCmd = m.generatedCmd
+	m.description = ""
+	m.descriptionErr = nil
+	m.describing = true
+	return m, tea.Batch(m.spinner.Tick, m.describeCommand())
+}
+
+// descriptionView shows the command with its explanation below
+func (m model) descriptionView() string {
+	var content strings.Builder
+	content.WriteString(promptStyle.Render("Command:"))
+	content.WriteString("\n")
+	content.WriteString(m.box(cmdStyle, m.generatedCmd))
+	content.WriteString("\n")
+	switch {
+	case m.describing:
+		content.WriteString(m.spinner.View() + " Explaining the command...")
+	case m.descriptionErr != nil:
+		content.WriteString(errorStyle.Render("Error: could not explain the command: " + m.descriptionErr.Error()))
+	default:
+		content.WriteString(promptStyle.Render("What it does:"))
+		content.WriteString("\n")
+		content.WriteString(m.box(critiqueStyle, m.description))
+	}
+	content.WriteString("\n")
+	content.WriteString(m.helpFooter())
+	return content.String()
+}
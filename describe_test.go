@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestExplainScreen(t *testing.T) {
+	m := initialModel("list files", false)
+	m.state = stateResult
+	m.generatedCmd = "ls -la"
+	m.generator = fakeGenerator{text: "Lists every file, including hidden ones.\n"}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = updated.(model)
+	if m.state != stateExplain || !m.describing || cmd == nil {
+		t.Fatalf("Expected x to open the explain screen and fetch an explanation, got state %v", m.state)
+	}
+	if !strings.Contains(m.View(), "Explaining") {
+		t.Error("Expected a spinner while the explanation loads")
+	}
+
+	updated, _ = m.Update(m.describeCommand()())
+	m = updated.(model)
+	view := m.View()
+	if !strings.Contains(view, "ls -la") || !strings.Contains(view, "including hidden ones.") {
+		t.Errorf("Expected the command and its explanation, got %q", view)
+	}
+
+	// Any key goes back, and coming back again reuses the explanation
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("z")})
+	m = updated.(model)
+	if m.state != stateResult {
+		t.Errorf("Expected any key to return to the result, got state %v", m.state)
+	}
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = updated.(model)
+	if m.state != stateExplain || m.describing || cmd != nil {
+		t.Error("Expected the cached explanation to be shown without another request")
+	}
+
+	// A different command needs a new explanation
+	m.state = stateResult
+	m.generatedCmd = "ls -A"
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if m = updated.(model); !m.describing || cmd == nil {
+		t.Error("Expected a new command to be explained afresh")
+	}
+}
+
+func TestExplainScreenError(t *testing.T) {
+	m := initialModel("list files", false)
+	m.generatedCmd = "ls -la"
+	m.generator = fakeGenerator{err: errors.New("overloaded")}
+	m, _ = m.startDescription()
+
+	updated, _ := m.Update(m.describeCommand()())
+	m = updated.(model)
+	if !strings.Contains(m.View(), "could not explain the command: overloaded") {
+		t.Errorf("Expected the error to be shown, got %q", m.View())
+	}
+
+	// A failure isn't cached, so asking again retries
+	m.state = stateResult
+	if _, cmd := m.startDescription(); cmd == nil {
+		t.Error("Expected another attempt after a failure")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// diffKind says whether a token was kept, added, or removed
+type diffKind int
+
+const (
+	diffSame diffKind = iota
+	diffAdded
+	diffRemoved
+)
+
+// diffToken is one whitespace-separated word of a command diff
+type diffToken struct {
+	kind diffKind
+	text string
+}
+
+var (
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(darkTheme.prompt)
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(darkTheme.err).Strikethrough(true)
+)
+
+// diffCommands compares two commands word by word, using the longest common
+// subsequence so unchanged words line up even when others were inserted
+func diffCommands(original, edited string) []diffToken {
+	a, b := strings.Fields(original), strings.Fields(edited)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var tokens []diffToken
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			tokens = append(tokens, diffToken{diffSame, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			tokens = append(tokens, diffToken{diffRemoved, a[i]})
+			i++
+		default:
+			tokens = append(tokens, diffToken{diffAdded, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		tokens = append(tokens, diffToken{diffRemoved, a[i]})
+	}
+	for ; j < len(b); j++ {
+		tokens = append(tokens, diffToken{diffAdded, b[j]})
+	}
+	return tokens
+}
+
+// commandChanged reports whether a diff contains any edits
+func commandChanged(tokens []diffToken) bool {
+	for _, tok := range tokens {
+		if tok.kind != diffSame {
+			return true
+		}
+	}
+	return false
+}
+
+// renderDiff shows a diff inline, marking words like git's --word-diff so the
+// changes are visible even without color
+func renderDiff(tokens []diffToken) string {
+	words := make([]string, len(tokens))
+	for i, tok := range tokens {
+		switch tok.kind {
+		case diffAdded:
+			words[i] = diffAddedStyle.Render("{+" + tok.text + "+}")
+		case diffRemoved:
+			words[i] = diffRemovedStyle.Render("[-" + tok.text + "-]")
+		default:
+			words[i] = dimStyle.Render(tok.text)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// editDiffView shows how the command differs from what the model returned,
+// so accidental edits stand out before copying. It's empty when unedited.
+func (m model) editDiffView() string {
+	if m.originalCmd == "" {
+		return ""
+	}
+	tokens := diffCommands(m.originalCmd, m.generatedCmd)
+	if !commandChanged(tokens) {
+		return ""
+	}
+	return "\n" + dimStyle.Render("Changed from the original:") + "\n" + renderDiff(tokens)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffCommands(t *testing.T) {
+	tests := []struct {
+		original string
+		edited   string
+		expected []diffToken
+	}{
+		{
+			"ls -la", "ls -la",
+			[]diffToken{{diffSame, "ls"}, {diffSame, "-la"}},
+		},
+		{
+			"find . -name *.go", "find src -name *.go -type f",
+			[]diffToken{
+				{diffSame, "find"}, {diffRemoved, "."}, {diffAdded, "src"},
+				{diffSame, "-name"}, {diffSame, "*.go"}, {diffAdded, "-type"}, {diffAdded, "f"},
+			},
+		},
+		{
+			"rm -rf build", "rm build",
+			[]diffToken{{diffSame, "rm"}, {diffRemoved, "-rf"}, {diffSame, "build"}},
+		},
+		{
+			"", "echo hi",
+			[]diffToken{{diffAdded, "echo"}, {diffAdded, "hi"}},
+		},
+	}
+
+	for _, test := range tests {
+		got := diffCommands(test.original, test.edited)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("diffCommands(%q, %q): expected %v, got %v", test.original, test.edited, test.expected, got)
+		}
+	}
+}
+
+func TestRenderDiff(t *testing.T) {
+	tokens := diffCommands("tar -czf out.tgz src", "tar -cjf out.tbz src")
+	if !commandChanged(tokens) {
+		t.Fatal("Expected the edit to be detected")
+	}
+
+	rendered := renderDiff(tokens)
+	for _, want := range []string{"[--czf-]", "{+-cjf+}", "[-out.tgz-]", "{+out.tbz+}", "src"} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("Expected rendered diff to contain %q, got %q", want, rendered)
+		}
+	}
+
+	if commandChanged(diffCommands("ls  -la", "ls -la")) {
+		t.Error("Expected whitespace-only changes not to count as edits")
+	}
+}
+
+func TestEditDiffViewOnConfirmation(t *testing.T) {
+	testModel := initialModel("clean up", false)
+	testModel.state = stateStrictConfirm
+	testModel.originalCmd = "rm -rf build"
+	testModel.generatedCmd = "rm -rf build"
+	if strings.Contains(testModel.View(), "Changed from the original") {
+		t.Error("Expected no diff for an unedited command")
+	}
+
+	testModel.generatedCmd = "rm -rf dist"
+	view := testModel.View()
+	if !strings.Contains(view, "[-build-]") || !strings.Contains(view, "{+dist+}") {
+		t.Error("Expected the edit to be shown when confirming")
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// dirConfigName is the per-directory file holding project default flags
+const dirConfigName = ".clippyrc"
+
+// dirConfigFlags are the flags a .clippyrc may set. A dotfile comes with
+// whatever repository was cloned, so it's limited to flags that shape the
+// output or make ClippyCLI more careful. Anything that changes what gets run,
+// which model is paid for, what text steers the model, or where files are
+// written has to come from the user.
+var dirConfigFlags = map[string]bool{
+	"-v":                 true,
+	"-q":                 true,
+	"--quiet":            true,
+	"--notify":           true,
+	"--with-undo":        true,
+	"--with-verify":      true,
+	"--ask-inputs":       true,
+	"--as-script":        true,
+	"--single-line":      true,
+	"--count":            true,
+	"--comment-style":    true,
+	"--lang":             true,
+	"--strict-confirm":   true,
+	"--max-width":        true,
+	"--theme":            true,
+	"--theme-color":      true,
+	"--no-highlight":     true,
+	"--no-loading-hints": true,
+	"--legacy-keys":      true,
+	"--detect-versions":  true,
+	"--no-env":           true,
+	"--no-update-check":  true,
+}
+
+// checkDirConfigFlags rejects any flag in args a .clippyrc may not set.
+// Words not starting with - are values, which parseArgs checks.
+func checkDirConfigFlags(args []string) error {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, _, _ := strings.Cut(arg, "=")
+		if !dirConfigFlags[name] {
+			return fmt.Errorf("%s can't be set in a %s, since one could come with any project; pass it on the command line or put it in config.toml", name, dirConfigName)
+		}
+	}
+	return nil
+}
+
+// findDirConfig walks up from dir looking for a .clippyrc, returning "" if
+// there isn't one
+func findDirConfig(dir string) string {
+	for {
+		path := filepath.Join(dir, dirConfigName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// readDirConfig reads the default flags from a .clippyrc. Flags are separated
+// by whitespace or newlines, and lines starting with # are comments.
+func readDirConfig(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var args []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		args = append(args, strings.Fields(line)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := checkDirConfigFlags(args); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// A dotfile sets defaults, it can't supply a prompt
+	opts, err := parseArgs(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if opts.prompt != "" {
+		return nil, fmt.Errorf("%s: unrecognized flags %q", path, opts.prompt)
+	}
+
+	return args, nil
+}
+
+// withDirDefaults prepends the flags from the nearest .clippyrc above dir to
+// args, so that explicit command-line flags take precedence
+func withDirDefaults(dir string, args []string) ([]string, error) {
+	path := findDirConfig(dir)
+	if path == "" {
+		return args, nil
+	}
+
+	defaults, err := readDirConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(defaults, args...), nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// runDryRun implements --dry-run: it writes the prompt a generation would
+// send for the prompt given on the command line, without calling the API.
+// With -v it's laid out like the verbose result screen. It returns the
+// process exit code.
+func runDryRun(m model, stdout, stderr io.Writer) int {
+	if m.prompt == "" {
+		fmt.Fprintln(stderr, "Error: --dry-run needs a prompt on the command line")
+		return 1
+	}
+
+	envInfo := getEnvironmentInfo(m.envOptions())
+	fullPrompt := formatFullPrompt(m.systemPrompt(envInfo), m.prompt)
+	if !m.verbose {
+		fmt.Fprintln(stdout, fullPrompt)
+		return 0
+	}
+
+	// There's no terminal size to go by, so wrap at the content cap
+	m.width = m.opts.contentMaxWidth() + screenMargin
+	fmt.Fprintln(stdout, dimStyle.Render("Model: "+m.modelName))
+	fmt.Fprintln(stdout, promptStyle.Render("Full prompt that would be sent to AI:"))
+	fmt.Fprintln(stdout, m.box(verbosePromptStyle, fullPrompt))
+	return 0
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunDryRun(t *testing.T) {
+	m := newModel(options{prompt: "list go files", dryRun: true})
+	m.generator = fakeGenerator{err: errors.New("the API was called")}
+
+	var stdout, stderr bytes.Buffer
+	if code := runDryRun(m, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr %q)", code, stderr.String())
+	}
+	envInfo := getEnvironmentInfo(m.envOptions())
+	want := formatFullPrompt(m.systemPrompt(envInfo), "list go files") + "\n"
+	if stdout.String() != want {
+		t.Errorf("Expected the full prompt generateCommand would send, got %q", stdout.String())
+	}
+
+	stdout.Reset()
+	m.verbose = true
+	if code := runDryRun(m, &stdout, &stderr); code != 0 || !strings.Contains(stdout.String(), "Model: "+m.modelName) || !strings.Contains(stdout.String(), "User: list go files") {
+		t.Errorf("Expected the verbose layout with the model name, got %q", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := runDryRun(newModel(options{dryRun: true}), &stdout, &stderr); code == 0 || !strings.Contains(stderr.String(), "needs a prompt") {
+		t.Errorf("Expected an error without a prompt, got %q", stderr.String())
+	}
+}
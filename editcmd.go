@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startEditCommand opens the generated command in the textarea so it can be
+// tweaked by hand before copying
+func (m model) startEditCommand() (model, tea.Cmd) {
+	m.state = stateEditCmd
+	m.textarea.SetValue(m.generatedCmd)
+	m.textarea.CursorEnd()
+	m.textarea.Focus()
+	return m, textarea.Blink
+}
+
+// finishEditCommand copies the edited command without calling the API. It
+// goes through the same confirmations as the generated command would.
+func (m model) finishEditCommand() (model, tea.Cmd) {
+	edited := strings.TrimSpace(m.textarea.Value())
+	if edited == "" {
+		return m, nil
+	}
+	m.generatedCmd = edited
+	m.state = stateResult
+	return m.copyOrConfirm()
+}
+
+// editCommandView shows the command being edited, with how it differs from
+// what the model returned
+func (m model) editCommandView() string {
+	var content strings.Builder
+	content.WriteString(promptStyle.Render("Edit the command:"))
+	content.WriteString("\n\n")
+	content.WriteString(m.textarea.View())
+	content.WriteString("\n")
+
+	edited := m
+	edited.generatedCmd = strings.TrimSpace(m.textarea.Value())
+	content.WriteString(edited.editDiffView())
+	content.WriteString("\n")
+	content.WriteString(m.helpFooter())
+	return content.String()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestEditCommandCopiesEditedText(t *testing.T) {
+	written := stubClipboard(t)
+
+	var m tea.Model = initialModel("list files", false)
+	m, _ = m.Update(cmdGeneratedMsg{cmd: "ls -l"})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	if m.(model).state != stateEditCmd || m.(model).textarea.Value() != "ls -l" {
+		t.Fatalf("Expected the command in the editor, got state %v and %q", m.(model).state, m.(model).textarea.Value())
+	}
+
+	m = typeText(m, "a")
+	if view := m.View(); !strings.Contains(view, "Changed from the original:") {
+		t.Errorf("Expected the edit to be diffed as it's typed, got %q", view)
+	}
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("Expected Enter to copy the edited command")
+	}
+	msg := cmd()
+	if *written != "ls -la" {
+		t.Errorf("Expected the edited command to be copied, got %q", *written)
+	}
+	m, _ = m.Update(msg)
+	if m.(model).copiedCmd != "ls -la" {
+		t.Errorf("Expected the banner to show the edited command, got %q", m.(model).copiedCmd)
+	}
+}
+
+func TestEditCommandEscDiscards(t *testing.T) {
+	var m tea.Model = initialModel("list files", false)
+	m, _ = m.Update(cmdGeneratedMsg{cmd: "ls -l"})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	m = typeText(m, "a")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.(model).state != stateResult || m.(model).generatedCmd != "ls -l" {
+		t.Errorf("Expected Esc to go back with the command unchanged, got state %v and %q", m.(model).state, m.(model).generatedCmd)
+	}
+}
+
+func TestEditCommandStillConfirmsDanger(t *testing.T) {
+	written := stubClipboard(t)
+
+	var m tea.Model = initialModel("list files", false)
+	m, _ = m.Update(cmdGeneratedMsg{cmd: "ls build"})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	edited := m.(model)
+	edited.textarea.SetValue("rm -rf build")
+	m, cmd := tea.Model(edited).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.(model).state != stateExplainConfirm || cmd != nil || *written != "" {
+		t.Errorf("Expected a dangerous edit to need confirming, got state %v and %q copied", m.(model).state, *written)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// sensitiveEnvRe matches variable names that give away which credentials are
+// set, like AWS_SECRET_ACCESS_KEY or GITHUB_TOKEN
+var sensitiveEnvRe = regexp.MustCompile(`(?i)(_KEY|_SECRET|_TOKEN)$|PASSWORD`)
+
+// envVarNames returns the sorted names of the variables in environ, a list
+// of KEY=value pairs like os.Environ's, leaving out sensitive ones
+func envVarNames(environ []string) []string {
+	var names []string
+	for _, env := range environ {
+		name, _, ok := strings.Cut(env, "=")
+		if !ok || name == "" || sensitiveEnvRe.MatchString(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
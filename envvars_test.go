@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnvVarNamesFiltersSensitive(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"ANTHROPIC_API_KEY=sk-test",
+		"AWS_SECRET_ACCESS_KEY=abc",
+		"CLIENT_SECRET=abc",
+		"GITHUB_TOKEN=abc",
+		"DB_PASSWORD_FILE=/run/secrets/db",
+		"mysql_password=abc",
+		"HOME=/home/me",
+		"KEYBOARD=us",
+		"EDITOR=vim",
+		"=C:=C:\\",
+	}
+	got := strings.Join(envVarNames(environ), ",")
+	if got != "EDITOR,HOME,KEYBOARD,PATH" {
+		t.Errorf("Expected only the harmless names, sorted, got %q", got)
+	}
+}
+
+func TestNoEnvOmitsVariables(t *testing.T) {
+	t.Setenv("CLIPPY_TEST_VAR", "1")
+	if !strings.Contains(getEnvironmentInfo(envOptions{}), "CLIPPY_TEST_VAR") {
+		t.Error("Expected environment variable names by default")
+	}
+
+	envInfo := getEnvironmentInfo(envOptions{noEnv: true})
+	if strings.Contains(envInfo, "Available environment variables") || strings.Contains(envInfo, "CLIPPY_TEST_VAR") {
+		t.Errorf("Expected no environment variables with --no-env, got %q", envInfo)
+	}
+
+	if opts, err := parseArgs([]string{"--no-env"}); err != nil || !opts.noEnv {
+		t.Errorf("Expected --no-env to be parsed, got %v (err %v)", opts.noEnv, err)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/benmyles/clippycli/clippy"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errorCategory groups generation failures by what the user can do about them
+type errorCategory int
+
+const (
+	errorOther   errorCategory = iota // Anything unrecognized, like a reply that couldn't be parsed
+	errorAuth                         // The API key was rejected, so retrying can't help
+	errorBusy                         // Rate limited or overloaded; worth a retry shortly
+	errorNetwork                      // The provider couldn't be reached
+	errorModel                        // The model doesn't exist or isn't available
+	errorRequest                      // The request itself was rejected, e.g. a prompt too long
+	errorServer                       // The provider failed on its end
+)
+
+// classifyError works out the category of a generation failure from the
+// HTTP status the provider replied with, or the kind of connection failure
+func classifyError(err error) errorCategory {
+	var status int
+	var apiErr *anthropic.Error
+	var httpErr *clippy.StatusError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.StatusCode
+	case errors.As(err, &httpErr):
+		status = httpErr.Status
+	case isConnectionLost(err):
+		return errorNetwork
+	}
+
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return errorAuth
+	case status == http.StatusNotFound:
+		return errorModel
+	// 529 is Anthropic's "overloaded"
+	case status == http.StatusTooManyRequests || status == 529:
+		return errorBusy
+	case status >= 500:
+		return errorServer
+	case status >= 400:
+		return errorRequest
+	}
+	return errorOther
+}
+
+// canRetry reports whether sending the same request again might work
+func (c errorCategory) canRetry() bool {
+	return c != errorAuth && c != errorModel && c != errorRequest
+}
+
+// canEditPrompt reports whether a different prompt might work
+func (c errorCategory) canEditPrompt() bool {
+	return c != errorAuth && c != errorNetwork && c != errorModel
+}
+
+// canChangeModel reports whether a different model might work
+func (c errorCategory) canChangeModel() bool {
+	return c != errorAuth && c != errorNetwork
+}
+
+// errorDetail is what the API reported about a failed request, beyond the
+// one-line error
+type errorDetail struct {
+	status    int
+	requestID string // Quoted to Anthropic support to find the request
+	body      string // The response as received
+}
+
+// apiErrorDetail pulls the status, request ID and response body out of an
+// Anthropic SDK error, returning nil for any other kind of error
+func apiErrorDetail(err error) *errorDetail {
+	var apiErr *anthropic.Error
+	if !errors.As(err, &apiErr) {
+		return nil
+	}
+	detail := &errorDetail{status: apiErr.StatusCode, body: apiErr.RawJSON()}
+	if apiErr.Response != nil {
+		detail.requestID = apiErr.Response.Header.Get("request-id")
+	}
+	return detail
+}
+
+// String lays out the detail a line per field, indenting a JSON body so the
+// field the API objected to is easy to spot
+func (d *errorDetail) String() string {
+	lines := []string{fmt.Sprintf("Status: %d %s", d.status, http.StatusText(d.status))}
+	if d.requestID != "" {
+		lines = append(lines, "Request ID: "+d.requestID)
+	}
+	if body := strings.TrimSpace(d.body); body != "" {
+		var indented bytes.Buffer
+		if json.Indent(&indented, []byte(body), "", "  ") == nil {
+			body = indented.String()
+		}
+		lines = append(lines, "Response:\n"+body)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// errorHint suggests what to do about a failure, in a line under the error
+func (m model) errorHint() string {
+	switch classifyError(m.err) {
+	case errorAuth:
+		if keyEnv := clippy.KeyEnv(m.provider()); keyEnv != "" {
+			return "Check that " + keyEnv + " holds a valid API key, then run ClippyCLI again."
+		}
+		return "The provider rejected the request's credentials."
+	case errorBusy:
+		return "The API is busy or rate limiting you. Wait a moment, then retry."
+	case errorNetwork:
+		return "Check your network connection, then retry."
+	case errorModel:
+		return "The model " + m.modelName + " isn't available. Try another."
+	case errorRequest:
+		return "The request was rejected. A shorter prompt or another model may help."
+	case errorServer:
+		return "The provider had a problem on its end. Retry, or try another model."
+	}
+	return ""
+}
+
+// errorView explains a failed generation, above the ways to recover
+func (m model) errorView() string {
+	var content strings.Builder
+	content.WriteString(errorStyle.Render("Error: " + m.err.Error()))
+	if hint := m.errorHint(); hint != "" {
+		content.WriteString("\n")
+		content.WriteString(dimStyle.Render(hint))
+	}
+	if m.verbose && m.errDetail != nil {
+		content.WriteString("\n\n")
+		content.WriteString(promptStyle.Render("Details from the API:"))
+		content.WriteString("\n")
+		content.WriteString(m.box(verbosePromptStyle, m.errDetail.String()))
+	}
+	content.WriteString(m.truncationWarning())
+	content.WriteString("\n")
+	content.WriteString(m.helpFooter())
+	return content.String()
+}
+
+// retry sends the failed request again
+func (m model) retry() (model, tea.Cmd) {
+	m.state = stateLoading
+	m.err = nil
+	return m, m.startGeneration(m.generateCommand())
+}
+
+// regenerate asks again with the same prompt for a different command,
+// sampling more freely where the provider allows it. Only this request is
+// varied; later ones go back to the usual temperature.
+func (m model) regenerate() (model, tea.Cmd) {
+	m.state = stateLoading
+	m.err = nil
+	varied := m
+	varied.generator = clippy.Varied(m.generator)
+	// The point is a different command, not the one cached last time
+	varied.opts.noCache = true
+	return m, m.startGeneration(varied.generateCommand())
+}
+
+// startChangeModel asks for a model to use instead, starting from the
+// current one
+func (m model) startChangeModel() (model, tea.Cmd) {
+	m.state = stateChangeModel
+	m.modelErr = nil
+	m.textarea.SetValue(m.modelName)
+	m.textarea.CursorEnd()
+	m.textarea.Focus()
+	return m, textarea.Blink
+}
+
+// changeModel switches to the model typed in and regenerates with it,
+// staying put if the name isn't one the provider accepts
+func (m model) changeModel() (model, tea.Cmd) {
+	provider := m.provider()
+	name, err := clippy.ResolveModel(provider, strings.TrimSpace(m.textarea.Value()))
+	if err != nil {
+		m.modelErr = err
+		return m, nil
+	}
+	m.opts.model = name
+	m.modelName = clippy.ModelFor(provider, name)
+	m.opts.maxTokens, m.tokensNote = clippy.CapMaxTokens(m.modelName, m.opts.maxTokens)
+	m.generator = newGenerator(provider, m.opts)
+	return m.retry()
+}
+
+// changeModelView asks for the model to switch to
+func (m model) changeModelView() string {
+	var content strings.Builder
+	content.WriteString(promptStyle.Render("Model to use:"))
+	content.WriteString("\n\n")
+	content.WriteString(m.textarea.View())
+	if m.modelErr != nil {
+		content.WriteString("\n")
+		content.WriteString(errorStyle.Render("Error: " + m.modelErr.Error()))
+	}
+	content.WriteString("\n")
+	content.WriteString(m.helpFooter())
+	return content.String()
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/benmyles/clippycli/clippy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// anthropicError builds the SDK's error for an HTTP status
+func anthropicError(status int) error {
+	req, _ := http.NewRequest(http.MethodPost, "https://api.anthropic.com/v1/messages", nil)
+	return &anthropic.Error{StatusCode: status, Request: req, Response: &http.Response{StatusCode: status}}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want errorCategory
+	}{
+		{anthropicError(http.StatusUnauthorized), errorAuth},
+		{fmt.Errorf("generating: %w", anthropicError(529)), errorBusy},
+		{anthropicError(http.StatusNotFound), errorModel},
+		{anthropicError(http.StatusBadRequest), errorRequest},
+		{anthropicError(http.StatusInternalServerError), errorServer},
+		{&clippy.StatusError{Status: http.StatusTooManyRequests, Message: "openai: 429 Too Many Requests"}, errorBusy},
+		{&url.Error{Op: "Post", URL: "http://localhost:11434", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, errorNetwork},
+		{errors.New("could not parse reply"), errorOther},
+	}
+	for _, tt := range tests {
+		if got := classifyError(tt.err); got != tt.want {
+			t.Errorf("%v: expected category %d, got %d", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestErrorViewOffersCategoryActions(t *testing.T) {
+	tests := []struct {
+		err   error
+		offer []string
+		hide  []string
+	}{
+		{anthropicError(http.StatusUnauthorized), []string{"Q/Esc/Ctrl+C to quit"}, []string{"to retry", "to edit prompt", "to change model"}},
+		{anthropicError(529), []string{"R to retry", "E to edit prompt", "M to change model"}, nil},
+		{anthropicError(http.StatusNotFound), []string{"M to change model"}, []string{"to retry", "to edit prompt"}},
+		{errors.New("could not parse reply"), []string{"R to retry", "E to edit prompt", "M to change model"}, nil},
+	}
+	for _, tt := range tests {
+		m := initialModel("list files", false)
+		updated, _ := m.Update(cmdGeneratedMsg{err: tt.err})
+		m = updated.(model)
+		if m.state != stateError {
+			t.Fatalf("Expected a failed generation to show the error view, got state %v", m.state)
+		}
+		footer := strings.Join(m.helpItems(), " ")
+		for _, want := range tt.offer {
+			if !strings.Contains(footer, want) {
+				t.Errorf("%v: expected %q to be offered, got %q", tt.err, want, footer)
+			}
+		}
+		for _, unwanted := range tt.hide {
+			if strings.Contains(footer, unwanted) {
+				t.Errorf("%v: expected %q not to be offered, got %q", tt.err, unwanted, footer)
+			}
+		}
+	}
+}
+
+func TestErrorViewAuthHint(t *testing.T) {
+	m := initialModel("list files", false)
+	updated, _ := m.Update(cmdGeneratedMsg{err: anthropicError(http.StatusUnauthorized)})
+	if view := updated.View(); !strings.Contains(view, "ANTHROPIC_API_KEY") {
+		t.Errorf("Expected the view to point at the API key, got %q", view)
+	}
+}
+
+func TestErrorViewVerboseDetail(t *testing.T) {
+	apiErr := anthropicError(http.StatusBadRequest).(*anthropic.Error)
+	apiErr.Response.Header = http.Header{"Request-Id": []string{"req_011abc"}}
+	body := `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: must be at most 8192"}}`
+	if err := apiErr.UnmarshalJSON([]byte(body)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, verbose := range []bool{false, true} {
+		m := initialModel("list files", verbose)
+		updated, _ := m.Update(cmdGeneratedMsg{err: fmt.Errorf("generating: %w", apiErr)})
+		view := updated.View()
+		for _, want := range []string{"Status: 400 Bad Request", "Request ID: req_011abc", `"message": "max_tokens: must be at most 8192"`} {
+			if strings.Contains(view, want) != verbose {
+				t.Errorf("Verbose %v: expected %q shown only in verbose mode, got %q", verbose, want, view)
+			}
+		}
+	}
+
+	// Errors that didn't come from the API have no detail to show
+	if detail := apiErrorDetail(errors.New("could not parse reply")); detail != nil {
+		t.Errorf("Expected no detail for a non-API error, got %+v", detail)
+	}
+}
+
+func TestErrorViewRetry(t *testing.T) {
+	m := initialModel("list files", false)
+	updated, _ := m.Update(cmdGeneratedMsg{err: anthropicError(529)})
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = updated.(model)
+	if m.state != stateLoading || m.err != nil || cmd == nil {
+		t.Errorf("Expected r to retry, got state %v with error %v", m.state, m.err)
+	}
+}
+
+// variedGenerator replies with varied when asked to sample more freely
+type variedGenerator struct {
+	fakeGenerator
+	varied string
+}
+
+func (g variedGenerator) Varied() clippy.Generator { return fakeGenerator{text: g.varied} }
+
+func TestRegenerateKeepsPrompt(t *testing.T) {
+	m := initialModel("list files by date", false)
+	m.generator = variedGenerator{fakeGenerator: fakeGenerator{text: "ls -lt"}, varied: "ls -ltr"}
+	updated, _ := m.Update(cmdGeneratedMsg{cmd: "ls -lt"})
+
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = updated.(model)
+	if m.state != stateLoading || m.prompt != "list files by date" {
+		t.Fatalf("Expected r to regenerate the same prompt, got state %v with prompt %q", m.state, m.prompt)
+	}
+	for _, msg := range collectMsgs(cmd) {
+		if generated, ok := msg.(cmdGeneratedMsg); ok {
+			updated, _ = updated.Update(generated)
+		}
+	}
+	m = updated.(model)
+	if m.state != stateResult || m.generatedCmd != "ls -ltr" {
+		t.Errorf("Expected the varied generator's command, got state %v with %q", m.state, m.generatedCmd)
+	}
+	// Only the regeneration samples more freely
+	if _, ok := m.generator.(variedGenerator); !ok {
+		t.Errorf("Expected the model to keep its usual generator, got %T", m.generator)
+	}
+}
+
+func TestErrorViewChangeModel(t *testing.T) {
+	m := initialModel("list files", false)
+	updated, _ := m.Update(cmdGeneratedMsg{err: anthropicError(http.StatusNotFound)})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	m = updated.(model)
+	if m.state != stateChangeModel || m.textarea.Value() != m.modelName {
+		t.Fatalf("Expected m to ask for a model starting from the current one, got state %v and %q", m.state, m.textarea.Value())
+	}
+
+	// An unknown name is refused without leaving the screen
+	m.textarea.SetValue("gpt-4o")
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.state != stateChangeModel || m.modelErr == nil {
+		t.Fatalf("Expected an unknown model to be refused, got state %v", m.state)
+	}
+
+	m.textarea.SetValue("haiku")
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.state != stateLoading || cmd == nil || m.modelName != string(anthropic.ModelClaude3_5HaikuLatest) {
+		t.Errorf("Expected to regenerate with haiku, got state %v and model %q", m.state, m.modelName)
+	}
+}
+
+func TestEmptyReplyShowsError(t *testing.T) {
+	for _, text := range []string{"", " \n\t"} {
+		m := initialModel("list files", false)
+		m.generator = fakeGenerator{text: text}
+		updated, _ := m.Update(m.generateCommand()())
+		m = updated.(model)
+		if m.state != stateError || !errors.Is(m.err, clippy.ErrEmptyReply) {
+			t.Fatalf("%q: expected the error view for an empty reply, got state %v with error %v", text, m.state, m.err)
+		}
+		if view := m.View(); !strings.Contains(view, "model returned no command, try rephrasing") || strings.Contains(view, "Enter to copy") {
+			t.Errorf("%q: expected the error and no copy action, got %q", text, view)
+		}
+	}
+
+	// The same goes for alternatives
+	m := newModel(options{prompt: "list files", count: 3})
+	m.generator = fakeGenerator{}
+	if msg := m.runGeneration().(cmdGeneratedMsg); !errors.Is(msg.err, clippy.ErrEmptyReply) {
+		t.Errorf("Expected an error for an empty reply with --count, got %v", msg.err)
+	}
+}
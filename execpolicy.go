@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// execPolicy limits which generated commands may be run rather than copied.
+// Patterns are matched against each segment of a command, so an allowed
+// `ls` can't smuggle in `; rm file`.
+type execPolicy struct {
+	allow []dangerPattern // When set, every segment must match one of these
+	deny  []dangerPattern // No segment may match any of these
+}
+
+// execPattern compiles a pattern such as "git status" or "docker ps *". A
+// pattern matches a whole segment, where * matches anything and a pattern
+// without one also matches when followed by arguments.
+func execPattern(pattern, reason string) dangerPattern {
+	pattern = strings.Join(strings.Fields(pattern), " ")
+	expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, `.*`)
+	if !strings.Contains(pattern, "*") {
+		expr += `(\s.*)?`
+	}
+	return dangerPattern{
+		re:     regexp.MustCompile(`^` + expr + `$`),
+		reason: fmt.Sprintf(reason, pattern),
+	}
+}
+
+// newExecPolicy builds a policy from --exec-allow and --exec-deny patterns
+func newExecPolicy(allow, deny []string) execPolicy {
+	var p execPolicy
+	for _, pattern := range allow {
+		p.allow = append(p.allow, execPattern(pattern, "matches %q"))
+	}
+	for _, pattern := range deny {
+		p.deny = append(p.deny, execPattern(pattern, "matches the deny pattern %q"))
+	}
+	return p
+}
+
+// harmlessRedirectRe matches redirections that can't write to a file, like
+// 2>/dev/null and 2>&1
+var harmlessRedirectRe = regexp.MustCompile(`(\d*|&)>>?\s*/dev/null\b|\d*>&\d`)
+
+// hasHiddenEffects reports whether cmd uses syntax that can write files or
+// run commands the segment patterns can't see: output redirection, command
+// substitution, and background jobs. Quoting isn't considered, so this errs
+// on the side of copying.
+func hasHiddenEffects(cmd string) bool {
+	cmd = harmlessRedirectRe.ReplaceAllString(cmd, "")
+	cmd = strings.ReplaceAll(cmd, "&&", "")
+	return strings.ContainsAny(cmd, ">&`") || strings.Contains(cmd, "$(") || strings.Contains(cmd, "<(")
+}
+
+// permits reports whether cmd may be run, or else why it should only be
+// copied. Commands flagged as dangerous, and downloaded scripts run without
+// being saved, are never run.
+func (p execPolicy) permits(cmd string) (bool, string) {
+	if dangerous, reason := isDangerous(cmd); dangerous {
+		return false, "it " + reason
+	}
+	if _, found := detectPipeToShell(cmd); found {
+		return false, "it runs a downloaded script without saving it first"
+	}
+	// Substitutions run commands no segment shows, so neither list can
+	// vouch for them
+	if (len(p.allow) > 0 || len(p.deny) > 0) && hasHiddenEffects(cmd) {
+		return false, "it uses redirection, substitution, or background jobs, which the exec policy can't check"
+	}
+
+	for _, segment := range splitSegments(cmd) {
+		// A subshell or group runs its body like any other segment
+		segment = strings.Join(strings.Fields(strings.Trim(segment, "(){} \t")), " ")
+		if segment == "" {
+			continue
+		}
+		if denied, reason := matchPatterns(p.deny, segment); denied {
+			return false, fmt.Sprintf("`%s` %s", segment, reason)
+		}
+		if allowed, _ := matchPatterns(p.allow, segment); len(p.allow) > 0 && !allowed {
+			return false, fmt.Sprintf("`%s` isn't in the allowlist", segment)
+		}
+	}
+	return true, ""
+}
+
+// execPolicy returns the policy set by the command-line options
+func (m model) execPolicy() execPolicy {
+	return newExecPolicy(m.opts.execAllow, m.opts.execDeny)
+}
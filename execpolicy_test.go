@@ -0,0 +1,96 @@
+package main
+
+import "testing"
+
+func TestExecPolicyPermits(t *testing.T) {
+	policy := newExecPolicy([]string{"ls", "git status", "git log", "wc", "docker ps *"}, []string{"git log -p"})
+
+	tests := []struct {
+		cmd     string
+		allowed bool
+	}{
+		{"ls", true},
+		{"ls -la /tmp", true},
+		{"git status -s", true},
+		{"ls | wc -l", true},
+		{"ls 2>/dev/null", true},
+		{"git log --oneline 2>&1 | wc -l", true},
+		{"docker ps -a", true},
+		{"docker ps", false},     // The * needs something to match after the space
+		{"lsof -i :8080", false}, // Patterns match whole words
+		{"git push", false},
+		{"ls; rm notes.txt", false},
+		{"ls && touch x", false},
+		{"git log -p", false}, // Denied even though git log is allowed
+		{"ls > files.txt", false},
+		{"ls >> files.txt", false},
+		{"ls $(cat dirs)", false},
+		{"ls `cat dirs`", false},
+		{"ls &", false},
+	}
+
+	for _, tt := range tests {
+		if allowed, reason := policy.permits(tt.cmd); allowed != tt.allowed {
+			t.Errorf("permits(%q) = %v (%s); want %v", tt.cmd, allowed, reason, tt.allowed)
+		}
+	}
+}
+
+func TestExecPolicyDenyOnly(t *testing.T) {
+	// Without an allowlist, anything not denied may run
+	policy := newExecPolicy(nil, []string{"kubectl delete"})
+	if allowed, _ := policy.permits("kubectl get pods"); !allowed {
+		t.Error("Expected a command not matching the denylist to be allowed")
+	}
+	allowed, reason := policy.permits("kubectl get pods && kubectl delete pod web")
+	if allowed {
+		t.Error("Expected a denied segment to stop the command running")
+	}
+	if reason != "`kubectl delete pod web` matches the deny pattern \"kubectl delete\"" {
+		t.Errorf("Unexpected reason %q", reason)
+	}
+}
+
+func TestExecPolicyDenySeesHiddenCommands(t *testing.T) {
+	policy := newExecPolicy(nil, []string{"git push"})
+	for _, cmd := range []string{
+		"echo $(git push)",
+		"echo `git push`",
+		"(git push)",
+		"(cd repo && git push --force)",
+		"{ git push; }",
+		"diff <(git push) out",
+	} {
+		if allowed, _ := policy.permits(cmd); allowed {
+			t.Errorf("Expected %q not to get past the deny pattern", cmd)
+		}
+	}
+	if allowed, reason := policy.permits("(cd repo && git status)"); !allowed {
+		t.Errorf("Expected a subshell without denied commands to run, got %s", reason)
+	}
+}
+
+func TestExecPolicyNeverRunsDangerousCommands(t *testing.T) {
+	policy := newExecPolicy([]string{"rm *"}, nil)
+	if allowed, reason := policy.permits("rm -rf build"); allowed || reason != "it recursively force-deletes files" {
+		t.Errorf("Expected a dangerous command to be copy-only even when allowed, got %v (%s)", allowed, reason)
+	}
+}
+
+func TestExecPolicyFromOptions(t *testing.T) {
+	opts, err := parseArgs([]string{"--exec-allow", "ls", "--exec-allow", "pwd", "--exec-deny", "ls -R", "show files"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newModel(opts)
+	if allowed, _ := m.execPolicy().permits("pwd"); !allowed {
+		t.Error("Expected an allowed command to run")
+	}
+	if allowed, _ := m.execPolicy().permits("cat notes.txt"); allowed {
+		t.Error("Expected a command outside the allowlist to fall back to copying")
+	}
+
+	if _, err := parseArgs([]string{"--exec-allow", " "}); err == nil {
+		t.Error("Expected an empty pattern to be rejected")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/benmyles/clippycli/clippy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// explainRequest asks for a short account of what a flagged command will do
+const explainRequest = "The command you just gave me was flagged as potentially destructive. In two or three plain sentences, explain exactly what it will change or delete when run here, including anything irreversible. Don't suggest alternatives and don't repeat the command."
+
+// explanationMsg carries the explanation of a flagged command
+type explanationMsg struct {
+	cmd  string // The command explained, so a stale reply can be ignored
+	text string
+	err  error
+}
+
+// explainCommand fetches an explanation of the current command. It's only
+// called for commands flagged as dangerous, to avoid the cost on safe ones.
+func (m model) explainCommand() tea.Cmd {
+	cmd := m.generatedCmd
+	return func() tea.Msg {
+		ctx, cancel := m.withTimeout(m.live.context())
+		defer cancel()
+
+		reply, err := m.generator.Generate(ctx,
+			critiqueSystemPrompt(getEnvironmentInfo(m.envOptions()), m.opts.lang),
+			explainMessages(m.prompt, cmd),
+			nil,
+		)
+		if err = m.timeoutError(ctx, err); err != nil {
+			return explanationMsg{cmd: cmd, err: err}
+		}
+		return explanationMsg{cmd: cmd, text: strings.TrimSpace(reply.Text)}
+	}
+}
+
+// explainMessages replays the exchange that produced cmd, then asks for the
+// explanation
+func explainMessages(prompt, cmd string) []clippy.Message {
+	messages := critiqueMessages(prompt, cmd)
+	messages[len(messages)-1] = clippy.UserMessage(explainRequest)
+	return messages
+}
+
+// startExplanation fetches an explanation if the new command is dangerous
+func (m model) startExplanation() (model, tea.Cmd) {
+	m.explanation = ""
+	m.explanationErr = nil
+	m.explaining = false
+	if dangerous, _ := isDangerous(m.generatedCmd); !dangerous {
+		return m, nil
+	}
+	m.explaining = true
+	return m, tea.Batch(m.spinner.Tick, m.explainCommand())
+}
+
+// explanationView shows the explanation, or that it's on its way, above a
+// request for confirmation
+func (m model) explanationView() string {
+	var content strings.Builder
+	switch {
+	case m.explaining:
+		content.WriteString("\n")
+		content.WriteString(m.spinner.View() + " Explaining what this will do...")
+	case m.explanation != "":
+		content.WriteString("\n")
+		content.WriteString(promptStyle.Render("What it does:"))
+		content.WriteString("\n")
+		content.WriteString(m.box(critiqueStyle, m.explanation))
+	case m.explanationErr != nil:
+		content.WriteString("\n")
+		content.WriteString(errorStyle.Render("Error: could not explain the command: " + m.explanationErr.Error()))
+	}
+	return content.String()
+}
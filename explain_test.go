@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestExplainMessages(t *testing.T) {
+	messages := explainMessages("clean up", "rm -rf build")
+
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(messages))
+	}
+	if messages[1].Text != "rm -rf build" {
+		t.Errorf("Expected the command to be included, got %q", messages[1].Text)
+	}
+	if messages[2].Text != explainRequest {
+		t.Error("Expected the last message to ask for an explanation")
+	}
+}
+
+func TestDangerousCommandIsExplained(t *testing.T) {
+	stubClipboard(t)
+
+	var m tea.Model = initialModel("clean up", false)
+	m, cmd := m.Update(cmdGeneratedMsg{cmd: "rm -rf build"})
+	if !m.(model).explaining {
+		t.Fatal("Expected a dangerous command to be explained")
+	}
+	// The explanation and a spinner tick
+	if n := batchSize(cmd); n < 2 {
+		t.Errorf("Expected the explanation to be requested, got %d commands", n)
+	}
+
+	// Enter asks for confirmation instead of copying
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.(model).state != stateExplainConfirm {
+		t.Fatalf("Expected state to be stateExplainConfirm, got %v", m.(model).state)
+	}
+	if view := m.View(); !strings.Contains(view, "Explaining") {
+		t.Errorf("Expected the view to show the explanation is on its way, got %q", view)
+	}
+
+	m, _ = m.Update(explanationMsg{cmd: "rm -rf build", text: "Deletes the build directory and everything in it."})
+	if view := m.View(); !strings.Contains(view, "Deletes the build directory") {
+		t.Errorf("Expected the view to show the explanation, got %q", view)
+	}
+
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if cmd == nil {
+		t.Fatal("Expected Y to copy the command")
+	}
+	if msg, ok := cmd().(cmdCopiedMsg); !ok || msg.cmd != "rm -rf build" {
+		t.Errorf("Expected the command to be copied, got %#v", msg)
+	}
+}
+
+func TestExplainConfirmGoesBack(t *testing.T) {
+	var m tea.Model = initialModel("clean up", false)
+	m, _ = m.Update(cmdGeneratedMsg{cmd: "rm -rf build"})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if m.(model).state != stateResult {
+		t.Errorf("Expected any other key to go back, got %v", m.(model).state)
+	}
+	if cmd != nil {
+		if _, copied := cmd().(cmdCopiedMsg); copied {
+			t.Error("Expected nothing to be copied")
+		}
+	}
+}
+
+func TestSafeCommandIsNotExplained(t *testing.T) {
+	var m tea.Model = initialModel("list files", false)
+	m, _ = m.Update(cmdGeneratedMsg{cmd: "ls -la"})
+	if m.(model).explaining {
+		t.Error("Expected a safe command not to be explained")
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.(model).state == stateExplainConfirm {
+		t.Error("Expected a safe command to be copied without confirmation")
+	}
+}
+
+func TestStaleExplanationIgnored(t *testing.T) {
+	var m tea.Model = initialModel("clean up", false)
+	m, _ = m.Update(cmdGeneratedMsg{cmd: "rm -rf build"})
+	m, _ = m.Update(explanationMsg{cmd: "rm -rf dist", text: "Deletes dist."})
+	if result := m.(model); !result.explaining || result.explanation != "" {
+		t.Error("Expected an explanation of an older command to be ignored")
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// explainShellHost is checked to decide between explainshell.com and the
+// local explainer
+const explainShellHost = "explainshell.com:443"
+
+// explainShellMsg reports how the command was explained: in the browser, or
+// locally with text to show in the pager
+type explainShellMsg struct {
+	local string
+	err   error
+}
+
+// isOnline reports whether explainshell.com can be reached; replaced in tests
+var isOnline = func() bool {
+	conn, err := net.DialTimeout("tcp", explainShellHost, 2*time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// browserCommand builds the command that opens url in the default browser on
+// goos
+func browserCommand(goos, url string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		return exec.Command("open", url)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	}
+	return exec.Command("xdg-open", url)
+}
+
+// openBrowser opens url without waiting for the browser; replaced in tests
+var openBrowser = func(url string) error {
+	return browserCommand(runtime.GOOS, url).Start()
+}
+
+// explainShell opens the command in explainshell.com, or explains it from the
+// local manual pages when offline
+func (m model) explainShell() tea.Cmd {
+	cmd := m.joinedCommand()
+	return func() tea.Msg {
+		if isOnline() {
+			return explainShellMsg{err: openBrowser(toShareLink(cmd))}
+		}
+		return explainShellMsg{local: localExplanation(cmd, manPage)}
+	}
+}
+
+// overstrikeRe matches the backspace sequences man uses for bold and underline
+var overstrikeRe = regexp.MustCompile(".\b")
+
+// manPage returns the plain text of prog's manual page; replaced in tests
+var manPage = func(prog string) (string, error) {
+	if runtime.GOOS == "windows" {
+		return "", errors.New("no manual pages on Windows")
+	}
+	cmd := exec.Command("man", "-P", "cat", prog)
+	cmd.Env = append(os.Environ(), "MANWIDTH=80")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return overstrikeRe.ReplaceAllString(string(out), ""), nil
+}
+
+// localExplanation explains each program in cmd and the flags passed to it,
+// using the one-line summary and option descriptions from its manual page
+func localExplanation(cmd string, page func(prog string) (string, error)) string {
+	var out strings.Builder
+	out.WriteString("Offline, so explained from local manual pages:\n")
+	for _, segment := range splitSegments(cmd) {
+		fields := strings.Fields(segment)
+		// Skip variable assignments and sudo to find the program
+		for len(fields) > 0 && (strings.Contains(fields[0], "=") || fields[0] == "sudo") {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		prog := fields[0]
+
+		out.WriteString("\n" + segment + "\n")
+		text, err := page(prog)
+		if err != nil {
+			out.WriteString("  No manual page for " + prog + "\n")
+			continue
+		}
+		if summary := manSummary(text); summary != "" {
+			out.WriteString("  " + summary + "\n")
+		}
+		for _, flag := range fields[1:] {
+			if !strings.HasPrefix(flag, "-") || flag == "-" || flag == "--" {
+				continue
+			}
+			for _, f := range expandFlag(flag) {
+				if desc := manOption(text, f); desc != "" {
+					out.WriteString(fmt.Sprintf("  %s: %s\n", f, desc))
+				} else {
+					out.WriteString(fmt.Sprintf("  %s: not described in the manual\n", f))
+				}
+			}
+		}
+	}
+	return out.String()
+}
+
+// expandFlag splits combined short flags like -sh into -s and -h, and drops
+// the value from --name=value
+func expandFlag(flag string) []string {
+	if strings.HasPrefix(flag, "--") {
+		name, _, _ := strings.Cut(flag, "=")
+		return []string{name}
+	}
+	if len(flag) <= 2 {
+		return []string{flag}
+	}
+	var flags []string
+	for _, r := range flag[1:] {
+		flags = append(flags, "-"+string(r))
+	}
+	return flags
+}
+
+// manSummary returns the one-line description from a manual page's NAME
+// section, e.g. "du - estimate file space usage"
+func manSummary(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "NAME" && i+1 < len(lines) {
+			return strings.TrimSpace(lines[i+1])
+		}
+	}
+	return ""
+}
+
+// manOption finds where a manual page describes flag, returning the
+// description that follows it, joined onto one line
+func manOption(text, flag string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !optionLineStarts(trimmed, flag) {
+			continue
+		}
+		// The description is either on the same line or indented below it
+		desc := []string{trimmed}
+		for _, next := range lines[i+1:] {
+			next = strings.TrimSpace(next)
+			if next == "" || strings.HasPrefix(next, "-") || len(desc) == 3 {
+				break
+			}
+			desc = append(desc, next)
+		}
+		return strings.Join(desc, " ")
+	}
+	return ""
+}
+
+// optionLineStarts reports whether line starts describing flag, as in
+// "-s, --summarize" or "--max-depth=N"
+func optionLineStarts(line, flag string) bool {
+	for _, part := range strings.Split(line, ",") {
+		part = strings.TrimSpace(part)
+		if part == flag || strings.HasPrefix(part, flag+" ") || strings.HasPrefix(part, flag+"=") || strings.HasPrefix(part, flag+"[") {
+			return true
+		}
+		// Only the option list at the start of the line counts
+		if !strings.HasPrefix(part, "-") {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stubExplainShell sets connectivity and records the URL opened in the browser
+func stubExplainShell(t *testing.T, online bool) *string {
+	t.Helper()
+	var opened string
+	origOnline, origOpen := isOnline, openBrowser
+	isOnline = func() bool { return online }
+	openBrowser = func(u string) error {
+		opened = u
+		return nil
+	}
+	t.Cleanup(func() { isOnline, openBrowser = origOnline, origOpen })
+	return &opened
+}
+
+func TestExplainShellOpensURL(t *testing.T) {
+	opened := stubExplainShell(t, true)
+
+	cmd := `find . -name '*.log' -mtime +7 | xargs -r rm -f`
+	var m tea.Model = initialModel("delete old logs", false)
+	m, _ = m.Update(cmdGeneratedMsg{cmd: cmd})
+	_, teaCmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'l'}})
+	if teaCmd == nil {
+		t.Fatal("Expected L to look up the command")
+	}
+	if msg, ok := teaCmd().(explainShellMsg); !ok || msg.err != nil || msg.local != "" {
+		t.Fatalf("Expected the browser to be opened, got %#v", msg)
+	}
+
+	expected := "https://explainshell.com/explain?cmd=find+.+-name+%27%2A.log%27+-mtime+%2B7+%7C+xargs+-r+rm+-f"
+	if *opened != expected {
+		t.Errorf("Expected %q, got %q", expected, *opened)
+	}
+	parsed, err := url.Parse(*opened)
+	if err != nil || parsed.Query().Get("cmd") != cmd {
+		t.Errorf("Expected the URL to decode to the command, got %v (err %v)", parsed, err)
+	}
+}
+
+const duManPage = `DU(1)                            User Commands                           DU(1)
+
+NAME
+       du - estimate file space usage
+
+OPTIONS
+       -h, --human-readable
+              print sizes in human readable format (e.g., 1K 234M 2G)
+
+       -s, --summarize
+              display only a total for each argument
+`
+
+func TestExplainShellOfflineUsesManPages(t *testing.T) {
+	stubExplainShell(t, false)
+	origMan := manPage
+	manPage = func(prog string) (string, error) {
+		if prog == "du" {
+			return duManPage, nil
+		}
+		return "", errors.New("no manual entry")
+	}
+	t.Cleanup(func() { manPage = origMan })
+
+	m := initialModel("folder sizes", false)
+	m.generatedCmd = "du -sh * | sort -h"
+	msg, ok := m.explainShell()().(explainShellMsg)
+	if !ok {
+		t.Fatalf("Expected an explainShellMsg, got %#v", msg)
+	}
+	for _, want := range []string{
+		"du - estimate file space usage",
+		"-s: -s, --summarize display only a total for each argument",
+		"-h: -h, --human-readable print sizes in human readable format",
+		"No manual page for sort",
+	} {
+		if !strings.Contains(msg.local, want) {
+			t.Errorf("Expected the local explanation to contain %q, got:\n%s", want, msg.local)
+		}
+	}
+}
+
+func TestExpandFlag(t *testing.T) {
+	tests := map[string][]string{
+		"-sh":           {"-s", "-h"},
+		"-v":            {"-v"},
+		"--max-depth=1": {"--max-depth"},
+		"--color":       {"--color"},
+	}
+	for flag, want := range tests {
+		got := expandFlag(flag)
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("Expected %v for %q, got %v", want, flag, got)
+		}
+	}
+}
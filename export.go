@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// exportFormat is a build tool the steps can be exported to
+type exportFormat int
+
+const (
+	exportMake exportFormat = iota
+	exportJust
+)
+
+// String returns the name of the export format's file
+func (f exportFormat) String() string {
+	if f == exportJust {
+		return "justfile"
+	}
+	return "Makefile"
+}
+
+// exportSavedMsg reports the result of writing the steps to a build file
+type exportSavedMsg struct {
+	format exportFormat
+	path   string
+	err    error
+}
+
+// exportRecipes turns steps into one recipe line each. Separators at the end
+// of a step aren't needed once each step is its own target, while a step that
+// continues onto the next (a trailing pipe or backslash) is merged with it.
+func exportRecipes(steps []string) []string {
+	var recipes []string
+	var pending string
+	for _, step := range steps {
+		step = strings.TrimSpace(step)
+		if pending != "" {
+			step = pending + " " + step
+			pending = ""
+		}
+
+		switch {
+		case strings.HasSuffix(step, "|"):
+			pending = step
+			continue
+		case strings.HasSuffix(step, "\\") && !strings.HasSuffix(step, `\\`):
+			pending = strings.TrimSpace(strings.TrimSuffix(step, "\\"))
+			continue
+		case strings.HasSuffix(step, "&&") && !strings.HasSuffix(step, `\&&`):
+			step = strings.TrimSpace(strings.TrimSuffix(step, "&&"))
+		case strings.HasSuffix(step, ";") && !strings.HasSuffix(step, `\;`):
+			step = strings.TrimSpace(strings.TrimSuffix(step, ";"))
+		}
+		recipes = append(recipes, step)
+	}
+	if pending != "" {
+		recipes = append(recipes, pending)
+	}
+	return recipes
+}
+
+// shellStateWords start steps that only change the shell they run in, which
+// is gone by the next recipe
+var shellStateWords = map[string]bool{"cd": true, "pushd": true, "popd": true, "export": true, "unset": true, "source": true, ".": true, "alias": true, "set": true}
+
+// exportHeader is the comment at the top of an exported file. Each recipe
+// runs in a shell of its own, so when a step changes directory or the
+// environment the header warns that later steps won't see it.
+func exportHeader(prompt string, recipes []string) string {
+	header := "# Generated by ClippyCLI for: " + strings.Join(strings.Fields(prompt), " ") + "\n"
+	for _, recipe := range recipes {
+		if fields := strings.Fields(recipe); len(fields) > 0 && shellStateWords[fields[0]] {
+			header += "# Note: each step runs in its own shell, so cd and export don't carry over to the steps after them\n"
+			break
+		}
+	}
+	return header
+}
+
+// stepTargets returns the target names for n steps
+func stepTargets(n int) []string {
+	targets := make([]string, n)
+	for i := range targets {
+		targets[i] = fmt.Sprintf("step%d", i+1)
+	}
+	return targets
+}
+
+// formatMakefile writes the steps as Makefile targets that each depend on the
+// previous one, so they run in order even with make -j. Make expands $ in
+// recipes, so it's doubled to reach the shell unchanged.
+func formatMakefile(prompt string, steps []string) string {
+	recipes := exportRecipes(steps)
+	targets := stepTargets(len(recipes))
+
+	var out strings.Builder
+	out.WriteString(exportHeader(prompt, recipes))
+	out.WriteString(".PHONY: all " + strings.Join(targets, " ") + "\n\n")
+	out.WriteString("all: " + targets[len(targets)-1] + "\n")
+	for i, recipe := range recipes {
+		out.WriteString("\n" + targets[i] + ":")
+		if i > 0 {
+			out.WriteString(" " + targets[i-1])
+		}
+		out.WriteString("\n\t" + strings.ReplaceAll(recipe, "$", "$$") + "\n")
+	}
+	return out.String()
+}
+
+// formatJustfile writes the steps as just recipes that each depend on the
+// previous one. just only interpolates {{...}}, so that's all that's escaped.
+func formatJustfile(prompt string, steps []string) string {
+	recipes := exportRecipes(steps)
+	targets := stepTargets(len(recipes))
+
+	var out strings.Builder
+	out.WriteString(exportHeader(prompt, recipes))
+	out.WriteString("all: " + targets[len(targets)-1] + "\n")
+	for i, recipe := range recipes {
+		out.WriteString("\n" + targets[i] + ":")
+		if i > 0 {
+			out.WriteString(" " + targets[i-1])
+		}
+		out.WriteString("\n    " + strings.ReplaceAll(recipe, "{{", `{{ "{{" }}`) + "\n")
+	}
+	return out.String()
+}
+
+// writeExport creates path with content, refusing to replace a file that's
+// already there, which may be a hand-written Makefile
+func writeExport(path, content string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s already exists", path)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// exportCmd writes the generated steps to each requested build file
+func (m model) exportCmd() tea.Cmd {
+	steps := m.steps()
+	if len(steps) == 0 {
+		return nil
+	}
+
+	var cmds []tea.Cmd
+	write := func(format exportFormat, path, content string) {
+		cmds = append(cmds, func() tea.Msg {
+			return exportSavedMsg{format: format, path: path, err: writeExport(path, content)}
+		})
+	}
+	if m.opts.exportMake != "" {
+		write(exportMake, m.opts.exportMake, formatMakefile(m.prompt, steps))
+	}
+	if m.opts.exportJust != "" {
+		write(exportJust, m.opts.exportJust, formatJustfile(m.prompt, steps))
+	}
+	return tea.Batch(cmds...)
+}
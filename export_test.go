@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFormatMakefile(t *testing.T) {
+	got := formatMakefile("build and\npackage", []string{"go build -o bin/app .", `tar -czf "app-$(date +%F).tgz" bin &&`, "echo $HOME"})
+
+	expected := "# Generated by ClippyCLI for: build and package\n" +
+		".PHONY: all step1 step2 step3\n" +
+		"\n" +
+		"all: step3\n" +
+		"\n" +
+		"step1:\n" +
+		"\tgo build -o bin/app .\n" +
+		"\n" +
+		"step2: step1\n" +
+		"\ttar -czf \"app-$$(date +%F).tgz\" bin\n" +
+		"\n" +
+		"step3: step2\n" +
+		"\techo $$HOME\n"
+	if got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
+
+func TestFormatMakefileStructure(t *testing.T) {
+	got := formatMakefile("clean", []string{"rm -rf dist"})
+
+	// Recipes must be indented with a tab, not spaces, or make rejects them
+	for _, line := range strings.Split(got, "\n") {
+		if strings.HasPrefix(line, " ") {
+			t.Errorf("Expected no space-indented lines, got %q", line)
+		}
+	}
+	if !strings.Contains(got, "all: step1\n") || !strings.Contains(got, "step1:\n\trm -rf dist\n") {
+		t.Errorf("Expected a single step target, got:\n%s", got)
+	}
+}
+
+func TestFormatJustfile(t *testing.T) {
+	got := formatJustfile("deploy", []string{"docker build -t app .", "docker inspect -f '{{.Id}}' app"})
+
+	expected := "# Generated by ClippyCLI for: deploy\n" +
+		"all: step2\n" +
+		"\n" +
+		"step1:\n" +
+		"    docker build -t app .\n" +
+		"\n" +
+		"step2: step1\n" +
+		"    docker inspect -f '{{ \"{{\" }}.Id}}' app\n"
+	if got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
+
+func TestExportRecipes(t *testing.T) {
+	got := exportRecipes([]string{"cat access.log |", "grep 404 |", "sort;", "make \\", "  install"})
+	expected := []string{"cat access.log | grep 404 | sort", "make install"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestExportWrittenAfterGeneration(t *testing.T) {
+	dir := t.TempDir()
+	testModel := initialModel("build", false)
+	testModel.opts.exportMake = filepath.Join(dir, "Makefile")
+	testModel.opts.exportJust = filepath.Join(dir, "justfile")
+
+	var m tea.Model = testModel
+	m, cmd := m.Update(cmdGeneratedMsg{cmd: "go vet ./...\ngo build ./..."})
+	if cmd == nil {
+		t.Fatal("Expected commands to export the steps")
+	}
+	for _, c := range cmd().(tea.BatchMsg) {
+		if msg := c(); msg != nil {
+			m, _ = m.Update(msg)
+		}
+	}
+
+	if len(m.(model).exported) != 2 || m.(model).exportErr != nil {
+		t.Fatalf("Expected both files to be exported, got %v, %v", m.(model).exported, m.(model).exportErr)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "Makefile"))
+	if err != nil || !strings.Contains(string(data), "step2: step1\n\tgo build ./...") {
+		t.Errorf("Expected the Makefile to hold the steps, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "justfile")); err != nil {
+		t.Errorf("Expected the justfile to be written, got %v", err)
+	}
+}
+
+func TestExportKeepsExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Makefile")
+	if err := os.WriteFile(path, []byte("build:\n\tgo build\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testModel := initialModel("build", false)
+	testModel.opts.exportMake = path
+
+	var m tea.Model = testModel
+	m, cmd := m.Update(cmdGeneratedMsg{cmd: "go vet ./...\ngo build ./..."})
+	for _, msg := range collectMsgs(cmd) {
+		m, _ = m.Update(msg)
+	}
+
+	if err := m.(model).exportErr; err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected exporting over an existing file to fail, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "build:\n\tgo build\n" {
+		t.Errorf("Expected the existing Makefile to be left alone, got %q", data)
+	}
+}
+
+func TestExportNotesShellState(t *testing.T) {
+	note := "each step runs in its own shell"
+	for _, format := range []func(string, []string) string{formatMakefile, formatJustfile} {
+		if got := format("build", []string{"cd build", "export CC=clang", "make"}); !strings.Contains(got, note) {
+			t.Errorf("Expected a note that cd doesn't carry over, got:\n%s", got)
+		}
+		if got := format("build", []string{"mkdir -p build", "make -C build"}); strings.Contains(got, note) {
+			t.Errorf("Expected no note without cd or export, got:\n%s", got)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// favoritesFileName is the saved prompts and commands in the config directory
+const favoritesFileName = "favorites.json"
+
+// favorite is a prompt and the command it produced, saved with f on the
+// result screen so it can be copied again without calling the API
+type favorite struct {
+	Prompt  string    `json:"prompt"`
+	Command string    `json:"command"`
+	Saved   time.Time `json:"saved"`
+}
+
+// favoriteSavedMsg reports the outcome of saving a favorite
+type favoriteSavedMsg struct {
+	err error
+}
+
+// defaultFavoritesPath returns where favorites are kept
+func defaultFavoritesPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, favoritesFileName), nil
+}
+
+// loadFavorites reads the favorites file at path, most recently saved first.
+// A missing file means there are none yet.
+func loadFavorites(path string) ([]favorite, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var favorites []favorite
+	if err := json.Unmarshal(data, &favorites); err != nil {
+		return nil, err
+	}
+	return favorites, nil
+}
+
+// addFavorite puts fav first in favorites. Saving the same prompt and command
+// again moves it to the top with the new time, rather than adding it twice.
+func addFavorite(favorites []favorite, fav favorite) []favorite {
+	updated := []favorite{fav}
+	for _, f := range favorites {
+		if f.Prompt != fav.Prompt || f.Command != fav.Command {
+			updated = append(updated, f)
+		}
+	}
+	return updated
+}
+
+// saveFavorite adds fav to the favorites file at path
+func saveFavorite(path string, fav favorite) error {
+	favorites, err := loadFavorites(path)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(addFavorite(favorites, fav), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// saveFavoriteCmd saves the prompt and the command on screen in the background
+func (m model) saveFavoriteCmd() tea.Cmd {
+	path := m.favoritesPath
+	fav := favorite{Prompt: m.prompt, Command: m.generatedCmd, Saved: time.Now().UTC()}
+	return func() tea.Msg {
+		return favoriteSavedMsg{err: saveFavorite(path, fav)}
+	}
+}
+
+// copyFavorite copies the favorite under the cursor, as if it had just been
+// generated
+func (m model) copyFavorite() (model, tea.Cmd) {
+	fav := m.favorites[m.favoritesCursor]
+	m.prompt = fav.Prompt
+	m.generatedCmd = fav.Command
+	return m, m.executeCommand()
+}
+
+// favoritesView lists the favorites, each as its prompt with the command
+// beneath, marking the one under the cursor
+func (m model) favoritesView() string {
+	var content strings.Builder
+	for i, fav := range m.favorites {
+		cursor := "  "
+		if i == m.favoritesCursor {
+			cursor = "> "
+		}
+		line := cursor + fav.Prompt
+		if i == m.favoritesCursor {
+			content.WriteString(promptStyle.Render(line))
+		} else {
+			content.WriteString(line)
+		}
+		content.WriteString("\n")
+		content.WriteString(dimStyle.Render("    " + strings.ReplaceAll(fav.Command, "\n", "\n    ")))
+		content.WriteString("\n")
+	}
+	return content.String()
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSaveFavoriteRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "favorites.json")
+	first := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, cmd := range []string{"ls", "pwd"} {
+		fav := favorite{Prompt: "p", Command: cmd, Saved: first.Add(time.Duration(i) * time.Hour)}
+		if err := saveFavorite(path, fav); err != nil {
+			t.Fatalf("Expected the favorite to be saved, got %v", err)
+		}
+	}
+
+	favorites, err := loadFavorites(path)
+	if err != nil {
+		t.Fatalf("Expected the favorites to be read, got %v", err)
+	}
+	if len(favorites) != 2 || favorites[0].Command != "pwd" || favorites[1].Command != "ls" {
+		t.Errorf("Expected both favorites, most recent first, got %+v", favorites)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("Expected the favorites file to be created 0600, got %v (err %v)", info.Mode().Perm(), err)
+		}
+	}
+}
+
+func TestSaveFavoriteDuplicateUpdatesTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "favorites.json")
+	first := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	later := first.Add(24 * time.Hour)
+	saves := []favorite{
+		{Prompt: "list", Command: "ls", Saved: first},
+		{Prompt: "where", Command: "pwd", Saved: first},
+		{Prompt: "list", Command: "ls", Saved: later},
+	}
+	for _, fav := range saves {
+		if err := saveFavorite(path, fav); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	favorites, _ := loadFavorites(path)
+	if len(favorites) != 2 {
+		t.Fatalf("Expected the duplicate to replace the first save, got %+v", favorites)
+	}
+	if favorites[0].Command != "ls" || !favorites[0].Saved.Equal(later) {
+		t.Errorf("Expected the resaved favorite first with the new time, got %+v", favorites[0])
+	}
+}
+
+func TestLoadFavoritesMissing(t *testing.T) {
+	favorites, err := loadFavorites(filepath.Join(t.TempDir(), "favorites.json"))
+	if err != nil || favorites != nil {
+		t.Errorf("Expected no favorites and no error for a missing file, got %v, %v", favorites, err)
+	}
+}
+
+func TestFavoriteKeySaves(t *testing.T) {
+	m := initialModel("list files", false)
+	m.state = stateResult
+	m.generatedCmd = "ls -la"
+	m.favoritesPath = filepath.Join(t.TempDir(), "favorites.json")
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	for _, msg := range collectMsgs(cmd) {
+		if saved, ok := msg.(favoriteSavedMsg); ok {
+			updated, _ = updated.Update(saved)
+		}
+	}
+	m = updated.(model)
+	if !m.favoriteSaved || !strings.Contains(m.View(), "Saved to favorites") {
+		t.Errorf("Expected the result screen to confirm the save, got %q", m.View())
+	}
+
+	favorites, _ := loadFavorites(m.favoritesPath)
+	if len(favorites) != 1 || favorites[0].Prompt != "list files" || favorites[0].Command != "ls -la" {
+		t.Errorf("Expected the prompt and command to be saved, got %+v", favorites)
+	}
+}
+
+func TestFavoritesPickCopies(t *testing.T) {
+	copied := stubClipboard(t)
+
+	m := newModel(options{favorites: true})
+	m.favorites = []favorite{{Prompt: "list", Command: "ls"}, {Prompt: "where", Command: "pwd"}}
+	if m.state != stateFavorites {
+		t.Fatalf("Expected --favorites to start on the list, got state %d", m.state)
+	}
+	if view := m.View(); !strings.Contains(view, "where") || !strings.Contains(view, "pwd") {
+		t.Errorf("Expected the list to show prompts and commands, got %q", view)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	collectMsgs(cmd)
+	if *copied != "pwd" {
+		t.Errorf("Expected the highlighted favorite to be copied, got %q", *copied)
+	}
+}
+
+func TestParseArgsFavorites(t *testing.T) {
+	opts, err := parseArgs([]string{"--favorites"})
+	if err != nil || !opts.favorites {
+		t.Errorf("Expected --favorites to be set, got %+v (err %v)", opts, err)
+	}
+	if _, err := parseArgs([]string{"--favorites", "list files"}); err == nil {
+		t.Error("Expected --favorites with a prompt to be rejected")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// maxGitContext caps the git output sent to the model so a huge working
+// tree doesn't blow up the prompt
+const maxGitContext = 4000
+
+// runGitCmd runs git with the given arguments in the current directory;
+// replaced in tests
+var runGitCmd = func(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "git", args...).Output()
+	return string(out), err
+}
+
+// capText truncates s to at most limit bytes on a line boundary, noting how
+// much was left out
+func capText(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	cut := strings.LastIndex(s[:limit], "\n")
+	if cut < 0 {
+		cut = limit
+	}
+	return fmt.Sprintf("%s\n... (%d more bytes truncated)", s[:cut], len(s)-cut)
+}
+
+// gitContext summarizes the working tree's changes so prompts like "commit
+// these changes" can produce a relevant message. It's empty outside a git
+// repository or when there's nothing to report.
+func gitContext() string {
+	status, err := runGitCmd("status", "--short", "--branch")
+	if err != nil {
+		return ""
+	}
+	status = strings.TrimSpace(status)
+
+	var sections []string
+	if status != "" {
+		sections = append(sections, "Git status:\n"+status)
+	}
+	if stat, err := runGitCmd("diff", "HEAD", "--stat"); err == nil && strings.TrimSpace(stat) != "" {
+		sections = append(sections, "Git diff summary:\n"+strings.TrimSpace(stat))
+	}
+	if len(sections) == 0 {
+		return ""
+	}
+	return capText(strings.Join(sections, "\n"), maxGitContext)
+}
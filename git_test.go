@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// stubGit replaces git with canned output for each subcommand
+func stubGit(t *testing.T, outputs map[string]string) {
+	t.Helper()
+	orig := runGitCmd
+	runGitCmd = func(args ...string) (string, error) {
+		if out, ok := outputs[args[0]]; ok {
+			return out, nil
+		}
+		return "", errors.New("fatal: not a git repository")
+	}
+	t.Cleanup(func() { runGitCmd = orig })
+}
+
+func TestGitContextIncluded(t *testing.T) {
+	stubGit(t, map[string]string{
+		"status": "## main...origin/main\n M main.go\n?? git.go\n",
+		"diff":   " main.go | 12 ++++++++----\n 1 file changed, 8 insertions(+), 4 deletions(-)\n",
+	})
+
+	envInfo := getEnvironmentInfo(envOptions{gitContext: true})
+	for _, want := range []string{"Git status:", " M main.go", "Git diff summary:", "1 file changed"} {
+		if !strings.Contains(envInfo, want) {
+			t.Errorf("Expected environment info to contain %q", want)
+		}
+	}
+
+	if strings.Contains(getEnvironmentInfo(envOptions{}), "Git status:") {
+		t.Error("Expected git context to be omitted without --git-context")
+	}
+}
+
+func TestGitContextOutsideRepository(t *testing.T) {
+	stubGit(t, nil)
+	if got := gitContext(); got != "" {
+		t.Errorf("Expected no git context outside a repository, got %q", got)
+	}
+}
+
+func TestGitContextIsCapped(t *testing.T) {
+	stubGit(t, map[string]string{
+		"status": "## main\n" + strings.Repeat(" M some/long/path/to/a/file.go\n", 500),
+	})
+
+	got := gitContext()
+	if len(got) > maxGitContext+100 {
+		t.Errorf("Expected git context to be capped near %d bytes, got %d", maxGitContext, len(got))
+	}
+	if !strings.Contains(got, "truncated") {
+		t.Error("Expected a note that the output was truncated")
+	}
+}
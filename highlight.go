@@ -0,0 +1,176 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// shellTokenKind is what part of a command a token is, for highlighting
+type shellTokenKind int
+
+const (
+	tokenPlain    shellTokenKind = iota // Arguments, whitespace and comments
+	tokenCommand                        // The program being run
+	tokenFlag                           // An option like -l or --all
+	tokenString                         // A quoted string, quotes included
+	tokenOperator                       // Pipes, &&, ||, ; and redirections
+)
+
+// shellToken is one piece of a command. Joined back together, a command's
+// tokens are exactly the command.
+type shellToken struct {
+	kind shellTokenKind
+	text string
+}
+
+// operatorChars start pipes, lists and redirections
+const operatorChars = "|&;<>"
+
+// tokenizeShell splits cmd into tokens for highlighting. It's a display aid
+// rather than a parser: words are classed by where they sit, so the first
+// word after a pipe or && is a command, and anything it can't make sense of
+// is left plain.
+func tokenizeShell(cmd string) []shellToken {
+	var tokens []shellToken
+	add := func(kind shellTokenKind, text string) {
+		if text != "" {
+			tokens = append(tokens, shellToken{kind, text})
+		}
+	}
+
+	runes := []rune(cmd)
+	expectCommand := true
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t':
+			start := i
+			for i < len(runes) && (runes[i] == ' ' || runes[i] == '\t') {
+				i++
+			}
+			add(tokenPlain, string(runes[start:i]))
+		case r == '\n':
+			add(tokenPlain, "\n")
+			i++
+			// A line continued with a backslash is still the same command
+			if i < 2 || runes[i-2] != '\\' {
+				expectCommand = true
+			}
+		case r == '#' && (i == 0 || runes[i-1] == ' ' || runes[i-1] == '\t' || runes[i-1] == '\n'):
+			start := i
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			add(tokenPlain, string(runes[start:i]))
+		case strings.ContainsRune(operatorChars, r):
+			start := i
+			for i < len(runes) && strings.ContainsRune(operatorChars, runes[i]) {
+				i++
+			}
+			op := string(runes[start:i])
+			add(tokenOperator, op)
+			// What follows a redirection is a file, not a command
+			if !strings.ContainsAny(op, "<>") {
+				expectCommand = true
+			}
+		case r == '\'' || r == '"':
+			end := closingQuote(runes, i)
+			add(tokenString, string(runes[i:end]))
+			i = end
+			expectCommand = false
+		default:
+			start := i
+			for i < len(runes) && !isWordEnd(runes[i]) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				i++
+			}
+			word := string(runes[start:i])
+			switch {
+			case expectCommand && isAssignment(word):
+				// FOO=bar cmd still runs cmd
+				add(tokenPlain, word)
+			case expectCommand:
+				add(tokenCommand, word)
+				expectCommand = false
+			case strings.HasPrefix(word, "-"):
+				add(tokenFlag, word)
+			default:
+				add(tokenPlain, word)
+			}
+		}
+	}
+	return tokens
+}
+
+// closingQuote returns the index just past the quote closing the string
+// that starts at runes[start], or the end if it's never closed
+func closingQuote(runes []rune, start int) int {
+	quote := runes[start]
+	for i := start + 1; i < len(runes); i++ {
+		switch {
+		case runes[i] == '\\' && quote == '"':
+			i++
+		case runes[i] == quote:
+			return i + 1
+		}
+	}
+	return len(runes)
+}
+
+// isWordEnd reports whether r ends an unquoted word
+func isWordEnd(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\'' || r == '"' || strings.ContainsRune(operatorChars, r)
+}
+
+// isAssignment reports whether word sets a variable, like FOO=bar
+func isAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !(r >= 'A' && r <= 'Z') && !(r >= 'a' && r <= 'z') && (i == 0 || !(r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// highlightCommand colors cmd's tokens for the command box. Every piece
+// carries the box's background, since each one's styling ends with a reset.
+func highlightCommand(cmd string) string {
+	base := lipgloss.NewStyle().Background(colors.commandBackground).Foreground(colors.command)
+	styles := map[shellTokenKind]lipgloss.Style{
+		tokenPlain:    base,
+		tokenCommand:  base.Foreground(colors.syntaxCommand).Bold(true),
+		tokenFlag:     base.Foreground(colors.syntaxFlag),
+		tokenString:   base.Foreground(colors.syntaxString),
+		tokenOperator: base.Foreground(colors.syntaxOperator),
+	}
+
+	var out strings.Builder
+	for _, token := range tokenizeShell(cmd) {
+		// Rendering lines separately stops lipgloss padding them to one width
+		for i, line := range strings.Split(token.text, "\n") {
+			if i > 0 {
+				out.WriteString("\n")
+			}
+			if line != "" {
+				out.WriteString(styles[token.kind].Render(line))
+			}
+		}
+	}
+	return out.String()
+}
+
+// displayCommand returns cmd as shown in the result's command box:
+// highlighted unless --no-highlight is set
+func (m model) displayCommand(cmd string) string {
+	if m.opts.noHighlight {
+		return cmd
+	}
+	return highlightCommand(cmd)
+}
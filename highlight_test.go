@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func TestTokenizeShell(t *testing.T) {
+	cmd := `LC_ALL=C find . -name "*.go" 2>/dev/null | xargs grep -l 'TODO' && echo done # note`
+	var kinds []string
+	var joined strings.Builder
+	names := map[shellTokenKind]string{tokenCommand: "cmd", tokenFlag: "flag", tokenString: "str", tokenOperator: "op"}
+	for _, token := range tokenizeShell(cmd) {
+		joined.WriteString(token.text)
+		if name, ok := names[token.kind]; ok {
+			kinds = append(kinds, name+":"+token.text)
+		}
+	}
+
+	if joined.String() != cmd {
+		t.Errorf("Expected the tokens to join back into the command, got %q", joined.String())
+	}
+	want := `cmd:find flag:-name str:"*.go" op:> op:| cmd:xargs flag:-l str:'TODO' op:&& cmd:echo`
+	if got := strings.Join(kinds, " "); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestTokenizeShellLines(t *testing.T) {
+	tokens := tokenizeShell("tar -czf out.tgz \\\n  -C src .\nls")
+	var commands []string
+	for _, token := range tokens {
+		if token.kind == tokenCommand {
+			commands = append(commands, token.text)
+		}
+	}
+	if strings.Join(commands, ",") != "tar,ls" {
+		t.Errorf("Expected a continued line to stay part of tar, got commands %q", commands)
+	}
+}
+
+func TestHighlightCommand(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	t.Cleanup(func() { lipgloss.SetColorProfile(termenv.Ascii) })
+
+	cmd := "ls -la | grep 'go'\ncat x"
+	highlighted := highlightCommand(cmd)
+	if !strings.Contains(highlighted, "\x1b[") {
+		t.Fatalf("Expected escape codes, got %q", highlighted)
+	}
+	if lines := strings.Split(highlighted, "\n"); len(lines) != 2 {
+		t.Errorf("Expected the line breaks kept, got %d lines", len(lines))
+	}
+
+	m := newModel(options{noHighlight: true})
+	if m.displayCommand(cmd) != cmd {
+		t.Errorf("Expected --no-highlight to show the command as is, got %q", m.displayCommand(cmd))
+	}
+}
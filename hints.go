@@ -0,0 +1,25 @@
+package main
+
+import "time"
+
+// loadingHints rotate under the spinner while waiting for a reply, so a slow
+// generation doesn't look stuck
+var loadingHints = []string{
+	"Thinking...",
+	"Analyzing your environment...",
+	"Consulting the model...",
+	"Almost there...",
+}
+
+// hintInterval is how long each loading hint is shown
+const hintInterval = 2 * time.Second
+
+// loadingHint returns the message shown next to the spinner, worked out from
+// how many times it has ticked during this generation
+func (m model) loadingHint() string {
+	if m.opts.noLoadingHints {
+		return loadingHints[0]
+	}
+	elapsed := time.Duration(m.loadingTicks) * m.spinner.Spinner.FPS
+	return loadingHints[int(elapsed/hintInterval)%len(loadingHints)]
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tick sends n spinner ticks to m
+func tick(m tea.Model, n int) tea.Model {
+	for i := 0; i < n; i++ {
+		m, _ = m.Update(spinner.TickMsg{ID: m.(model).spinner.ID(), Time: time.Now()})
+	}
+	return m
+}
+
+func TestLoadingHintsRotate(t *testing.T) {
+	var m tea.Model = initialModel("list files", false)
+	if view := m.View(); !strings.Contains(view, loadingHints[0]) {
+		t.Fatalf("Expected the first hint straight away, got %q", view)
+	}
+
+	perHint := int(hintInterval / m.(model).spinner.Spinner.FPS)
+	m = tick(m, perHint)
+	if view := m.View(); !strings.Contains(view, loadingHints[1]) {
+		t.Errorf("Expected the second hint after %v, got %q", hintInterval, view)
+	}
+
+	// A new generation starts from the first hint again
+	m, _ = m.Update(cmdGeneratedMsg{cmd: "ls"})
+	if m.(model).loadingTicks != 0 {
+		t.Errorf("Expected the tick count to reset, got %d", m.(model).loadingTicks)
+	}
+}
+
+func TestLoadingHintsDisabled(t *testing.T) {
+	var m tea.Model = newModel(options{prompt: "list files", noLoadingHints: true})
+	m = tick(m, 3*int(hintInterval/m.(model).spinner.Spinner.FPS))
+	if view := m.View(); !strings.Contains(view, "Thinking...") {
+		t.Errorf("Expected only Thinking... with --no-loading-hints, got %q", view)
+	}
+}
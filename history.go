@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultHistoryCount is how many entries --history prints without a count
+const defaultHistoryCount = 20
+
+// historyEntry is one generated command, as a line of history.jsonl
+type historyEntry struct {
+	Time    time.Time `json:"time"`
+	Prompt  string    `json:"prompt"`
+	Command string    `json:"command"`
+	Model   string    `json:"model,omitempty"`
+}
+
+// historySavedMsg reports the outcome of writing a history entry
+type historySavedMsg struct {
+	err error
+}
+
+// defaultHistoryPath returns where generated commands are recorded
+func defaultHistoryPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// appendHistory appends entry as a JSON line to the history file at path.
+// The history can hold anything typed as a prompt, so it's private to the
+// user.
+func appendHistory(path string, entry historyEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// readHistory returns the last n entries in the history file at path, oldest
+// first, or all of them when n is zero. Lines that don't parse, like one cut
+// short by a crash mid-write, are skipped rather than failing the whole read.
+func readHistory(path string, n int) ([]historyEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []historyEntry
+	scanner := bufio.NewScanner(f)
+	// Scripts can make for long lines
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry historyEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Command == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries, nil
+}
+
+// printHistory writes entries for reading in a terminal, each as its time
+// and prompt followed by the indented command
+func printHistory(w io.Writer, entries []historyEntry) {
+	for i, entry := range entries {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s  %s\n", entry.Time.Local().Format("2006-01-02 15:04"), entry.Prompt)
+		fmt.Fprintf(w, "  %s\n", strings.ReplaceAll(entry.Command, "\n", "\n  "))
+	}
+}
+
+// runHistory implements --history: it prints the last n generated commands.
+// It returns the process exit code.
+func runHistory(path string, n int, stdout, stderr io.Writer) int {
+	entries, err := readHistory(path, n)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: could not read history: %v\n", err)
+		return 1
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(stdout, "No commands in the history yet.")
+		return 0
+	}
+	printHistory(stdout, entries)
+	return 0
+}
+
+// replayPrompt returns the prompt n entries back in the history file at path,
+// where 1 is the most recent
+func replayPrompt(path string, n int) (string, error) {
+	entries, err := readHistory(path, 0)
+	if err != nil {
+		return "", fmt.Errorf("could not read history: %w", err)
+	}
+	switch {
+	case len(entries) == 0:
+		return "", errors.New("there's nothing to replay, the history is empty")
+	case n > len(entries):
+		noun := "entries"
+		if len(entries) == 1 {
+			noun = "entry"
+		}
+		return "", fmt.Errorf("can't replay entry %d, the history only has %d %s (see --history)", n, len(entries), noun)
+	}
+	return entries[len(entries)-n].Prompt, nil
+}
+
+// saveHistory records a generated command in the background, unless history
+// is off. --always-fresh only stops replies being reused, so it's still
+// recorded then.
+func (m model) saveHistory(command string) tea.Cmd {
+	if m.historyPath == "" {
+		return nil
+	}
+	path := m.historyPath
+	entry := historyEntry{
+		Time:    time.Now().UTC(),
+		Prompt:  m.prompt,
+		Command: command,
+		Model:   m.modelName,
+	}
+	return func() tea.Msg {
+		return historySavedMsg{err: appendHistory(path, entry)}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "history.jsonl")
+	for _, cmd := range []string{"ls", "pwd", "whoami"} {
+		if err := appendHistory(path, historyEntry{Time: time.Now(), Prompt: "p", Command: cmd, Model: "haiku"}); err != nil {
+			t.Fatalf("Expected the entry to be written, got %v", err)
+		}
+	}
+
+	entries, err := readHistory(path, 2)
+	if err != nil {
+		t.Fatalf("Expected the history to be read, got %v", err)
+	}
+	if len(entries) != 2 || entries[0].Command != "pwd" || entries[1].Command != "whoami" {
+		t.Errorf("Expected the last 2 entries oldest first, got %+v", entries)
+	}
+	if all, _ := readHistory(path, 0); len(all) != 3 {
+		t.Errorf("Expected all 3 entries, got %d", len(all))
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Dir(path))
+		if err != nil || info.Mode().Perm() != 0700 {
+			t.Errorf("Expected the history directory to be created 0700, got %v (err %v)", info.Mode().Perm(), err)
+		}
+	}
+}
+
+func TestReadHistorySkipsBadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	data := `{"time":"2026-01-02T03:04:05Z","prompt":"list","command":"ls"}
+not json
+{"time":"2026-01-02T03:04:05Z","prompt":"where","command":"pw`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := readHistory(path, 0)
+	if err != nil {
+		t.Fatalf("Expected bad lines to be skipped, got %v", err)
+	}
+	if len(entries) != 1 || entries[0].Command != "ls" {
+		t.Errorf("Expected only the complete entry, got %+v", entries)
+	}
+}
+
+func TestReadHistoryMissing(t *testing.T) {
+	entries, err := readHistory(filepath.Join(t.TempDir(), "history.jsonl"), 10)
+	if err != nil || entries != nil {
+		t.Errorf("Expected no entries and no error for a missing history, got %+v (err %v)", entries, err)
+	}
+}
+
+func TestGenerationSavesHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	m := newModel(options{})
+	m.historyPath = path
+	m.prompt = "list files"
+	m.generator = fakeGenerator{text: "ls -la"}
+
+	_, cmd := m.Update(m.generateCommand()())
+	runInitCmds(cmd)
+
+	entries, _ := readHistory(path, 0)
+	if len(entries) != 1 || entries[0].Prompt != "list files" || entries[0].Command != "ls -la" || entries[0].Model != m.modelName {
+		t.Fatalf("Expected the generated command to be recorded, got %+v", entries)
+	}
+
+	// --always-fresh skips the cache, but the command is still recorded
+	m.opts.alwaysFresh = true
+	runInitCmds(m.saveHistory("ls"))
+	if entries, _ := readHistory(path, 0); len(entries) != 2 {
+		t.Errorf("Expected history to be written with --always-fresh, got %+v", entries)
+	}
+}
+
+func TestPrintHistory(t *testing.T) {
+	var out bytes.Buffer
+	printHistory(&out, []historyEntry{{Time: time.Now(), Prompt: "two steps", Command: "cd /tmp\nls"}})
+	if !strings.Contains(out.String(), "two steps\n  cd /tmp\n  ls\n") {
+		t.Errorf("Expected the prompt followed by the indented command, got %q", out.String())
+	}
+}
+
+func TestParseArgsHistory(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"--history"}, defaultHistoryCount},
+		{[]string{"--history", "5"}, 5},
+		{[]string{"--history=3"}, 3},
+	}
+	for _, tt := range tests {
+		opts, err := parseArgs(tt.args)
+		if err != nil || opts.history != tt.want {
+			t.Errorf("%v: expected history %d, got %d (err %v)", tt.args, tt.want, opts.history, err)
+		}
+	}
+
+	if _, err := parseArgs([]string{"--history=0"}); err == nil {
+		t.Error("Expected an error for --history=0")
+	}
+}
+
+func TestReplayPrompt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if _, err := replayPrompt(path, 1); err == nil || !strings.Contains(err.Error(), "empty") {
+		t.Errorf("Expected an error for an empty history, got %v", err)
+	}
+
+	for _, prompt := range []string{"first", "second", "third"} {
+		if err := appendHistory(path, historyEntry{Prompt: prompt, Command: "true"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if prompt, err := replayPrompt(path, 1); err != nil || prompt != "third" {
+		t.Errorf("Expected the most recent prompt, got %q (err %v)", prompt, err)
+	}
+	if prompt, err := replayPrompt(path, 3); err != nil || prompt != "first" {
+		t.Errorf("Expected the 3rd most recent prompt, got %q (err %v)", prompt, err)
+	}
+	if _, err := replayPrompt(path, 4); err == nil || !strings.Contains(err.Error(), "only has 3 entries") {
+		t.Errorf("Expected an error saying how many entries there are, got %v", err)
+	}
+}
+
+func TestReplayedPromptRegenerates(t *testing.T) {
+	opts, err := parseArgs([]string{"--replay", "2"})
+	if err != nil || opts.replay != 2 {
+		t.Fatalf("Expected replay 2, got %d (err %v)", opts.replay, err)
+	}
+	if _, err := parseArgs([]string{"--replay", "2", "list files"}); err == nil {
+		t.Error("Expected an error when --replay is given a prompt too")
+	}
+
+	opts.prompt = "list files"
+	m := newModel(opts)
+	if m.state != stateLoading || m.textarea.Value() != "list files" {
+		t.Errorf("Expected the replayed prompt to regenerate and be editable, got state %v and %q", m.state, m.textarea.Value())
+	}
+}
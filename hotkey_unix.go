@@ -0,0 +1,79 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// daemonHotkeyHelp tells the user how to pop up the daemon's prompt
+const daemonHotkeyHelp = "Bind `clippycli daemon trigger` to a keyboard shortcut in your desktop settings (or skhd, sxhkd, etc.) to pop up a prompt."
+
+// daemonSocketPath is where the daemon listens for triggers
+func daemonSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName+".sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("%s-%d.sock", appName, os.Getuid()))
+}
+
+// listenHotkey waits for triggers from `clippycli daemon trigger`. Registering
+// a global hotkey on macOS or X11 needs cgo, so the shortcut is left to the
+// desktop, which runs the trigger command.
+func listenHotkey(ctx context.Context, triggers chan<- struct{}) error {
+	return listenTriggers(ctx, daemonSocketPath(), triggers)
+}
+
+// listenTriggers turns each connection to the socket at path into a trigger
+// until ctx is cancelled
+func listenTriggers(ctx context.Context, path string, triggers chan<- struct{}) error {
+	// A socket that accepts connections belongs to a running daemon, while
+	// one that doesn't was left behind by a crash
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return errors.New("a ClippyCLI daemon is already running")
+	}
+	os.Remove(path)
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		conn.Close()
+		select {
+		case triggers <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// sendTrigger asks a running daemon to pop up its prompt
+func sendTrigger() error {
+	conn, err := net.Dial("unix", daemonSocketPath())
+	if err != nil {
+		return fmt.Errorf("no ClippyCLI daemon is running, start one with `clippycli daemon`: %w", err)
+	}
+	return conn.Close()
+}
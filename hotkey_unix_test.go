@@ -0,0 +1,69 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListenTriggers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clippycli.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	triggers := make(chan struct{}, 1)
+	listenErr := make(chan error, 1)
+	go func() { listenErr <- listenTriggers(ctx, path, triggers) }()
+
+	// Wait for the socket to appear
+	var conn net.Conn
+	var err error
+	for i := 0; i < 100; i++ {
+		if conn, err = net.Dial("unix", path); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Expected the daemon to listen on %s: %v", path, err)
+	}
+	conn.Close()
+
+	select {
+	case <-triggers:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a connection to trigger the prompt")
+	}
+
+	// A second daemon refuses to start
+	if err := listenTriggers(context.Background(), path, triggers); err == nil {
+		t.Error("Expected a second daemon on the same socket to fail")
+	}
+
+	cancel()
+	if err := <-listenErr; err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected the socket to be removed on shutdown")
+	}
+}
+
+func TestListenTriggersReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clippycli.sock")
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	listenErr := make(chan error, 1)
+	go func() { listenErr <- listenTriggers(ctx, path, make(chan struct{}, 1)) }()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err := <-listenErr; err != nil {
+		t.Errorf("Expected a leftover socket file to be replaced, got %v", err)
+	}
+}
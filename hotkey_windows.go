@@ -0,0 +1,91 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// daemonHotkeyHelp tells the user how to pop up the daemon's prompt
+const daemonHotkeyHelp = "Press Ctrl+Alt+G anywhere to pop up a prompt."
+
+var (
+	user32                 = syscall.NewLazyDLL("user32.dll")
+	kernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procRegisterHotKey     = user32.NewProc("RegisterHotKey")
+	procUnregisterHotKey   = user32.NewProc("UnregisterHotKey")
+	procGetMessage         = user32.NewProc("GetMessageW")
+	procPostThreadMessage  = user32.NewProc("PostThreadMessageW")
+	procGetCurrentThreadID = kernel32.NewProc("GetCurrentThreadId")
+)
+
+const (
+	modAlt      = 0x0001
+	modControl  = 0x0002
+	modNoRepeat = 0x4000
+	wmQuit      = 0x0012
+	wmHotkey    = 0x0312
+	hotkeyID    = 1
+)
+
+// winMsg mirrors the Win32 MSG structure
+type winMsg struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+}
+
+// listenHotkey registers Ctrl+Alt+G and sends a trigger for each press until
+// ctx is cancelled
+func listenHotkey(ctx context.Context, triggers chan<- struct{}) error {
+	// Hotkey messages go to the thread that registered the hotkey
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if r, _, err := procRegisterHotKey.Call(0, hotkeyID, modControl|modAlt|modNoRepeat, 'G'); r == 0 {
+		return fmt.Errorf("could not register Ctrl+Alt+G, another program may be using it: %w", err)
+	}
+	defer procUnregisterHotKey.Call(0, hotkeyID)
+
+	// Wake the message loop with WM_QUIT to shut down
+	threadID, _, _ := procGetCurrentThreadID.Call()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			procPostThreadMessage.Call(threadID, wmQuit, 0, 0)
+		case <-done:
+		}
+	}()
+
+	var msg winMsg
+	for {
+		r, _, err := procGetMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+		switch int32(r) {
+		case -1:
+			return err
+		case 0:
+			return nil
+		}
+		if msg.message == wmHotkey {
+			select {
+			case triggers <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// sendTrigger isn't needed on Windows, where the daemon owns the hotkey
+func sendTrigger() error {
+	return errors.New("the daemon listens for Ctrl+Alt+G itself on Windows")
+}
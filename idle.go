@@ -0,0 +1,48 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// idleTickMsg fires when the idle timeout scheduled for a given keypress
+// elapses. Only the tick matching the latest keypress counts.
+type idleTickMsg struct {
+	id int
+}
+
+// scheduleIdleTimeout starts the idle countdown, or returns nil when
+// --idle-timeout isn't set
+func (m model) scheduleIdleTimeout() tea.Cmd {
+	if m.opts.idleTimeout <= 0 {
+		return nil
+	}
+	id := m.idleID
+	return tea.Tick(m.opts.idleTimeout, func(time.Time) tea.Msg {
+		return idleTickMsg{id: id}
+	})
+}
+
+// resetIdleTimeout restarts the countdown after a keypress. Ticks already
+// scheduled become stale and are ignored when they arrive.
+func (m model) resetIdleTimeout() (model, tea.Cmd) {
+	if m.opts.idleTimeout <= 0 {
+		return m, nil
+	}
+	m.idleID++
+	return m, m.scheduleIdleTimeout()
+}
+
+// handleIdleTick quits without copying once the timeout passes with no
+// keypress, except while the user is waiting on a generation or anything
+// else the spinner shows is in progress
+func (m model) handleIdleTick(msg idleTickMsg) (model, tea.Cmd) {
+	if msg.id != m.idleID {
+		return m, nil
+	}
+	if m.spinning() {
+		return m, m.scheduleIdleTimeout()
+	}
+	return m, tea.Quit
+}
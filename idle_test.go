@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestIdleTimeoutQuits(t *testing.T) {
+	testModel := initialModel("", false)
+	testModel.opts.idleTimeout = 30 * time.Second
+
+	// No keypress since startup, so the first countdown quits
+	_, cmd := testModel.Update(idleTickMsg{id: testModel.idleID})
+	if cmd == nil {
+		t.Fatal("Expected a quit command after the idle timeout")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("Expected the idle timeout to quit the program")
+	}
+}
+
+func TestIdleTimeoutResetsOnKeypress(t *testing.T) {
+	testModel := initialModel("", false)
+	testModel.opts.idleTimeout = 30 * time.Second
+	staleID := testModel.idleID
+
+	updatedModel, cmd := testModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'l'}})
+	if updatedModel.(model).idleID == staleID {
+		t.Fatal("Expected a keypress to restart the countdown")
+	}
+	if cmd == nil {
+		t.Error("Expected a keypress to schedule a new countdown")
+	}
+
+	// The countdown from before the keypress no longer counts
+	_, cmd = updatedModel.Update(idleTickMsg{id: staleID})
+	if cmd != nil {
+		t.Error("Expected a stale idle tick to be ignored")
+	}
+}
+
+func TestIdleTimeoutWaitsDuringLoading(t *testing.T) {
+	testModel := initialModel("list files", false)
+	testModel.opts.idleTimeout = time.Millisecond
+	if testModel.state != stateLoading {
+		t.Fatal("Expected quick mode to start loading")
+	}
+
+	_, cmd := testModel.Update(idleTickMsg{id: testModel.idleID})
+	if cmd == nil {
+		t.Fatal("Expected the countdown to be rescheduled while loading")
+	}
+	if _, ok := cmd().(idleTickMsg); !ok {
+		t.Error("Expected another idle tick rather than a quit while loading")
+	}
+}
+
+func TestIdleTimeoutWaitsDuringStreaming(t *testing.T) {
+	for name, setup := range map[string]func(*model){
+		"streaming":  func(m *model) { m.state = stateStreaming },
+		"explaining": func(m *model) { m.state = stateResult; m.explaining = true },
+		"critiquing": func(m *model) { m.state = stateResult; m.critiquing = true },
+	} {
+		testModel := initialModel("list files", false)
+		testModel.opts.idleTimeout = time.Millisecond
+		setup(&testModel)
+
+		_, cmd := testModel.Update(idleTickMsg{id: testModel.idleID})
+		if cmd == nil {
+			t.Fatalf("%s: expected the countdown to be rescheduled", name)
+		}
+		if _, ok := cmd().(idleTickMsg); !ok {
+			t.Errorf("%s: expected another idle tick rather than a quit", name)
+		}
+	}
+}
+
+func TestIdleTimeoutOffByDefault(t *testing.T) {
+	testModel := initialModel("", false)
+	if testModel.scheduleIdleTimeout() != nil {
+		t.Error("Expected no idle countdown without --idle-timeout")
+	}
+
+	opts, err := parseArgs([]string{"--idle-timeout", "45", "list"})
+	if err != nil || opts.idleTimeout != 45*time.Second {
+		t.Errorf("Expected a 45s idle timeout, got %v, %v", opts.idleTimeout, err)
+	}
+	for _, bad := range []string{"0", "-5", "soon"} {
+		if _, err := parseArgs([]string{"--idle-timeout=" + bad}); err == nil {
+			t.Errorf("Expected an error for --idle-timeout=%s", bad)
+		}
+	}
+}
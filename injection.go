@@ -0,0 +1,25 @@
+package main
+
+import "regexp"
+
+// injectionPatterns match phrases commonly used to smuggle instructions into
+// data that gets included in the prompt (file contents, listings, etc.)
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget)\b.{0,20}\b(previous|prior|above|earlier|all|system)\b.{0,20}\b(instructions?|rules?|prompts?|messages?)`),
+	regexp.MustCompile(`(?i)\byou are now\b`),
+	regexp.MustCompile(`(?i)\bnew instructions?\s*:`),
+	regexp.MustCompile(`(?i)\b(override|bypass)\b.{0,20}\b(rules?|instructions?|safety)\b`),
+	regexp.MustCompile(`(?i)\bsystem prompt\b`),
+	regexp.MustCompile(`(?i)</?context\b`),
+}
+
+// looksLikeInjection reports whether s contains text that reads like an
+// attempt to give the model instructions
+func looksLikeInjection(s string) bool {
+	for _, re := range injectionPatterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
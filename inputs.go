@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// requiredInput is a value only the user knows, left in the command as a
+// placeholder for them to fill in
+type requiredInput struct {
+	Placeholder string `json:"placeholder"`
+	Description string `json:"description"`
+}
+
+// inputsFormatNote tells the model how to leave placeholders for --ask-inputs
+const inputsFormatNote = `If the command needs values only the user knows (a search pattern, a hostname, a file name they didn't give), write each one in the command as an uppercase placeholder like <PATTERN>, quoted if the value may contain spaces, and list it in "inputs" with a short description. Use an empty list when nothing is missing.`
+
+// cleanInputs drops inputs without a placeholder or whose placeholder doesn't
+// appear in cmd, and merges duplicates, so the fill-in flow only asks for
+// values that will actually be used
+func cleanInputs(cmd string, inputs []requiredInput) []requiredInput {
+	var cleaned []requiredInput
+	seen := map[string]bool{}
+	for _, in := range inputs {
+		in.Placeholder = strings.TrimSpace(in.Placeholder)
+		in.Description = strings.TrimSpace(in.Description)
+		if in.Placeholder == "" || seen[in.Placeholder] || !strings.Contains(cmd, in.Placeholder) {
+			continue
+		}
+		seen[in.Placeholder] = true
+		cleaned = append(cleaned, in)
+	}
+	return cleaned
+}
+
+// safeWordRe matches values the shell reads as one literal word unquoted
+var safeWordRe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// substituteInputs replaces each placeholder in cmd with the user's value,
+// quoted for wherever the placeholder sits, so a value is only ever data: a
+// space, quote or $(...) in it can't split it or run anything. Placeholders
+// are all replaced in one pass, so a value that happens to contain another
+// placeholder is left as typed.
+func substituteInputs(cmd string, inputs []requiredInput, values []string) string {
+	var filled []filledInput
+	for i, in := range inputs {
+		if i < len(values) && in.Placeholder != "" {
+			filled = append(filled, filledInput{placeholder: in.Placeholder, value: values[i]})
+		}
+	}
+	// Longer placeholders go first, in case one starts with another
+	sort.SliceStable(filled, func(i, j int) bool { return len(filled[i].placeholder) > len(filled[j].placeholder) })
+
+	var out strings.Builder
+	var quote byte
+	escaped := false
+	for i := 0; i < len(cmd); i++ {
+		c := cmd[i]
+		if !escaped {
+			if in, ok := placeholderAt(cmd, i, filled); ok {
+				out.WriteString(quoteInput(in.value, quote))
+				i += len(in.placeholder) - 1
+				continue
+			}
+		}
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
+
+// filledInput is a placeholder with the value the user typed for it
+type filledInput struct {
+	placeholder string
+	value       string
+}
+
+// placeholderAt returns the first of inputs whose placeholder starts cmd at i
+func placeholderAt(cmd string, i int, inputs []filledInput) (filledInput, bool) {
+	for _, in := range inputs {
+		if strings.HasPrefix(cmd[i:], in.placeholder) {
+			return in, true
+		}
+	}
+	return filledInput{}, false
+}
+
+// quoteInput escapes value for the quotes it's going inside, or quotes it
+// itself outside quotes unless it's a plain word
+func quoteInput(value string, quote byte) string {
+	switch quote {
+	case '\'':
+		return strings.ReplaceAll(value, "'", `'\''`)
+	case '"':
+		return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(value)
+	}
+	if safeWordRe.MatchString(value) {
+		return value
+	}
+	return shellQuote(value)
+}
+
+// startFillInputs begins asking for the first of the command's inputs
+func (m model) startFillInputs() (model, tea.Cmd) {
+	m.state = stateFillInputs
+	m.inputValues = nil
+	m.textarea.SetValue("")
+	m.textarea.Focus()
+	return m, textarea.Blink
+}
+
+// nextInput records the value typed for the current input, substituting them
+// all into the command once the last one is in
+func (m model) nextInput() model {
+	m.inputValues = append(m.inputValues, strings.TrimSpace(m.textarea.Value()))
+	m.textarea.SetValue("")
+	if len(m.inputValues) < len(m.inputs) {
+		return m
+	}
+
+	m.generatedCmd = substituteInputs(m.generatedCmd, m.inputs, m.inputValues)
+	m.inputs = nil
+	m.state = stateResult
+	return m
+}
+
+// fillInputsView asks for the current input
+func (m model) fillInputsView() string {
+	in := m.inputs[len(m.inputValues)]
+
+	var content strings.Builder
+	content.WriteString(promptStyle.Render("This command needs some details:"))
+	content.WriteString("\n")
+	content.WriteString(m.box(cmdStyle, m.generatedCmd))
+	content.WriteString("\n")
+	label := in.Placeholder
+	if in.Description != "" {
+		label += " - " + in.Description
+	}
+	content.WriteString(promptStyle.Render(fmt.Sprintf("(%d/%d) %s", len(m.inputValues)+1, len(m.inputs), label)))
+	content.WriteString("\n\n")
+	content.WriteString(m.textarea.View())
+	content.WriteString("\n")
+	content.WriteString(m.helpFooter())
+	return content.String()
+}
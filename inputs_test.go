@@ -0,0 +1,120 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseRequiredInputs(t *testing.T) {
+	resp, err := parseCommandResponse(`{
+		"command": "grep -rn \"<PATTERN>\" <DIR>",
+		"inputs": [
+			{"placeholder": "<PATTERN>", "description": "text to search for"},
+			{"placeholder": " <DIR> ", "description": "directory to search"},
+			{"placeholder": "<PATTERN>", "description": "duplicate"},
+			{"placeholder": "<HOST>", "description": "not in the command"},
+			{"placeholder": "", "description": "empty"}
+		]
+	}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []requiredInput{
+		{Placeholder: "<PATTERN>", Description: "text to search for"},
+		{Placeholder: "<DIR>", Description: "directory to search"},
+	}
+	if !reflect.DeepEqual(resp.Inputs, expected) {
+		t.Errorf("Expected %v, got %v", expected, resp.Inputs)
+	}
+
+	resp, err = parseCommandResponse(`{"command": "ls -la", "inputs": []}`)
+	if err != nil || len(resp.Inputs) != 0 {
+		t.Errorf("Expected no inputs, got %v, %v", resp.Inputs, err)
+	}
+}
+
+func TestSubstituteInputs(t *testing.T) {
+	inputs := []requiredInput{{Placeholder: "<PATTERN>"}, {Placeholder: "<DIR>"}}
+	got := substituteInputs(`grep -rn "<PATTERN>" <DIR> | grep -v "<PATTERN>.bak"`, inputs, []string{"TODO fix", "src"})
+	expected := `grep -rn "TODO fix" src | grep -v "TODO fix.bak"`
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestSubstituteInputsQuotesValues(t *testing.T) {
+	tests := []struct {
+		cmd      string
+		value    string
+		expected string
+	}{
+		{`ls <DIR>`, "my files", `ls 'my files'`},
+		{`ls <DIR>`, "$(rm -rf ~)", `ls '$(rm -rf ~)'`},
+		{`ls <DIR>`, "it's", `ls "it's"`},
+		{`grep "<PATTERN>" log`, `say "hi" $(id) \ ` + "`id`", `grep "say \"hi\" \$(id) \\ \` + "`id\\`" + `" log`},
+		{`grep '<PATTERN>' log`, "it's $(id)", `grep 'it'\''s $(id)' log`},
+		{`echo \<DIR> <DIR>`, "a b", `echo \<DIR> 'a b'`},
+	}
+	for _, tt := range tests {
+		got := substituteInputs(tt.cmd, []requiredInput{{Placeholder: "<DIR>"}, {Placeholder: "<PATTERN>"}}, []string{tt.value, tt.value})
+		if got != tt.expected {
+			t.Errorf("%q with %q: expected %q, got %q", tt.cmd, tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestSubstituteInputsSinglePass(t *testing.T) {
+	inputs := []requiredInput{{Placeholder: "<A>"}, {Placeholder: "<B>"}}
+	got := substituteInputs(`echo <A> <B>`, inputs, []string{"<B>", "b"})
+	if expected := `echo '<B>' b`; got != expected {
+		t.Errorf("Expected a value containing a placeholder to be left as typed, got %q", got)
+	}
+}
+
+func TestFillInputsFlow(t *testing.T) {
+	var m tea.Model = initialModel("search for text", false)
+	m, _ = m.Update(cmdGeneratedMsg{
+		cmd: `grep -rn "<PATTERN>" <DIR>`,
+		inputs: []requiredInput{
+			{Placeholder: "<PATTERN>", Description: "text to search for"},
+			{Placeholder: "<DIR>", Description: "directory to search"},
+		},
+	})
+	if m.(model).state != stateFillInputs {
+		t.Fatalf("Expected state to be stateFillInputs, got %v", m.(model).state)
+	}
+	if !strings.Contains(m.(model).View(), "text to search for") {
+		t.Error("Expected the first input's description to be shown")
+	}
+
+	m = typeText(m, "TODO")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.(model).state != stateFillInputs || !strings.Contains(m.(model).View(), "(2/2)") {
+		t.Fatal("Expected to be asked for the second input")
+	}
+
+	m = typeText(m, "src")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	result := m.(model)
+	if result.state != stateResult {
+		t.Errorf("Expected to show the result after the last input, got %v", result.state)
+	}
+	if result.generatedCmd != `grep -rn "TODO" src` {
+		t.Errorf("Expected the values to be substituted, got %q", result.generatedCmd)
+	}
+}
+
+func TestResponseFormatAsksForInputs(t *testing.T) {
+	testModel := initialModel("search", false)
+	if testModel.responseFormat() != "" {
+		t.Fatal("Expected no structured format by default")
+	}
+	testModel.opts.askInputs = true
+	if !strings.Contains(testModel.responseFormat(), `"inputs"`) {
+		t.Error("Expected --ask-inputs to request an inputs list")
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// widgetSnippets bind Ctrl+G to replace the command line with a command
+// generated from it, using --widget mode
+var widgetSnippets = map[string]string{
+	"zsh": `# ClippyCLI: press Ctrl+G to turn the command line into a command
+clippy-widget() {
+  local cmd
+  cmd=$(CLIPPY_BUFFER="$BUFFER" clippycli --widget) && BUFFER=$cmd && CURSOR=$#BUFFER
+  zle redisplay
+}
+zle -N clippy-widget
+bindkey '^G' clippy-widget
+`,
+	"bash": `# ClippyCLI: press Ctrl+G to turn the command line into a command
+clippy-widget() {
+  local cmd
+  cmd=$(CLIPPY_BUFFER="$READLINE_LINE" clippycli --widget) && READLINE_LINE=$cmd && READLINE_POINT=${#READLINE_LINE}
+}
+bind -x '"\C-g": clippy-widget'
+`,
+}
+
+// widgetSnippet returns the key binding for shell
+func widgetSnippet(shell string) (string, error) {
+	snippet, ok := widgetSnippets[shell]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell %q, expected bash or zsh", shell)
+	}
+	return snippet, nil
+}
+
+// widgetRCLine loads the widget from a shell's startup file, so it picks up
+// fixes to the snippet when ClippyCLI is upgraded
+func widgetRCLine(shell string) string {
+	return fmt.Sprintf(`eval "$(clippycli install-widget %s)"`, shell)
+}
+
+// widgetRCFile is the interactive startup file for shell
+func widgetRCFile(shell string, getenv func(string) string, home string) string {
+	if shell == "zsh" {
+		if dir := getenv("ZDOTDIR"); dir != "" {
+			return filepath.Join(dir, ".zshrc")
+		}
+		return filepath.Join(home, ".zshrc")
+	}
+	return filepath.Join(home, ".bashrc")
+}
+
+// installWidgetRC appends the line loading the widget to the startup file at
+// path, reporting false if it was already there
+func installWidgetRC(path, shell string) (bool, error) {
+	line := widgetRCLine(shell)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	if bytes.Contains(data, []byte(line)) {
+		return false, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	// Keep a blank line between the existing contents and ours
+	var prefix string
+	switch {
+	case len(data) == 0:
+	case bytes.HasSuffix(data, []byte("\n")):
+		prefix = "\n"
+	default:
+		prefix = "\n\n"
+	}
+	if _, err := fmt.Fprintf(f, "%s# ClippyCLI shell widget\n%s\n", prefix, line); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// runInstallWidget implements `clippycli install-widget [bash|zsh] [--rc]`:
+// it prints the key binding for the shell, or with --rc adds a line loading
+// it to the shell's startup file. It returns the process exit code.
+func runInstallWidget(args []string, stdout, stderr io.Writer) int {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	writeRC := false
+	for _, arg := range args {
+		switch {
+		case arg == "--rc":
+			writeRC = true
+		case strings.HasPrefix(arg, "-"):
+			fmt.Fprintf(stderr, "Error: unknown option %s\n", arg)
+			return 1
+		default:
+			shell = arg
+		}
+	}
+
+	snippet, err := widgetSnippet(shell)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if !writeRC {
+		fmt.Fprint(stdout, snippet)
+		return 0
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	path := widgetRCFile(shell, os.Getenv, home)
+	added, err := installWidgetRC(path, shell)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: could not update %s: %v\n", path, err)
+		return 1
+	}
+	if added {
+		fmt.Fprintf(stdout, "Added the ClippyCLI widget to %s. Open a new shell and press Ctrl+G.\n", path)
+	} else {
+		fmt.Fprintf(stdout, "The ClippyCLI widget is already in %s.\n", path)
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWidgetSnippets(t *testing.T) {
+	tests := []struct {
+		shell    string
+		expected []string
+	}{
+		{"zsh", []string{`CLIPPY_BUFFER="$BUFFER" clippycli --widget`, "BUFFER=$cmd", "zle -N clippy-widget", "bindkey '^G' clippy-widget"}},
+		{"bash", []string{`CLIPPY_BUFFER="$READLINE_LINE" clippycli --widget`, "READLINE_LINE=$cmd", "READLINE_POINT=${#READLINE_LINE}", `bind -x '"\C-g": clippy-widget'`}},
+	}
+
+	for _, tt := range tests {
+		snippet, err := widgetSnippet(tt.shell)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.shell, err)
+		}
+		for _, want := range tt.expected {
+			if !strings.Contains(snippet, want) {
+				t.Errorf("%s: expected the snippet to contain %q, got:\n%s", tt.shell, want, snippet)
+			}
+		}
+		// Only replace the buffer when generation succeeded
+		if !strings.Contains(snippet, "--widget) && ") {
+			t.Errorf("%s: expected the buffer to be kept when generation fails", tt.shell)
+		}
+	}
+
+	if _, err := widgetSnippet("fish"); err == nil {
+		t.Error("Expected an unsupported shell to be an error")
+	}
+}
+
+func TestWidgetRCFile(t *testing.T) {
+	noEnv := func(string) string { return "" }
+	if got := widgetRCFile("zsh", noEnv, "/home/ada"); got != filepath.Join("/home/ada", ".zshrc") {
+		t.Errorf("Expected ~/.zshrc, got %q", got)
+	}
+	if got := widgetRCFile("zsh", func(string) string { return "/zdot" }, "/home/ada"); got != filepath.Join("/zdot", ".zshrc") {
+		t.Errorf("Expected $ZDOTDIR/.zshrc, got %q", got)
+	}
+	if got := widgetRCFile("bash", noEnv, "/home/ada"); got != filepath.Join("/home/ada", ".bashrc") {
+		t.Errorf("Expected ~/.bashrc, got %q", got)
+	}
+}
+
+func TestInstallWidgetRC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".zshrc")
+	if err := os.WriteFile(path, []byte("export EDITOR=vim"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := installWidgetRC(path, "zsh")
+	if err != nil || !added {
+		t.Fatalf("Expected the widget to be added, got %v, %v", added, err)
+	}
+	// Installing twice must not duplicate the line
+	added, err = installWidgetRC(path, "zsh")
+	if err != nil || added {
+		t.Errorf("Expected a second install to change nothing, got %v, %v", added, err)
+	}
+
+	data, _ := os.ReadFile(path)
+	expected := "export EDITOR=vim\n\n# ClippyCLI shell widget\n" + `eval "$(clippycli install-widget zsh)"` + "\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(data))
+	}
+}
+
+func TestRunInstallWidgetPrintsSnippet(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runInstallWidget([]string{"bash"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected success, got %d: %s", code, stderr.String())
+	}
+	if stdout.String() != widgetSnippets["bash"] {
+		t.Errorf("Expected the bash snippet on stdout, got %q", stdout.String())
+	}
+
+	t.Setenv("SHELL", "/usr/bin/fish")
+	stdout.Reset()
+	if code := runInstallWidget(nil, &stdout, &stderr); code == 0 {
+		t.Error("Expected an unsupported login shell to fail")
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected nothing on stdout after an error, got %q", stdout.String())
+	}
+}
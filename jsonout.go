@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/benmyles/clippycli/clippy"
+)
+
+// jsonResult is what --json writes for a generated command
+type jsonResult struct {
+	Prompt       string     `json:"prompt"`
+	Command      string     `json:"command"`
+	Model        string     `json:"model"`
+	Tokens       jsonTokens `json:"tokens"`
+	Cached       bool       `json:"cached,omitempty"` // Reused from an identical request, so no tokens were used
+	Alternatives []string   `json:"alternatives,omitempty"`
+	Undo         string     `json:"undo,omitempty"`
+	Verify       string     `json:"verify,omitempty"`
+	Warnings     []string   `json:"warnings,omitempty"`
+	PipeToShell  *jsonPipe  `json:"pipe_to_shell,omitempty"` // Set when the command runs a downloaded script
+}
+
+// jsonPipe describes a downloaded script the command runs without saving it,
+// so a program can ask before running it
+type jsonPipe struct {
+	URL string `json:"url"` // Empty if it couldn't be found in the command
+}
+
+// jsonTokens is the token usage of the request
+type jsonTokens struct {
+	Input  int `json:"input"`
+	Output int `json:"output"`
+}
+
+// jsonError is what --json writes instead when something goes wrong
+type jsonError struct {
+	Error string `json:"error"`
+}
+
+// writeJSON writes v as one line of JSON. Commands are full of &, < and >,
+// which are left as they are rather than escaped for HTML.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// writeJSONError writes err as a JSON object, returning the exit code for it
+func writeJSONError(w io.Writer, err error) int {
+	writeJSON(w, jsonError{Error: err.Error()})
+	return 1
+}
+
+// exitWithError reports an error found before anything is generated and
+// exits. With --json it's written as JSON to stdout, so scripts always get
+// an object to parse.
+func exitWithError(opts options, err error) {
+	if opts.jsonOutput {
+		writeJSONError(os.Stdout, err)
+	} else {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+	os.Exit(1)
+}
+
+// runJSON implements --json: it generates a command for the prompt given on
+// the command line and writes it to stdout as a single JSON object, for
+// programs that call clippycli. Nothing else is written to stdout. It
+// returns the process exit code.
+func runJSON(m model, stdout io.Writer) int {
+	if m.prompt == "" {
+		return writeJSONError(stdout, errors.New("--json needs a prompt on the command line"))
+	}
+
+	msg := m.runGeneration()
+	if _, err := generationResult(msg); err != nil {
+		return writeJSONError(stdout, err)
+	}
+	generated := msg.(cmdGeneratedMsg)
+
+	result := jsonResult{
+		Prompt:  m.prompt,
+		Command: generated.cmd,
+		Model:   m.modelName,
+		Tokens:  jsonTokens{Input: generated.inputTokens, Output: generated.outputTokens},
+		Cached:  generated.cached,
+		Undo:    generated.undo,
+		Verify:  generated.verify,
+	}
+	if len(generated.alts) > 1 {
+		result.Alternatives = generated.alts
+	}
+	for _, note := range []string{m.tokensNote, m.temperatureNote} {
+		if note != "" {
+			result.Warnings = append(result.Warnings, note)
+		}
+	}
+	if url, found := detectPipeToShell(generated.cmd); found {
+		result.PipeToShell = &jsonPipe{URL: url}
+		result.Warnings = append(result.Warnings, pipeToShellWarning(generated.cmd))
+	}
+	if generated.stopReason == clippy.StopMaxTokens {
+		result.Warnings = append(result.Warnings, "the reply hit the token limit, so the command may be cut off; increase --max-tokens")
+	}
+	if err := writeJSON(stdout, result); err != nil {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/benmyles/clippycli/clippy"
+)
+
+func TestRunJSON(t *testing.T) {
+	command := "printf \"a\\tb\\n\" > out.txt && echo 'done'\ncat out.txt"
+	m := newModel(options{prompt: "write \"a b\" to a file", jsonOutput: true})
+	m.generator = fakeGenerator{text: command, inputTokens: 412, outputTokens: 9}
+
+	var stdout bytes.Buffer
+	if code := runJSON(m, &stdout); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (output %q)", code, stdout.String())
+	}
+	if strings.Count(stdout.String(), "\n") != 1 || !strings.HasSuffix(stdout.String(), "}\n") {
+		t.Errorf("Expected a single JSON object on one line, got %q", stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "&& echo") {
+		t.Errorf("Expected & to be left unescaped, got %q", stdout.String())
+	}
+
+	var result jsonResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("Expected valid JSON, got %v for %q", err, stdout.String())
+	}
+	expected := jsonResult{
+		Prompt:  "write \"a b\" to a file",
+		Command: command,
+		Model:   m.modelName,
+		Tokens:  jsonTokens{Input: 412, Output: 9},
+	}
+	if result.Prompt != expected.Prompt || result.Command != expected.Command || result.Model != expected.Model || result.Tokens != expected.Tokens {
+		t.Errorf("Expected %+v, got %+v", expected, result)
+	}
+	for _, key := range []string{"alternatives", "undo", "verify", "warnings", "cached"} {
+		if strings.Contains(stdout.String(), `"`+key+`"`) {
+			t.Errorf("Expected %q to be left out when empty, got %q", key, stdout.String())
+		}
+	}
+}
+
+func TestRunJSONErrors(t *testing.T) {
+	m := newModel(options{prompt: "list go files", jsonOutput: true})
+	m.generator = fakeGenerator{err: errors.New(`rate "limited"`)}
+
+	var stdout bytes.Buffer
+	if code := runJSON(m, &stdout); code == 0 {
+		t.Error("Expected a non-zero exit code")
+	}
+	var result map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("Expected valid JSON, got %v for %q", err, stdout.String())
+	}
+	if len(result) != 1 || result["error"] != `rate "limited"` {
+		t.Errorf("Expected only the error, got %v", result)
+	}
+
+	stdout.Reset()
+	if code := runJSON(newModel(options{jsonOutput: true}), &stdout); code == 0 || !strings.Contains(stdout.String(), `{"error":"--json needs a prompt`) {
+		t.Errorf("Expected an error without a prompt, got %q", stdout.String())
+	}
+}
+
+func TestRunJSONWarnings(t *testing.T) {
+	m := newModel(options{prompt: "list go files", jsonOutput: true, model: "claude-3-haiku-20240307", maxTokens: 9000})
+	m.generator = fakeGenerator{text: "find . -name", stopReason: clippy.StopMaxTokens}
+
+	var stdout bytes.Buffer
+	if code := runJSON(m, &stdout); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	var result jsonResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if len(result.Warnings) != 2 || !strings.Contains(result.Warnings[0], "lowered") || !strings.Contains(result.Warnings[1], "cut off") {
+		t.Errorf("Expected the token limit warnings in the object, got %q", result.Warnings)
+	}
+}
+
+func TestRunJSONFlagsDownloadedScripts(t *testing.T) {
+	m := newModel(options{prompt: "install it", jsonOutput: true})
+	m.generator = fakeGenerator{text: "curl -fsSL https://example.com/install.sh | sh"}
+
+	var stdout bytes.Buffer
+	if code := runJSON(m, &stdout); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	var result jsonResult
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if result.PipeToShell == nil || result.PipeToShell.URL != "https://example.com/install.sh" {
+		t.Errorf("Expected pipe_to_shell to name the URL, got %+v", result.PipeToShell)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "downloads a script") {
+		t.Errorf("Expected a warning about the download, got %q", result.Warnings)
+	}
+
+	// Other commands leave the field out
+	stdout.Reset()
+	m.generator = fakeGenerator{text: "ls"}
+	runJSON(m, &stdout)
+	if strings.Contains(stdout.String(), "pipe_to_shell") {
+		t.Errorf("Expected no pipe_to_shell for a plain command, got %s", stdout.String())
+	}
+}
+
+func TestParseArgsJSON(t *testing.T) {
+	opts, err := parseArgs([]string{"--json", "compress", "logs"})
+	if err != nil || !opts.jsonOutput || opts.prompt != "compress logs" {
+		t.Errorf("Expected --json with the prompt, got %v and %q (err %v)", opts.jsonOutput, opts.prompt, err)
+	}
+	for _, other := range []string{"--print", "--widget"} {
+		if _, err := parseArgs([]string{"--json", other, "compress logs"}); err == nil {
+			t.Errorf("Expected --json with %s to be rejected", other)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// keyAction names what a key press does in a given state
+type keyAction string
+
+const (
+	actionNone           keyAction = "" // The state's fallback handles the key
+	actionQuit           keyAction = "quit"
+	actionBack           keyAction = "back"
+	actionSubmit         keyAction = "submit"
+	actionEditRules      keyAction = "edit-rules"
+	actionPasteClipboard keyAction = "paste-clipboard"
+	actionCopy           keyAction = "copy"
+	actionJoin           keyAction = "join"
+	actionInstalledTools keyAction = "installed-tools"
+	actionCopyUndo       keyAction = "copy-undo"
+	actionCopyVerify     keyAction = "copy-verify"
+	actionSaveScript     keyAction = "save-script"
+	actionPretty         keyAction = "pretty"
+	actionCopyPretty     keyAction = "copy-pretty"
+	actionCopyRaw        keyAction = "copy-raw"
+	actionRun            keyAction = "run"
+	actionReveal         keyAction = "reveal"
+	actionCritique       keyAction = "critique"
+	actionPager          keyAction = "pager"
+	actionExplainShell   keyAction = "explainshell"
+	actionEditPrompt     keyAction = "edit-prompt"
+	actionConfirm        keyAction = "confirm"
+	actionUp             keyAction = "up"
+	actionDown           keyAction = "down"
+	actionToggle         keyAction = "toggle"
+	actionContinue       keyAction = "continue"
+	actionStartOver      keyAction = "start-over"
+	actionKeepPartial    keyAction = "keep-partial"
+	actionRetry          keyAction = "retry"
+	actionChangeModel    keyAction = "change-model"
+	actionExplain        keyAction = "explain"
+	actionEditCommand    keyAction = "edit-command"
+	actionFavorite       keyAction = "favorite"
+	actionRegenerate     keyAction = "regenerate"
+	actionCopyPrompt     keyAction = "copy-prompt"
+	actionRunInline      keyAction = "run-inline"
+	actionScroll         keyAction = "scroll"
+)
+
+// keyBinding maps keys to an action in one state, along with the help shown
+// in that state's footer
+type keyBinding struct {
+	action  keyAction
+	keys    []string
+	help    func(m model) string
+	enabled func(m model) bool // nil means always enabled
+}
+
+// fixedHelp is help text that doesn't depend on the model
+func fixedHelp(text string) func(model) string {
+	return func(model) string { return text }
+}
+
+func hasCommand(m model) bool { return m.generatedCmd != "" }
+
+func hasAlternatives(m model) bool { return len(m.generatedCmds) > 1 }
+
+// stateBindings lists every key each state's handler recognizes. Update
+// dispatches through it and the footer is built from it, so the two can't
+// drift apart.
+var stateBindings = map[state][]keyBinding{
+	stateInput: {
+		{action: actionSubmit, keys: []string{"enter"}, help: fixedHelp("to generate command")},
+		{action: actionEditRules, keys: []string{"ctrl+r"}, help: fixedHelp("to edit rules")},
+		{action: actionPasteClipboard, keys: []string{"ctrl+y"}, help: fixedHelp("to use the clipboard as the prompt")},
+		{action: actionQuit, keys: []string{"ctrl+c", "esc"}, help: fixedHelp("to quit")},
+	},
+	stateLoading: {
+		{action: actionQuit, keys: []string{"ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateStreaming: {
+		{action: actionQuit, keys: []string{"ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateResult: {
+		{action: actionCopy, keys: []string{"enter"}, enabled: hasCommand, help: func(m model) string {
+			if m.noClipboard {
+				return "to print (no clipboard found)"
+			}
+			if m.opts.urlEncode {
+				return "to copy a share link"
+			}
+			return "to copy to clipboard"
+		}},
+		{action: actionUp, keys: []string{"up"}, enabled: hasAlternatives, help: fixedHelp("to pick the previous command")},
+		{action: actionDown, keys: []string{"down"}, enabled: hasAlternatives, help: fixedHelp("to pick the next command")},
+		{action: actionRun, keys: []string{"R"}, enabled: func(m model) bool { return m.opts.execute && m.generatedCmd != "" }, help: fixedHelp("to run")},
+		{action: actionRunInline, keys: []string{"!"}, enabled: func(m model) bool { return m.opts.execute && m.generatedCmd != "" }, help: fixedHelp("to run here")},
+		{action: actionJoin, keys: []string{"j"}, enabled: model.canJoin, help: func(m model) string {
+			return "to change join (join: " + m.joinMode.String() + ")"
+		}},
+		{action: actionInstalledTools, keys: []string{"i"}, enabled: func(m model) bool { return len(m.missingTools) > 0 }, help: fixedHelp("to regenerate with installed tools")},
+		{action: actionCopyUndo, keys: []string{"u"}, enabled: func(m model) bool { return m.undoCmd != "" }, help: fixedHelp("to copy undo")},
+		{action: actionCopyVerify, keys: []string{"t"}, enabled: func(m model) bool { return m.verifyCmd != "" }, help: fixedHelp("to copy verification")},
+		{action: actionSaveScript, keys: []string{"s"}, enabled: func(m model) bool { return m.opts.asScript && m.generatedCmd != "" }, help: fixedHelp("to save as a script")},
+		{action: actionPretty, keys: []string{"b"}, enabled: model.canPrettyFormat, help: func(m model) string {
+			if m.prettyView {
+				return "to show raw"
+			}
+			return "to show pretty"
+		}},
+		{action: actionCopyPretty, keys: []string{"B"}, enabled: model.canPrettyFormat, help: fixedHelp("to copy pretty form")},
+		{action: actionReveal, keys: []string{"w"}, enabled: hasCommand, help: func(m model) string {
+			if m.revealView {
+				return "to hide whitespace"
+			}
+			return "to reveal whitespace"
+		}},
+		{action: actionCritique, keys: []string{"k"}, enabled: func(m model) bool { return m.generatedCmd != "" && !m.critiquing }, help: fixedHelp("to critique")},
+		{action: actionExplain, keys: []string{"x"}, enabled: hasCommand, help: fixedHelp("to explain")},
+		{action: actionPager, keys: []string{"v"}, enabled: hasCommand, help: fixedHelp("to view in pager")},
+		{action: actionExplainShell, keys: []string{"l"}, enabled: hasCommand, help: fixedHelp("to look up in explainshell")},
+		{action: actionCopyRaw, keys: []string{"o"}, enabled: func(m model) bool { return m.rawResponse != "" }, help: fixedHelp("to copy raw model output")},
+		{action: actionEditPrompt, keys: []string{"e"}, help: fixedHelp("to edit prompt")},
+		{action: actionEditCommand, keys: []string{"c"}, enabled: hasCommand, help: fixedHelp("to edit command")},
+		{action: actionRegenerate, keys: []string{"r"}, enabled: hasCommand, help: fixedHelp("to regenerate")},
+		// Only for debugging prompts, so it stays out of the way otherwise
+		{action: actionCopyPrompt, keys: []string{"p"}, enabled: func(m model) bool { return m.verbose && m.fullPrompt != "" && !m.noClipboard }, help: fixedHelp("to copy full prompt")},
+		{action: actionFavorite, keys: []string{"f"}, enabled: func(m model) bool { return m.generatedCmd != "" && m.favoritesPath != "" }, help: fixedHelp("to save as favorite")},
+		{action: actionQuit, keys: []string{"q", "esc", "ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateError: {
+		{action: actionRetry, keys: []string{"r"}, enabled: func(m model) bool { return classifyError(m.err).canRetry() }, help: fixedHelp("to retry")},
+		{action: actionEditPrompt, keys: []string{"e"}, enabled: func(m model) bool { return classifyError(m.err).canEditPrompt() }, help: fixedHelp("to edit prompt")},
+		{action: actionChangeModel, keys: []string{"m"}, enabled: func(m model) bool { return classifyError(m.err).canChangeModel() }, help: fixedHelp("to change model")},
+		{action: actionCopyRaw, keys: []string{"o"}, enabled: func(m model) bool { return m.rawResponse != "" }, help: fixedHelp("to copy raw model output")},
+		{action: actionQuit, keys: []string{"q", "esc", "ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateChangeModel: {
+		{action: actionSubmit, keys: []string{"enter"}, help: fixedHelp("to regenerate with it")},
+		{action: actionBack, keys: []string{"esc"}, help: fixedHelp("to go back")},
+		{action: actionQuit, keys: []string{"ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateEdit: {
+		{action: actionSubmit, keys: []string{"enter"}, help: fixedHelp("to regenerate")},
+		{action: actionQuit, keys: []string{"ctrl+c", "esc"}, help: fixedHelp("to quit")},
+	},
+	stateEditCmd: {
+		{action: actionSubmit, keys: []string{"enter"}, help: fixedHelp("to copy")},
+		{action: actionBack, keys: []string{"esc"}, help: fixedHelp("to discard changes")},
+		{action: actionQuit, keys: []string{"ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateSaveScript: {
+		{action: actionSubmit, keys: []string{"enter"}, help: fixedHelp("to save")},
+		{action: actionBack, keys: []string{"esc"}, help: fixedHelp("to go back")},
+		{action: actionQuit, keys: []string{"ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateStrictConfirm: {
+		{action: actionSubmit, keys: []string{"enter"}, help: fixedHelp("to confirm")},
+		{action: actionBack, keys: []string{"esc"}, help: fixedHelp("to go back")},
+		{action: actionQuit, keys: []string{"ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateFillInputs: {
+		{action: actionSubmit, keys: []string{"enter"}, help: fixedHelp("to continue")},
+		{action: actionBack, keys: []string{"esc"}, help: fixedHelp("to keep the placeholders")},
+		{action: actionQuit, keys: []string{"ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	statePipeConfirm: {
+		{action: actionConfirm, keys: []string{"y", "Y"}, help: fixedHelp("to copy anyway")},
+		{action: actionQuit, keys: []string{"ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateExplain: {
+		{action: actionQuit, keys: []string{"ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateExplainConfirm: {
+		{action: actionConfirm, keys: []string{"y", "Y"}, help: fixedHelp("to copy anyway")},
+		{action: actionQuit, keys: []string{"ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateRunConfirm: {
+		{action: actionConfirm, keys: []string{"y", "Y"}, help: fixedHelp("to run")},
+		{action: actionQuit, keys: []string{"ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	statePager: {
+		{action: actionScroll, keys: []string{"up", "down", "pgup", "pgdown"}, help: fixedHelp("to scroll")},
+		{action: actionBack, keys: []string{"q", "esc"}, help: fixedHelp("to go back")},
+		{action: actionQuit, keys: []string{"ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateOutput: {
+		{action: actionScroll, keys: []string{"up", "down", "pgup", "pgdown"}, help: fixedHelp("to scroll")},
+		{action: actionBack, keys: []string{"esc"}, enabled: func(m model) bool { return !m.inlineRunning }, help: fixedHelp("to go back")},
+		{action: actionQuit, keys: []string{"q", "ctrl+c"}, help: func(m model) string {
+			if m.inlineRunning {
+				return "to stop"
+			}
+			return "to quit"
+		}},
+	},
+	stateFavorites: {
+		{action: actionCopy, keys: []string{"enter"}, enabled: func(m model) bool { return len(m.favorites) > 0 }, help: fixedHelp("to copy")},
+		{action: actionUp, keys: []string{"up", "k"}, help: fixedHelp("to move up")},
+		{action: actionDown, keys: []string{"down", "j"}, help: fixedHelp("to move down")},
+		{action: actionQuit, keys: []string{"q", "esc", "ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateRules: {
+		{action: actionUp, keys: []string{"up", "k"}, help: fixedHelp("to move up")},
+		{action: actionDown, keys: []string{"down", "j"}, help: fixedHelp("to move down")},
+		{action: actionToggle, keys: []string{" ", "x"}, help: fixedHelp("to toggle")},
+		{action: actionBack, keys: []string{"enter", "esc"}, help: fixedHelp("to save")},
+		{action: actionQuit, keys: []string{"ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateInterrupted: {
+		{action: actionContinue, keys: []string{"c"}, help: fixedHelp("to continue from here")},
+		{action: actionStartOver, keys: []string{"r", "s"}, help: fixedHelp("to start over")},
+		// Structured replies can't be used until they're complete
+		{action: actionKeepPartial, keys: []string{"k"}, enabled: func(m model) bool { return m.responseFormat() == "" }, help: fixedHelp("to keep what arrived")},
+		{action: actionQuit, keys: []string{"q", "esc", "ctrl+c"}, help: fixedHelp("to quit")},
+	},
+	stateInjectionWarning: {
+		{action: actionConfirm, keys: []string{"y", "Y"}, help: fixedHelp("to send anyway")},
+	},
+}
+
+// fallbackHelp documents what an unbound key does in states where it does
+// more than type into the textarea or get ignored
+func (m model) fallbackHelp() string {
+	switch m.state {
+	case stateResult, stateError:
+		if m.opts.legacyKeys {
+			return "Any other key to cancel"
+		}
+	case statePipeConfirm, stateExplainConfirm, stateExplain, stateRunConfirm:
+		return "Any other key to go back"
+	case stateInjectionWarning:
+		return "Any other key to cancel"
+	}
+	return ""
+}
+
+// activeBindings returns the bindings that currently apply in m's state
+func (m model) activeBindings() []keyBinding {
+	var active []keyBinding
+	for _, b := range stateBindings[m.state] {
+		if b.enabled == nil || b.enabled(m) {
+			active = append(active, b)
+		}
+	}
+	return active
+}
+
+// keyAction looks up what key does in the current state, returning
+// actionNone when the state's fallback should handle it
+func (m model) keyAction(key string) keyAction {
+	for _, b := range m.activeBindings() {
+		for _, k := range b.keys {
+			if k == key {
+				return b.action
+			}
+		}
+	}
+	return actionNone
+}
+
+// keyNames formats keys for display, e.g. "Ctrl+C/Esc" or "Shift+B"
+func keyNames(keys []string) string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, k := range keys {
+		name := keyName(k, keys)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "/")
+}
+
+// keyName formats a single key. An upper-case letter is shown as Shift+ the
+// letter unless its lower-case form is bound too.
+func keyName(key string, keys []string) string {
+	switch key {
+	case " ":
+		return "Space"
+	case "esc":
+		return "Esc"
+	}
+
+	runes := []rune(key)
+	if len(runes) == 1 {
+		if unicode.IsUpper(runes[0]) {
+			lower := string(unicode.ToLower(runes[0]))
+			for _, k := range keys {
+				if k == lower {
+					return key
+				}
+			}
+			return "Shift+" + key
+		}
+		return strings.ToUpper(key)
+	}
+
+	parts := strings.Split(key, "+")
+	for i, p := range parts {
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "+")
+}
+
+// helpItems lists the footer entries for the current state
+func (m model) helpItems() []string {
+	var items []string
+	for _, b := range m.activeBindings() {
+		items = append(items, keyNames(b.keys)+" "+b.help(m))
+	}
+	if fallback := m.fallbackHelp(); fallback != "" {
+		items = append(items, fallback)
+	}
+	if len(items) > 0 {
+		items[0] = "Press " + items[0]
+	}
+	return items
+}
+
+// helpFooter renders the current state's key help, after a hint about the
+// last key if it wasn't recognized
+func (m model) helpFooter() string {
+	footer := helpStyle.Render(joinHelp(m.helpItems()...))
+	if m.keyHint != "" {
+		footer = dimStyle.Render(m.keyHint) + "\n" + footer
+	}
+	return footer
+}
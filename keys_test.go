@@ -0,0 +1,201 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// richModel returns a model in state s with an undo, verification and missing
+// tool. Script mode turns off joining and pretty-printing, so between them the
+// two variants enable every binding.
+func richModel(s state, asScript bool) model {
+	m := initialModel("tidy up", false)
+	m.state = s
+	m.generatedCmd = "cd build\nmake | tee log"
+	m.missingTools = []string{"tee"}
+	m.undoCmd = "make clean"
+	m.verifyCmd = "ls build"
+	m.rawResponse = "cd build\nmake | tee log\n"
+	m.generatedCmds = []string{m.generatedCmd, "make -C build | tee log"}
+	m.opts.asScript = asScript
+	m.opts.execute = true
+	m.favoritesPath = "favorites.json"
+	m.verbose = true
+	m.fullPrompt = "System: be brief\n\nUser: tidy up"
+	m.favorites = []favorite{{Prompt: "tidy up", Command: m.generatedCmd}}
+	return m
+}
+
+func TestHelpFooterListsHandledKeys(t *testing.T) {
+	for s := range stateBindings {
+		seen := make(map[keyAction]bool)
+		for _, asScript := range []bool{false, true} {
+			checkHelpFooter(t, richModel(s, asScript), seen)
+		}
+		for _, b := range stateBindings[s] {
+			if !seen[b.action] {
+				t.Errorf("State %d: expected %q to be listed in some footer", s, b.action)
+			}
+		}
+	}
+}
+
+// checkHelpFooter verifies that m's footer has one entry per active binding,
+// naming the keys that trigger it, plus the fallback when there is one
+func checkHelpFooter(t *testing.T, m model, seen map[keyAction]bool) {
+	t.Helper()
+	s := m.state
+	items := m.helpItems()
+	active := m.activeBindings()
+	want := len(active)
+	if m.fallbackHelp() != "" {
+		want++
+	}
+	if len(items) != want {
+		t.Errorf("State %d: expected %d footer items, got %d: %v", s, want, len(items), items)
+		return
+	}
+
+	for i, b := range active {
+		seen[b.action] = true
+		item := strings.TrimPrefix(items[i], "Press ")
+		if !strings.HasPrefix(item, keyNames(b.keys)+" ") {
+			t.Errorf("State %d: expected footer item %q to name keys %q", s, item, keyNames(b.keys))
+		}
+		for _, k := range b.keys {
+			if got := m.keyAction(k); got != b.action {
+				t.Errorf("State %d: expected %q to trigger %q, got %q", s, k, b.action, got)
+			}
+		}
+	}
+
+	// Keys missing from the footer fall through to the state's default
+	if got := m.keyAction("ctrl+g"); got != actionNone {
+		t.Errorf("State %d: expected an unlisted key to have no action, got %q", s, got)
+	}
+}
+
+func TestHelpFooterHidesDisabledKeys(t *testing.T) {
+	stubClipboard(t)
+
+	m := initialModel("list files", false)
+	m.state = stateResult
+	m.generatedCmd = "ls"
+	footer := strings.Join(m.helpItems(), "\n")
+	for _, hidden := range []string{"U to copy undo", "T to copy verification", "S to save as a script", "J to change join"} {
+		if strings.Contains(footer, hidden) {
+			t.Errorf("Expected the footer not to offer %q, got %q", hidden, footer)
+		}
+	}
+	if strings.Contains(footer, "Any other key") {
+		t.Errorf("Expected no fallback to be documented by default, got %q", footer)
+	}
+
+	// An error leaves only editing the prompt and quitting
+	m.generatedCmd = ""
+	m.err = errors.New("rate limited")
+	items := m.helpItems()
+	if len(items) != 2 || items[0] != "Press E to edit prompt" {
+		t.Errorf("Expected only the edit and quit entries after an error, got %v", items)
+	}
+}
+
+func TestHelpFooterReflectsToggles(t *testing.T) {
+	m := richModel(stateResult, false)
+	m.opts.urlEncode = true
+	m.revealView = true
+	footer := strings.Join(m.helpItems(), "\n")
+	for _, want := range []string{"Press Enter to copy a share link", "W to hide whitespace", "J to change join (join: newline)"} {
+		if !strings.Contains(footer, want) {
+			t.Errorf("Expected the footer to contain %q, got %q", want, footer)
+		}
+	}
+}
+
+func TestLoadingQuitsOnCtrlC(t *testing.T) {
+	m := initialModel("list files", false)
+	m.state = stateLoading
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if cmd == nil || cmd() != tea.Quit() {
+		t.Error("Expected Ctrl+C to quit while loading")
+	}
+}
+
+func TestKeyNames(t *testing.T) {
+	tests := []struct {
+		keys []string
+		want string
+	}{
+		{[]string{"ctrl+c", "esc"}, "Ctrl+C/Esc"},
+		{[]string{"B"}, "Shift+B"},
+		{[]string{"y", "Y"}, "Y"},
+		{[]string{" ", "x"}, "Space/X"},
+		{[]string{"up", "k"}, "Up/K"},
+	}
+	for _, tt := range tests {
+		if got := keyNames(tt.keys); got != tt.want {
+			t.Errorf("Expected %v to display as %q, got %q", tt.keys, tt.want, got)
+		}
+	}
+}
+
+func TestResultIgnoresStrayKeys(t *testing.T) {
+	m := initialModel("list files", false)
+	m.state = stateResult
+	m.generatedCmd = "ls"
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("z")})
+	if cmd != nil && cmd() == tea.Quit() {
+		t.Error("Expected an unrecognized key not to quit")
+	}
+	m = updated.(model)
+	if m.state != stateResult || m.generatedCmd != "ls" {
+		t.Error("Expected the result to be kept after an unrecognized key")
+	}
+	if !strings.Contains(m.View(), "Z does nothing here") {
+		t.Errorf("Expected a hint about the unrecognized key, got %q", m.View())
+	}
+
+	// The hint goes away with the next key
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	if updated.(model).keyHint != "" {
+		t.Error("Expected the hint to clear on the next key")
+	}
+
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("q")},
+		{Type: tea.KeyEsc},
+		{Type: tea.KeyCtrlC},
+	} {
+		_, cmd := m.Update(key)
+		if cmd == nil || cmd() != tea.Quit() {
+			t.Errorf("Expected %q to quit", key.String())
+		}
+	}
+}
+
+func TestLegacyKeysQuitOnAnyKey(t *testing.T) {
+	m := initialModel("list files", false)
+	m.state = stateResult
+	m.generatedCmd = "ls"
+	m.opts.legacyKeys = true
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("z")})
+	if cmd == nil || cmd() != tea.Quit() {
+		t.Error("Expected an unrecognized key to quit with --legacy-keys")
+	}
+	if !strings.Contains(strings.Join(m.helpItems(), "\n"), "Any other key to cancel") {
+		t.Error("Expected the footer to document the legacy fallback")
+	}
+
+	opts, err := parseArgs([]string{"--legacy-keys"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.legacyKeys {
+		t.Error("Expected --legacy-keys to be parsed")
+	}
+}
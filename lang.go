@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// languageNames spells out common language codes, which models follow more
+// reliably than the bare code
+var languageNames = map[string]string{
+	"ar": "Arabic",
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"hi": "Hindi",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"sv": "Swedish",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"zh": "Chinese",
+}
+
+// langRe matches a language code like es or pt-BR, or a name like Spanish
+var langRe = regexp.MustCompile(`^[A-Za-z]+([ _-][A-Za-z]+)*$`)
+
+// languageName returns the name to give the model for lang
+func languageName(lang string) string {
+	if name, ok := languageNames[strings.ToLower(lang)]; ok {
+		return name
+	}
+	return lang
+}
+
+// langInstruction tells the model which language to write explanations in,
+// leaving the commands themselves alone. It's empty for English, the default.
+func langInstruction(lang string) string {
+	if lang == "" || strings.EqualFold(languageName(lang), "English") {
+		return ""
+	}
+	return fmt.Sprintf("Write any explanatory text, such as explanations, reviews, descriptions and comments, in %s. Keep commands, flags, file names and other code exactly as they're written, untranslated.", languageName(lang))
+}
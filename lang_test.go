@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLangInstruction(t *testing.T) {
+	prompt := buildSystemPrompt(promptOptions{lang: "es"}, "Shell: /bin/bash")
+	if !strings.Contains(prompt, "explanatory text, such as explanations, reviews, descriptions and comments, in Spanish") {
+		t.Errorf("Expected the Spanish instruction in the system prompt, got %q", prompt)
+	}
+	if !strings.Contains(critiqueSystemPrompt("Shell: /bin/bash", "es"), "in Spanish") {
+		t.Error("Expected explanations and reviews to be asked for in Spanish too")
+	}
+
+	// English is the default, so it adds nothing
+	for _, lang := range []string{"", "en", "English"} {
+		if strings.Contains(buildSystemPrompt(promptOptions{lang: lang}, ""), "explanatory text") {
+			t.Errorf("%q: expected no language instruction", lang)
+		}
+	}
+
+	// Names and codes without a spelled-out name are passed along as given
+	if !strings.Contains(langInstruction("pt-BR"), "in pt-BR") {
+		t.Errorf("Expected an unknown code to be used as is, got %q", langInstruction("pt-BR"))
+	}
+}
+
+func TestParseArgsLang(t *testing.T) {
+	opts, err := parseArgs([]string{"--lang", "es", "list files"})
+	if err != nil || opts.lang != "es" {
+		t.Fatalf("Expected lang es, got %q (err %v)", opts.lang, err)
+	}
+	if m := newModel(opts); m.promptOptions().lang != "es" {
+		t.Errorf("Expected the language to reach the prompt options, got %q", m.promptOptions().lang)
+	}
+	if _, err := parseArgs([]string{"--lang", "es; rm -rf"}); err == nil {
+		t.Error("Expected an error for a language that isn't a code or name")
+	}
+}
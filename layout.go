@@ -0,0 +1,51 @@
+package main
+
+import "github.com/charmbracelet/lipgloss"
+
+const (
+	// defaultMaxWidth caps the content on wide terminals, where boxes and
+	// help text stretched across the whole screen are hard to read
+	defaultMaxWidth = 100
+	// screenMargin keeps the content clear of the terminal's edges
+	screenMargin = 4
+)
+
+// contentMaxWidth returns the widest the content may be drawn
+func (o options) contentMaxWidth() int {
+	if o.maxWidth > 0 {
+		return o.maxWidth
+	}
+	return defaultMaxWidth
+}
+
+// contentWidth is how wide the UI is drawn: the terminal's width less a
+// margin, up to the cap. It's zero until the terminal's size is known.
+func (m model) contentWidth() int {
+	if m.width <= 0 {
+		return 0
+	}
+	return max(1, min(m.opts.contentMaxWidth(), m.width-screenMargin))
+}
+
+// box renders text in one of the bordered styles, fitting its content but
+// wrapping rather than growing wider than the content width
+func (m model) box(style lipgloss.Style, text string) string {
+	rendered := style.Render(text)
+	width := m.contentWidth()
+	if width == 0 || lipgloss.Width(rendered) <= width {
+		return rendered
+	}
+	// A style's width includes its padding but not its border or margins
+	return style.Width(width - style.GetHorizontalBorderSize() - style.GetHorizontalMargins()).Render(text)
+}
+
+// frame wraps a rendered view to the content width and centers it, so every
+// element lines up however wide the terminal is
+func (m model) frame(view string) string {
+	width := m.contentWidth()
+	if width == 0 {
+		return view
+	}
+	view = lipgloss.NewStyle().Width(width).Render(view)
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Center, view)
+}
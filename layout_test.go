@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestMaxWidthOnWideTerminal(t *testing.T) {
+	m := newModel(options{prompt: "list files", verbose: true, maxWidth: 60})
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 300, Height: 50})
+	m = updated.(model)
+	updated, _ = m.Update(cmdGeneratedMsg{
+		cmd:        "find . -type f -name '*.go' -newer go.mod -exec grep -l 'TODO' {} + | xargs wc -l | sort -n",
+		fullPrompt: strings.Repeat("a long system prompt line ", 20),
+	})
+	m = updated.(model)
+
+	if m.textarea.Width() > 60 {
+		t.Errorf("Expected the textarea to fit in 60 columns, got %d", m.textarea.Width())
+	}
+
+	lines := strings.Split(m.View(), "\n")
+	left := -1
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if left == -1 || indent < left {
+			left = indent
+		}
+	}
+	for _, line := range lines {
+		if len(line) < left {
+			continue
+		}
+		if w := lipgloss.Width(strings.TrimRight(line[left:], " ")); w > 60 {
+			t.Errorf("Expected every line within 60 columns, got %d: %q", w, line)
+		}
+	}
+	if left != (300-60)/2 {
+		t.Errorf("Expected the content centered at column %d, got %d", (300-60)/2, left)
+	}
+}
+
+func TestContentWidth(t *testing.T) {
+	m := newModel(options{})
+	if m.contentWidth() != 0 {
+		t.Error("Expected no width limit before the terminal size is known")
+	}
+	m.width = 300
+	if m.contentWidth() != defaultMaxWidth {
+		t.Errorf("Expected the default cap of %d, got %d", defaultMaxWidth, m.contentWidth())
+	}
+	m.width = 50
+	if m.contentWidth() != 50-screenMargin {
+		t.Errorf("Expected a narrow terminal to set the width, got %d", m.contentWidth())
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// cmdStreamChunkMsg carries a reply's text so far while it streams in
+type cmdStreamChunkMsg struct {
+	text    string
+	done    bool // The generation has finished; stop listening
+	retry   int  // Which retry is under way after a transient failure, if any
+	retries int  // How many retries there will be at most
+}
+
+// liveReply passes a generation's text to the TUI as it arrives and lets the
+// user cancel the request. It's shared by every copy of the model, since
+// generations run outside Update. Only the latest text matters, so a slow
+// reader skips ahead rather than holding up the stream.
+type liveReply struct {
+	updates chan cmdStreamChunkMsg
+	base    context.Context // Every request the TUI makes derives from this
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+}
+
+func newLiveReply() *liveReply {
+	return &liveReply{updates: make(chan cmdStreamChunkMsg, 1), base: context.Background()}
+}
+
+// context returns the context for a request made alongside the result, like
+// a critique. It isn't streamed, and it doesn't take over the current
+// generation's cancel, since several can be in flight at once.
+func (l *liveReply) context() context.Context {
+	return l.base
+}
+
+// begin starts a generation, returning its context and a callback that
+// publishes each piece of text as it streams in
+func (l *liveReply) begin() (context.Context, func(string)) {
+	ctx, cancel := context.WithCancel(l.base)
+	l.mu.Lock()
+	l.cancel = cancel
+	l.mu.Unlock()
+
+	// Drop whatever an earlier generation left behind
+	select {
+	case <-l.updates:
+	default:
+	}
+
+	var text string
+	return ctx, func(chunk string) {
+		text += chunk
+		l.publish(cmdStreamChunkMsg{text: text})
+	}
+}
+
+// end finishes the current generation, releasing its context and the
+// listener waiting on it
+func (l *liveReply) end() {
+	l.stop()
+	l.publish(cmdStreamChunkMsg{done: true})
+}
+
+// retrying reports that the request failed and is being retried
+func (l *liveReply) retrying(retry, retries int) {
+	l.publish(cmdStreamChunkMsg{retry: retry, retries: retries})
+}
+
+// stop cancels the current generation, if any
+func (l *liveReply) stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cancel != nil {
+		l.cancel()
+		l.cancel = nil
+	}
+}
+
+// publish replaces any update that hasn't been read yet with msg
+func (l *liveReply) publish(msg cmdStreamChunkMsg) {
+	for {
+		select {
+		case l.updates <- msg:
+			return
+		default:
+		}
+		select {
+		case <-l.updates:
+		default:
+		}
+	}
+}
+
+// listen waits for the next update to the reply being generated
+func (l *liveReply) listen() tea.Cmd {
+	return func() tea.Msg {
+		return <-l.updates
+	}
+}
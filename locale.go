@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// glyphSet holds the decorative characters used by the UI
+type glyphSet struct {
+	titleIcon      string
+	bullet         string
+	check          string
+	border         lipgloss.Border
+	textareaPrompt string
+	spinner        spinner.Spinner
+	space          string // Shows a space in the whitespace view
+	trailingSpace  string // Shows a trailing space in the whitespace view
+}
+
+var (
+	unicodeGlyphs = glyphSet{
+		titleIcon:      "🔧 ",
+		bullet:         "•",
+		check:          "✓",
+		border:         lipgloss.RoundedBorder(),
+		textareaPrompt: "┃ ",
+		spinner:        spinner.Dot,
+		space:          "·",
+		trailingSpace:  "␣",
+	}
+
+	// asciiGlyphs render correctly in locales without UTF-8 support
+	asciiGlyphs = glyphSet{
+		titleIcon:      "",
+		bullet:         "-",
+		check:          "OK:",
+		border:         lipgloss.ASCIIBorder(),
+		textareaPrompt: "| ",
+		spinner:        spinner.Line,
+		space:          ".",
+		trailingSpace:  "<SP>",
+	}
+
+	glyphs = unicodeGlyphs
+)
+
+// supportsUTF8 reports whether the current locale can display UTF-8, using
+// the same precedence as setlocale: LC_ALL, then LC_CTYPE, then LANG
+func supportsUTF8() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			value = strings.ToLower(value)
+			return strings.Contains(value, "utf-8") || strings.Contains(value, "utf8")
+		}
+	}
+
+	// Without any locale set, Unix falls back to the ASCII-only C locale
+	return runtime.GOOS == "windows"
+}
+
+// setGlyphs switches the UI to the given glyph set, updating the bordered styles
+func setGlyphs(g glyphSet) {
+	glyphs = g
+	cmdStyle = cmdStyle.Border(g.border)
+	secondaryStyle = secondaryStyle.Border(g.border)
+	verbosePromptStyle = verbosePromptStyle.Border(g.border)
+	critiqueStyle = critiqueStyle.Border(g.border)
+}
+
+// joinHelp joins help items with the current bullet separator
+func joinHelp(items ...string) string {
+	return strings.Join(items, " "+glyphs.bullet+" ")
+}
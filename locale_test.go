@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSupportsUTF8(t *testing.T) {
+	tests := []struct {
+		name     string
+		lcAll    string
+		lcCtype  string
+		lang     string
+		expected bool
+	}{
+		{"utf-8 lang", "", "", "en_US.UTF-8", true},
+		{"utf8 lang", "", "", "en_US.utf8", true},
+		{"C locale", "", "", "C", false},
+		{"POSIX override", "POSIX", "", "en_US.UTF-8", false},
+		{"ctype over lang", "", "C.UTF-8", "C", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LC_ALL", tt.lcAll)
+			t.Setenv("LC_CTYPE", tt.lcCtype)
+			t.Setenv("LANG", tt.lang)
+
+			if result := supportsUTF8(); result != tt.expected {
+				t.Errorf("supportsUTF8() = %v; want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestASCIIViewInNonUTF8Locale(t *testing.T) {
+	t.Setenv("LC_ALL", "C")
+	if supportsUTF8() {
+		t.Fatal("Expected the C locale not to support UTF-8")
+	}
+
+	setGlyphs(asciiGlyphs)
+	defer setGlyphs(unicodeGlyphs)
+
+	// Render each main view and make sure only ASCII comes out
+	inputModel := initialModel("", false)
+	loadingModel := initialModel("find large files", false)
+	resultModel, _ := initialModel("find large files", false).Update(cmdGeneratedMsg{cmd: "find . -size +100M"})
+
+	views := map[string]string{
+		"input":   inputModel.View(),
+		"loading": loadingModel.View(),
+		"result":  resultModel.View(),
+	}
+
+	for name, view := range views {
+		for _, r := range view {
+			if r > 127 {
+				t.Errorf("Expected %s view to be ASCII-only, found %q", name, r)
+				break
+			}
+		}
+	}
+}
@@ -2,18 +2,25 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"path/filepath"
 	"runtime"
-	"sort"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/atotto/clipboard"
+	"github.com/benmyles/clippycli/clippy"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
 // Application states
@@ -24,8 +31,86 @@ const (
 	stateLoading
 	stateResult
 	stateEdit
+	stateInjectionWarning
+	stateSaveScript
+	stateInterrupted
+	stateStrictConfirm
+	stateRules
+	statePipeConfirm
+	stateFillInputs
+	stateExplainConfirm
+	stateError
+	stateChangeModel
+	stateStreaming
+	stateExplain
+	stateEditCmd
+	stateFavorites
+	stateRunConfirm
+	stateOutput
+	statePager
 )
 
+// options holds the settings parsed from the command line
+type options struct {
+	prompt           string
+	verbose          bool
+	systemStats      bool          // Include CPU/memory/disk stats in the environment info
+	notify           bool          // Show a desktop notification when generation completes
+	withUndo         bool          // Ask the model for a command that reverses the generated one
+	auditLog         string        // Append overrides of safety warnings to this file
+	asScript         bool          // Generate a reusable script with argument parsing
+	singleLine       bool          // Ask for the command on one line
+	strictConfirm    bool          // Require typing a phrase before copying dangerous commands
+	toolVersions     []string      // tool=version hints for version-sensitive syntax
+	contexts         []string      // Standing context added to every prompt, e.g. "in the prod cluster"
+	detectVersions   bool          // Detect versions of tools mentioned in the prompt
+	withVerify       bool          // Ask the model for a command that checks the generated one worked
+	alwaysFresh      bool          // Never read or write cached generations
+	noCache          bool          // Call the API even when a cached reply would do
+	editRules        bool          // Start on the screen for toggling system prompt rules
+	favorites        bool          // Start on the list of favorites, to copy one
+	clipboardTargets []string      // Selections to copy to instead of the default clipboard
+	osc52            bool          // Copy through the terminal with OSC 52, e.g. over SSH
+	urlEncode        bool          // Copy an explainshell.com share link instead of the raw command
+	askInputs        bool          // Have the model mark values only the user knows, then ask for them
+	idleTimeout      time.Duration // Quit without copying after this long with no keypress
+	gitContext       bool          // Include git status and a diff summary in the environment info
+	noEnv            bool          // Leave environment variable names out of the environment info
+	yes              bool          // Copy the command as soon as it's generated, unless it's dangerous
+	widget           bool          // Print only the command for a shell widget, without the TUI
+	print            bool          // Print only the command for scripts, without the TUI
+	jsonOutput       bool          // Print the command and its details as JSON, without the TUI
+	dryRun           bool          // Print the prompt that would be sent instead of calling the API
+	updateCheck      bool          // Opted in to checking for newer releases
+	noUpdateCheck    bool          // Skip the update check even if opted in
+	exportMake       string        // Write the steps as Makefile targets to this path
+	exportJust       string        // Write the steps as justfile recipes to this path
+	legacyKeys       bool          // Quit on any unrecognized key in the result view
+	execAllow        []string      // Only commands matching these patterns may be run
+	execDeny         []string      // Commands matching these patterns are never run
+	commentStyle     string        // How much generated scripts explain themselves: none, minimal, or verbose
+	execute          bool          // Offer to run the command in the user's shell after the TUI exits
+	fromClipboard    bool          // Seed the prompt from the clipboard
+	provider         string        // Model provider: anthropic or openai
+	model            string        // Model name, or empty for the provider's default
+	maxTokens        int           // Longest reply allowed, or zero for clippy.DefaultMaxTokens
+	temperature      *float64      // Sampling temperature from 0 to 1; nil leaves the provider's default
+	daemon           bool          // Run as a background daemon serving a global hotkey
+	history          int           // Print this many history entries instead of starting the TUI
+	replay           int           // Regenerate the prompt this many entries back in the history
+	maxWidth         int           // Widest the UI is drawn, or zero for defaultMaxWidth
+	retries          int           // Times a transient API failure is retried
+	retryDelay       time.Duration // Wait before the first retry, doubling each time
+	timeout          time.Duration // Longest a generation may take, or zero for no limit
+	count            int           // Alternative commands to generate and choose between
+	theme            string        // Built-in color theme: dark or light
+	themeColors      []string      // name=color overrides of the theme's colors
+	noHighlight      bool          // Show the command without syntax highlighting
+	lang             string        // Language for explanations, or empty for English
+	noLoadingHints   bool          // Keep to "Thinking..." while waiting, without rotating hints
+	quiet            bool          // Skip the banner after copying, and warnings with --print
+}
+
 // Model represents the application state
 type model struct {
 	state           state
@@ -33,24 +118,126 @@ type model struct {
 	spinner         spinner.Model
 	prompt          string
 	generatedCmd    string
-	copiedCmd       string // Track the command that was copied to clipboard
+	generatedCmds   []string // Alternatives to choose from, with --count
+	selectedCmd     int      // Index in generatedCmds of generatedCmd
+	undoCmd         string   // Command that reverses generatedCmd, if any
+	verifyCmd       string   // Command that checks generatedCmd worked, if any
+	joinMode        joinMode // How multi-step commands are joined when copied
+	missingTools    []string // Tools the generated command uses that aren't installed
+	avoidTools      []string // Tools the model has been told not to use
+	scriptPath      string   // Where the generated script was last saved
+	scriptErr       error    // Last failure saving the generated script
+	partialCmd      string   // Output received before a generation was interrupted
+	pagerErr        error    // Last failure opening the pager
+	confirmMismatch bool     // Last typed confirmation phrase didn't match
+	copiedCmd       string   // Track the command that was copied to clipboard
 	err             error
+	errDetail       *errorDetail // What the API said about err, shown in verbose mode
 	width           int
 	height          int
-	anthropicClient *anthropic.Client
-	verbose         bool   // Show full prompt in verbose mode
-	fullPrompt      string // Store the full prompt sent to AI
+	generator       clippy.Generator
+	verbose         bool               // Show full prompt in verbose mode
+	fullPrompt      string             // Store the full prompt sent to AI
+	promptCopied    bool               // The full prompt was copied to the clipboard
+	promptCopyErr   error              // Last failure copying the full prompt
+	alternatives    []string           // Alternatives revealed so far while generating
+	inlineOutput    *runOutput         // Output of the command run with !, as it arrives
+	inlineCancel    context.CancelFunc // Kills the command run with !
+	inlineRunning   bool               // The command run with ! hasn't exited yet
+	inlineExitCode  int                // How the command run with ! exited
+	inlineErr       error              // The command run with ! was stopped or couldn't start
+	inlineRefused   string             // Why the execution policy wouldn't run the command with !
+	outputView      viewport.Model     // Scrolls the output of the command run with !
+	opts            options
+	injectionAcked  bool            // User chose to send context that looks like an injection attempt
+	auditErr        error           // Last failure writing the audit log
+	critique        string          // Second-opinion review of generatedCmd
+	critiqueErr     error           // Last failure getting a critique
+	critiquing      bool            // A critique is being generated
+	explanation     string          // What a dangerous generatedCmd will do
+	explanationErr  error           // Last failure explaining a command
+	explaining      bool            // An explanation is being generated
+	disabledRules   map[string]bool // System prompt rules the user has turned off
+	rulesCursor     int             // Rule highlighted on the rules screen
+	rulesPath       string          // Where rule settings are saved; empty disables saving
+	rulesErr        error           // Last failure saving rule settings
+	originalCmd     string          // generatedCmd as the model returned it, before manual edits
+	copiedTargets   []string        // Selections the command was copied to, if not the default
+	noClipboard     bool            // No clipboard was found at startup, so copying prints instead
+	copyFailed      bool            // copiedCmd couldn't be copied, so it's printed for copying by hand
+	inputs          []requiredInput // Placeholders in generatedCmd still to be filled in
+	inputValues     []string        // Values typed so far for inputs
+	idleID          int             // Identifies the idle countdown started by the latest keypress
+	prettyView      bool            // Show generatedCmd reformatted across lines
+	revealView      bool            // Show generatedCmd with whitespace and hidden characters made visible
+	updateStatePath string          // Where the last update check is cached; empty disables checks
+	latestVersion   string          // Newer release than this one, if the update check found one
+	exported        []string        // Build files the steps were exported to
+	exportErr       error           // Last failure exporting the steps
+	favoritesPath   string          // Where favorites are kept; empty disables saving them
+	favorites       []favorite      // Listed by --favorites, most recent first
+	favoritesCursor int             // Which favorite is highlighted
+	favoriteSaved   bool            // The command on screen was saved as a favorite
+	favoriteErr     error           // Last failure saving a favorite
+	keyHint         string          // Explains that the last key pressed wasn't recognized
+	rawResponse     string          // The last reply exactly as the model sent it
+	runCmd          string          // Run in the shell once the TUI exits
+	runRefused      string          // Why the command was copied rather than run
+	clipboardErr    error           // Why the clipboard couldn't seed the prompt
+	stopReason      string          // Why the model stopped generating generatedCmd
+	modelName       string          // The model generating commands
+	explainShellErr error           // Last failure opening explainshell.com
+	historyPath     string          // Where generated commands are recorded; empty disables history
+	replyCachePath  string          // Where replies are cached for reuse; empty disables the cache
+	cached          bool            // The command came from the cache, without calling the API
+	historyErr      error           // Last failure recording a command in the history
+	modelErr        error           // Why the model typed in to switch to was refused
+	inputTokens     int             // Tokens sent by the last generation
+	outputTokens    int             // Tokens received from the last generation
+	live            *liveReply      // Streams the reply being generated and cancels it
+	streamedText    string          // The reply so far, shown while it streams in
+	tokensNote      string          // Why the reply limit was lowered, if it was
+	temperatureNote string          // Why the temperature was changed, if it was
+	retryAttempt    int             // Which retry of the generation is under way, if any
+	retryLimit      int             // How many retries there will be at most
+	loadingTicks    int             // Spinner ticks so far while waiting, for rotating the loading hints
+	description     string          // Plain-English explanation of describedCmd
+	describedCmd    string          // The command description explains
+	descriptionErr  error           // Last failure explaining a command on request
+	describing      bool            // An explanation was asked for and is being generated
 }
 
 // Messages
 type cmdGeneratedMsg struct {
 	cmd        string
+	alts       []string // Every alternative with --count, cmd being the first
+	undo       string
+	verify     string
+	inputs     []requiredInput
 	err        error
 	fullPrompt string // Include the full prompt that was sent to AI
+	raw        string // The model's reply before any parsing or trimming
+	stopReason string // Why the model stopped, e.g. clippy.StopMaxTokens
+	cached     bool   // The reply was reused from an identical request
+	// Tokens the request used, for the estimate shown in verbose mode
+	inputTokens  int
+	outputTokens int
 }
 
+// injectionWarningMsg is sent instead of calling the API when the context
+// included in the prompt looks like it contains instructions
+type injectionWarningMsg struct{}
+
 type cmdCopiedMsg struct {
-	cmd string
+	cmd     string
+	targets []string // Clipboard targets that were written, with --clipboard-targets
+	err     error
+	printed bool // cmd couldn't be copied, so it's to be printed instead
+}
+
+// promptCopiedMsg reports the outcome of copying the full prompt, which
+// unlike copying the command leaves ClippyCLI open
+type promptCopiedMsg struct {
 	err error
 }
 
@@ -58,48 +245,73 @@ type cmdCopiedMsg struct {
 var (
 	titleStyle = lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#7C3AED")).
+			Foreground(darkTheme.title).
 			MarginBottom(1)
 
 	promptStyle = lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("#059669"))
+			Foreground(darkTheme.prompt)
 
 	cmdStyle = lipgloss.NewStyle().
-			Background(lipgloss.Color("#1F2937")).
-			Foreground(lipgloss.Color("#F9FAFB")).
+			Background(darkTheme.commandBackground).
+			Foreground(darkTheme.command).
 			Padding(1).
 			MarginTop(1).
 			MarginBottom(1).
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#6B7280"))
+			BorderForeground(darkTheme.border)
 
 	helpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#6B7280")).
+			Foreground(darkTheme.help).
 			MarginTop(1)
 
 	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#DC2626")).
+			Foreground(darkTheme.err).
 			Bold(true).
 			MarginTop(1)
 
+	dimStyle = lipgloss.NewStyle().
+			Foreground(darkTheme.dim)
+
+	secondaryStyle = lipgloss.NewStyle().
+			Foreground(darkTheme.secondary).
+			Padding(0, 1).
+			MarginBottom(1).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(darkTheme.dim)
+
 	verbosePromptStyle = lipgloss.NewStyle().
-				Background(lipgloss.Color("#374151")).
-				Foreground(lipgloss.Color("#D1D5DB")).
+				Background(darkTheme.verboseBackground).
+				Foreground(darkTheme.secondary).
 				Padding(1).
 				MarginTop(1).
 				MarginBottom(1).
 				Border(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("#4B5563"))
+				BorderForeground(darkTheme.dim)
+
+	critiqueStyle = lipgloss.NewStyle().
+			Foreground(darkTheme.critique).
+			Padding(0, 1).
+			MarginBottom(1).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(darkTheme.critiqueBorder)
 )
 
 func initialModel(initialPrompt string, verbose bool) model {
+	return newModel(options{prompt: initialPrompt, verbose: verbose})
+}
+
+// newModel builds the initial model from the parsed command-line options
+func newModel(opts options) model {
+	initialPrompt := opts.prompt
+
 	// Initialize textarea
 	ta := textarea.New()
 	ta.Placeholder = "Describe what you want to do..."
 	ta.Focus()
-	ta.SetWidth(80)
+	ta.SetWidth(min(80, opts.contentMaxWidth()))
 	ta.SetHeight(3)
+	ta.Prompt = glyphs.textareaPrompt
 
 	// If we have an initial prompt, set it and adjust the UI
 	if initialPrompt != "" {
@@ -110,65 +322,102 @@ func initialModel(initialPrompt string, verbose bool) model {
 
 	// Initialize spinner
 	s := spinner.New()
-	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#7C3AED"))
-
-	// Initialize Anthropic client
-	client := anthropic.NewClient()
+	s.Spinner = glyphs.spinner
+	s.Style = lipgloss.NewStyle().Foreground(colors.title)
 
 	// Determine initial state based on whether we have a prompt
 	initialState := stateInput
 	if initialPrompt != "" {
 		initialState = stateLoading
 	}
+	if opts.editRules {
+		initialState = stateRules
+	}
+	if opts.favorites {
+		initialState = stateFavorites
+	}
 
-	return model{
+	// Asking for more than the model can give fails every request
+	modelName := clippy.ModelFor(opts.provider, opts.model)
+	var tokensNote string
+	opts.maxTokens, tokensNote = clippy.CapMaxTokens(modelName, opts.maxTokens)
+	var temperatureNote string
+	if opts.temperature != nil {
+		clamped, note := clippy.ClampTemperature(*opts.temperature)
+		opts.temperature, temperatureNote = &clamped, note
+	}
+
+	m := model{
 		state:           initialState,
 		textarea:        ta,
 		spinner:         s,
 		prompt:          initialPrompt,
-		anthropicClient: &client,
-		verbose:         verbose,
+		generator:       newGenerator(opts.provider, opts),
+		modelName:       modelName,
+		tokensNote:      tokensNote,
+		temperatureNote: temperatureNote,
+		live:            newLiveReply(),
+		verbose:         opts.verbose,
+		opts:            opts,
 	}
+	// A prompt given as an argument wins over the clipboard
+	if opts.fromClipboard && initialPrompt == "" {
+		m = m.seedFromClipboard()
+	}
+	return m
 }
 
 func (m model) Init() tea.Cmd {
 	cmds := []tea.Cmd{
 		textarea.Blink,
-		m.spinner.Tick,
+		m.warmUp(),
+		m.scheduleIdleTimeout(),
+		m.checkUpdateCmd(),
 	}
 
 	// If we start in loading state (with initial prompt), generate command immediately
 	if m.state == stateLoading && m.prompt != "" {
-		cmds = append(cmds, m.generateCommand())
+		cmds = append(cmds, m.startGeneration(m.generateCommand()))
 	}
 
 	return tea.Batch(cmds...)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Every branch adds to cmds rather than returning early, so commands
+	// queued earlier in the same update are never dropped
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.textarea.SetWidth(min(80, msg.Width-4))
+		m.textarea.SetWidth(m.contentWidth())
+		m.outputView.Width = m.outputWidth()
+		m.outputView.Height = m.outputHeight()
 
 	case tea.KeyMsg:
+		var idle tea.Cmd
+		m, idle = m.resetIdleTimeout()
+		cmds = append(cmds, idle)
+
+		// Keys are dispatched through stateBindings, which also builds the
+		// help footer, so every handled key is listed there
 		switch m.state {
 		case stateInput:
-			switch msg.String() {
-			case "ctrl+c", "esc":
-				return m, tea.Quit
-			case "enter":
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionEditRules:
+				m.state = stateRules
+				m.rulesErr = nil
+			case actionPasteClipboard:
+				m = m.seedFromClipboard()
+			case actionSubmit:
 				if strings.TrimSpace(m.textarea.Value()) != "" {
 					m.prompt = m.textarea.Value()
 					m.state = stateLoading
-					return m, tea.Batch(
-						m.spinner.Tick,
-						m.generateCommand(),
-					)
+					cmds = append(cmds, m.startGeneration(m.generateCommand()))
 				}
 			default:
 				var cmd tea.Cmd
@@ -176,78 +425,617 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmds = append(cmds, cmd)
 			}
 
+		case stateLoading, stateStreaming:
+			// Don't leave the request running after quitting
+			if m.keyAction(msg.String()) == actionQuit {
+				m.live.stop()
+				cmds = append(cmds, tea.Quit)
+			}
+
 		case stateResult:
-			switch msg.String() {
-			case "ctrl+c", "esc":
-				return m, tea.Quit
-			case "enter":
-				if m.generatedCmd != "" {
-					return m, m.executeCommand()
+			m.keyHint = ""
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionCopy:
+				var cmd tea.Cmd
+				m, cmd = m.copyOrConfirm()
+				cmds = append(cmds, cmd)
+			case actionUp:
+				var cmd tea.Cmd
+				m, cmd = m.selectCommand((m.selectedCmd + len(m.generatedCmds) - 1) % len(m.generatedCmds))
+				cmds = append(cmds, cmd)
+			case actionDown:
+				var cmd tea.Cmd
+				m, cmd = m.selectCommand((m.selectedCmd + 1) % len(m.generatedCmds))
+				cmds = append(cmds, cmd)
+			case actionJoin:
+				m.joinMode = m.joinMode.next()
+			case actionSaveScript:
+				m.state = stateSaveScript
+				m.scriptErr = nil
+				m.textarea.SetValue("script.sh")
+				m.textarea.CursorEnd()
+				m.textarea.Focus()
+				cmds = append(cmds, textarea.Blink)
+			case actionInstalledTools:
+				m.avoidTools = append(m.avoidTools, m.missingTools...)
+				m.state = stateLoading
+				cmds = append(cmds, m.startGeneration(m.generateCommand()))
+			case actionExplain:
+				var cmd tea.Cmd
+				m, cmd = m.startDescription()
+				cmds = append(cmds, cmd)
+			case actionPager:
+				cmds = append(cmds, m.openPager())
+			case actionExplainShell:
+				m.explainShellErr = nil
+				cmds = append(cmds, m.explainShell())
+			case actionCopyUndo:
+				cmds = append(cmds, m.copyCommand(m.undoCmd))
+			case actionCopyVerify:
+				cmds = append(cmds, m.copyCommand(m.verifyCmd))
+			case actionReveal:
+				m.revealView = !m.revealView
+			case actionPretty:
+				m.prettyView = !m.prettyView
+			case actionCopyPretty:
+				cmds = append(cmds, m.copyCommand(prettyFormat(m.generatedCmd)))
+			case actionRun:
+				var cmd tea.Cmd
+				m, cmd = m.runOrCopy()
+				cmds = append(cmds, cmd)
+			case actionCopyRaw:
+				cmds = append(cmds, m.copyCommand(m.rawResponse))
+			case actionCritique:
+				m.critiquing = true
+				m.critique = ""
+				m.critiqueErr = nil
+				cmds = append(cmds, m.spinner.Tick, m.critiqueCommand())
+			case actionEditPrompt:
+				m.state = stateEdit
+				m.textarea.SetValue(m.prompt)
+				m.textarea.Focus()
+				cmds = append(cmds, textarea.Blink)
+			case actionEditCommand:
+				var cmd tea.Cmd
+				m, cmd = m.startEditCommand()
+				cmds = append(cmds, cmd)
+			case actionFavorite:
+				cmds = append(cmds, m.saveFavoriteCmd())
+			case actionRegenerate:
+				var cmd tea.Cmd
+				m, cmd = m.regenerate()
+				cmds = append(cmds, cmd)
+			case actionRunInline:
+				m = m.confirmRunInline()
+			case actionCopyPrompt:
+				prompt := m.fullPrompt
+				cmds = append(cmds, func() tea.Msg {
+					return promptCopiedMsg{err: copyToClipboard(prompt)}
+				})
+			default:
+				// A stray key shouldn't throw away the command unless asked to
+				if m.opts.legacyKeys {
+					cmds = append(cmds, tea.Quit)
+				} else {
+					m.keyHint = keyNames([]string{msg.String()}) + " does nothing here"
 				}
-			case "e":
+			}
+
+		case stateError:
+			m.keyHint = ""
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionRetry:
+				var cmd tea.Cmd
+				m, cmd = m.retry()
+				cmds = append(cmds, cmd)
+			case actionEditPrompt:
 				m.state = stateEdit
 				m.textarea.SetValue(m.prompt)
 				m.textarea.Focus()
 				cmds = append(cmds, textarea.Blink)
+			case actionChangeModel:
+				var cmd tea.Cmd
+				m, cmd = m.startChangeModel()
+				cmds = append(cmds, cmd)
+			case actionCopyRaw:
+				cmds = append(cmds, m.copyCommand(m.rawResponse))
+			default:
+				if m.opts.legacyKeys {
+					cmds = append(cmds, tea.Quit)
+				} else {
+					m.keyHint = keyNames([]string{msg.String()}) + " does nothing here"
+				}
+			}
+
+		case stateChangeModel:
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionBack:
+				m.state = stateError
+			case actionSubmit:
+				var cmd tea.Cmd
+				m, cmd = m.changeModel()
+				cmds = append(cmds, cmd)
 			default:
-				// Any other key cancels
-				return m, tea.Quit
+				var cmd tea.Cmd
+				m.textarea, cmd = m.textarea.Update(msg)
+				cmds = append(cmds, cmd)
 			}
 
 		case stateEdit:
-			switch msg.String() {
-			case "ctrl+c", "esc":
-				return m, tea.Quit
-			case "enter":
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionSubmit:
 				if strings.TrimSpace(m.textarea.Value()) != "" {
 					m.prompt = m.textarea.Value()
 					m.state = stateLoading
 					m.err = nil
-					return m, tea.Batch(
-						m.spinner.Tick,
-						m.generateCommand(),
-					)
+					cmds = append(cmds, m.startGeneration(m.generateCommand()))
+				}
+			default:
+				var cmd tea.Cmd
+				m.textarea, cmd = m.textarea.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+
+		case stateEditCmd:
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionBack:
+				m.state = stateResult
+			case actionSubmit:
+				var cmd tea.Cmd
+				m, cmd = m.finishEditCommand()
+				cmds = append(cmds, cmd)
+			default:
+				var cmd tea.Cmd
+				m.textarea, cmd = m.textarea.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+
+		case stateSaveScript:
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionBack:
+				m.state = stateResult
+			case actionSubmit:
+				if path := strings.TrimSpace(m.textarea.Value()); path != "" {
+					m.state = stateResult
+					cmds = append(cmds, m.saveScriptCmd(path))
+				}
+			default:
+				var cmd tea.Cmd
+				m.textarea, cmd = m.textarea.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+
+		case stateStrictConfirm:
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionBack:
+				m.state = stateResult
+			case actionSubmit:
+				if strings.TrimSpace(m.textarea.Value()) == m.confirmPhrase() {
+					_, reason := isDangerous(m.generatedCmd)
+					m = m.audit("confirmed dangerous command: "+reason, m.generatedCmd)
+					m.state = stateResult
+					cmds = append(cmds, m.executeCommand())
+				} else {
+					m.confirmMismatch = true
+				}
+			default:
+				var cmd tea.Cmd
+				m.textarea, cmd = m.textarea.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+
+		case stateFillInputs:
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionBack:
+				m.state = stateResult
+			case actionSubmit:
+				m = m.nextInput()
+				// Export the finished command rather than the placeholders
+				if m.state == stateResult {
+					var cmd tea.Cmd
+					m, cmd = m.autoCopy(m.exportCmd())
+					cmds = append(cmds, cmd)
 				}
 			default:
 				var cmd tea.Cmd
 				m.textarea, cmd = m.textarea.Update(msg)
 				cmds = append(cmds, cmd)
 			}
+
+		case statePipeConfirm:
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionConfirm:
+				url, _ := detectPipeToShell(m.generatedCmd)
+				m = m.audit("confirmed running a downloaded script from "+url, m.generatedCmd)
+				m.state = stateResult
+				var cmd tea.Cmd
+				m, cmd = m.copyWithConfirmation()
+				cmds = append(cmds, cmd)
+			default:
+				m.state = stateResult
+			}
+
+		case stateExplain:
+			if m.keyAction(msg.String()) == actionQuit {
+				cmds = append(cmds, tea.Quit)
+			} else {
+				m.state = stateResult
+			}
+
+		case stateExplainConfirm:
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionConfirm:
+				_, reason := isDangerous(m.generatedCmd)
+				m = m.audit("confirmed dangerous command after explanation: "+reason, m.generatedCmd)
+				m.state = stateResult
+				cmds = append(cmds, m.executeCommand())
+			default:
+				m.state = stateResult
+			}
+
+		case stateRunConfirm:
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionConfirm:
+				var cmd tea.Cmd
+				m, cmd = m.runInline()
+				cmds = append(cmds, cmd)
+			default:
+				m.state = stateResult
+			}
+
+		case stateOutput:
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				// Ctrl+C stops a running command rather than leaving it behind
+				if m.inlineRunning {
+					m = m.stopInline()
+				} else {
+					cmds = append(cmds, tea.Quit)
+				}
+			case actionBack:
+				m.state = stateResult
+			case actionScroll:
+				var cmd tea.Cmd
+				m.outputView, cmd = m.outputView.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+
+		case statePager:
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionBack:
+				m.state = stateResult
+			case actionScroll:
+				var cmd tea.Cmd
+				m.outputView, cmd = m.outputView.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+
+		case stateFavorites:
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionUp:
+				if m.favoritesCursor > 0 {
+					m.favoritesCursor--
+				}
+			case actionDown:
+				if m.favoritesCursor < len(m.favorites)-1 {
+					m.favoritesCursor++
+				}
+			case actionCopy:
+				var cmd tea.Cmd
+				m, cmd = m.copyFavorite()
+				cmds = append(cmds, cmd)
+			}
+
+		case stateRules:
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionUp:
+				if m.rulesCursor > 0 {
+					m.rulesCursor--
+				}
+			case actionDown:
+				if m.rulesCursor < len(clippy.Rules)-1 {
+					m.rulesCursor++
+				}
+			case actionToggle:
+				m = m.toggleRule()
+			case actionBack:
+				m.state = stateInput
+				cmds = append(cmds, m.saveRulesCmd(), textarea.Blink)
+			}
+
+		case stateInterrupted:
+			switch m.keyAction(msg.String()) {
+			case actionQuit:
+				cmds = append(cmds, tea.Quit)
+			case actionContinue:
+				m.state = stateLoading
+				m.err = nil
+				cmds = append(cmds, m.startGeneration(m.continueGeneration()))
+			case actionStartOver:
+				m.state = stateLoading
+				m.err = nil
+				m.partialCmd = ""
+				cmds = append(cmds, m.startGeneration(m.generateCommand()))
+			case actionKeepPartial:
+				// Treat what arrived as the finished command
+				partial := clippy.SanitizeCommand(m.partialCmd)
+				m.err = nil
+				m.partialCmd = ""
+				cmds = append(cmds, func() tea.Msg { return cmdGeneratedMsg{cmd: partial} })
+			}
+
+		case stateInjectionWarning:
+			switch m.keyAction(msg.String()) {
+			case actionConfirm:
+				m.injectionAcked = true
+				m = m.audit("sent context flagged as possible prompt injection", "")
+				m.state = stateLoading
+				cmds = append(cmds, m.startGeneration(m.generateCommand()))
+			default:
+				cmds = append(cmds, tea.Quit)
+			}
+		}
+
+	case runFinishedMsg:
+		m.inlineRunning = false
+		m.inlineExitCode = msg.exitCode
+		m.inlineErr = msg.err
+		m = m.refreshOutput()
+
+	case promptCopiedMsg:
+		m.promptCopyErr = msg.err
+		m.promptCopied = msg.err == nil
+
+	case favoriteSavedMsg:
+		m.favoriteErr = msg.err
+		m.favoriteSaved = msg.err == nil
+
+	case exportSavedMsg:
+		if msg.err != nil {
+			m.exportErr = msg.err
+		} else {
+			m.exported = append(m.exported, fmt.Sprintf("%s %s", msg.format, msg.path))
+		}
+
+	case updateAvailableMsg:
+		m.latestVersion = msg.latest
+
+	case idleTickMsg:
+		var cmd tea.Cmd
+		m, cmd = m.handleIdleTick(msg)
+		cmds = append(cmds, cmd)
+
+	case pagerClosedMsg:
+		m.pagerErr = msg.err
+
+	case pagerViewMsg:
+		m = m.openBuiltinPager(msg.content)
+
+	case explainShellMsg:
+		m.explainShellErr = msg.err
+		if msg.local != "" {
+			cmds = append(cmds, showInPager(msg.local))
+		}
+
+	case rulesSavedMsg:
+		m.rulesErr = msg.err
+
+	case scriptSavedMsg:
+		m.scriptErr = msg.err
+		if msg.err == nil {
+			m.scriptPath = msg.path
+		} else if errors.Is(msg.err, os.ErrExist) && m.state == stateResult {
+			// Ask for another name rather than replace the file
+			m.state = stateSaveScript
+			m.textarea.SetValue(msg.path)
+			m.textarea.CursorEnd()
+			m.textarea.Focus()
+			cmds = append(cmds, textarea.Blink)
+		}
+
+	case injectionWarningMsg:
+		m.state = stateInjectionWarning
+
+	case critiqueMsg:
+		m.critiquing = false
+		m.critique = msg.text
+		m.critiqueErr = msg.err
+
+	case descriptionMsg:
+		// Keep the explanation even if the user has gone back, for next time
+		if msg.cmd == m.describedCmd {
+			m.describing = false
+			m.description = msg.text
+			m.descriptionErr = msg.err
+		}
+
+	case explanationMsg:
+		// Ignore an explanation of a command that's since been replaced
+		if msg.cmd == m.originalCmd {
+			m.explaining = false
+			m.explanation = msg.text
+			m.explanationErr = msg.err
 		}
 
+	case generationInterruptedMsg:
+		m.state = stateInterrupted
+		m.loadingTicks = 0
+		m.retryAttempt = 0
+		m.partialCmd = msg.partial
+		m.err = msg.err
+
+	case historySavedMsg:
+		m.historyErr = msg.err
+
 	case cmdGeneratedMsg:
 		m.state = stateResult
+		m.loadingTicks = 0
+		m.alternatives = nil
+		m.retryAttempt = 0
+		// Kept even when parsing failed, since that's when it's most useful
+		m.rawResponse = msg.raw
+		m.stopReason = msg.stopReason
+		m.inputTokens = msg.inputTokens
+		m.outputTokens = msg.outputTokens
+		m.cached = msg.cached
 		if msg.err != nil {
+			m.state = stateError
 			m.err = msg.err
+			m.errDetail = apiErrorDetail(msg.err)
 		} else {
 			m.generatedCmd = msg.cmd
+			m.originalCmd = msg.cmd
+			m.generatedCmds = msg.alts
+			m.selectedCmd = 0
+			m.undoCmd = msg.undo
+			m.verifyCmd = msg.verify
+			m.critique = ""
+			m.critiqueErr = nil
+			cmds = append(cmds, m.saveHistory(msg.cmd))
+			var cmd tea.Cmd
+			m, cmd = m.startExplanation()
+			cmds = append(cmds, cmd)
+			m.inputs = msg.inputs
+			// Scripts define their own functions, so only check one-off commands
+			if !m.opts.asScript {
+				m.missingTools = missingTools(msg.cmd)
+			}
 			m.fullPrompt = msg.fullPrompt
+
+			// Ask for anything the model couldn't know before showing the result
+			m.exported = nil
+			m.exportErr = nil
+			m.favoriteSaved = false
+			m.favoriteErr = nil
+			m.promptCopied = false
+			m.promptCopyErr = nil
+			m.inlineRefused = ""
+			if len(m.inputs) > 0 {
+				var cmd tea.Cmd
+				m, cmd = m.startFillInputs()
+				cmds = append(cmds, cmd)
+			} else {
+				var cmd tea.Cmd
+				m, cmd = m.autoCopy(m.exportCmd())
+				cmds = append(cmds, cmd)
+			}
+		}
+
+		if m.opts.notify {
+			if msg.err != nil {
+				cmds = append(cmds, notifyCmd("ClippyCLI: generation failed", msg.err.Error()))
+			} else {
+				cmds = append(cmds, notifyCmd("ClippyCLI: command ready", msg.cmd))
+			}
+		}
+
+	case cmdStreamChunkMsg:
+		// Text that arrives after the result is ignored, and once the
+		// generation is done there's nothing more to listen for
+		if msg.done || (m.state != stateLoading && m.state != stateStreaming) {
+			break
+		}
+		cmds = append(cmds, m.live.listen())
+		if msg.retry > 0 {
+			m.retryAttempt, m.retryLimit = msg.retry, msg.retries
+			break
 		}
+		// Alternatives are revealed a line at a time instead
+		if m.opts.count > 1 {
+			m.alternatives = completedAlternatives(msg.text)
+			break
+		}
+		m.state = stateStreaming
+		m.streamedText = msg.text
 
 	case cmdCopiedMsg:
 		if msg.err != nil {
 			fmt.Fprintf(os.Stderr, "Error: Could not copy command to clipboard: %v\n", msg.err)
-		} else {
+		}
+		// Some targets may have succeeded even if others failed
+		if msg.cmd != "" {
 			m.copiedCmd = msg.cmd
+			m.copiedTargets = msg.targets
+			m.copyFailed = msg.printed
 		}
-		return m, tea.Quit
+		cmds = append(cmds, tea.Quit)
 
 	case spinner.TickMsg:
-		if m.state == stateLoading {
+		// Each tick schedules the next, so dropping one stops the loop
+		// until startGeneration, a critique or an explanation arms it again
+		if m.spinning() {
 			var cmd tea.Cmd
 			m.spinner, cmd = m.spinner.Update(msg)
 			cmds = append(cmds, cmd)
 		}
+		// Show the output of a command run with ! as it arrives
+		if m.inlineRunning {
+			m = m.refreshOutput()
+		}
+		// Count ticks while waiting, to rotate the loading hints
+		if m.state == stateLoading {
+			m.loadingTicks++
+		} else {
+			m.loadingTicks = 0
+		}
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+// truncationWarning notes when the reply stopped at the token limit, which
+// can cut a command off partway
+func (m model) truncationWarning() string {
+	if m.stopReason != clippy.StopMaxTokens {
+		return ""
+	}
+	return "\n" + errorStyle.Render("Warning: the reply hit the token limit, so the output may be truncated. Increase --max-tokens, or press E and regenerate.")
+}
+
+// spinning reports whether anything on screen is waiting on the spinner
+func (m model) spinning() bool {
+	return m.state == stateLoading || m.state == stateStreaming || m.critiquing || m.explaining || m.describing || m.inlineRunning
+}
+
+// startGeneration shows the spinner while the given generation runs, and
+// its reply as it streams in
+func (m model) startGeneration(generate tea.Cmd) tea.Cmd {
+	return tea.Batch(m.spinner.Tick, generate, m.live.listen())
+}
+
 func (m model) View() string {
 	var content strings.Builder
 
 	// Title
-	content.WriteString(titleStyle.Render("🔧 ClippyCLI - AI Command Generator"))
+	content.WriteString(titleStyle.Render(glyphs.titleIcon + "ClippyCLI - AI Command Generator"))
 	content.WriteString("\n\n")
 
 	switch m.state {
@@ -259,162 +1047,634 @@ func (m model) View() string {
 		}
 		content.WriteString("\n\n")
 		content.WriteString(m.textarea.View())
+		if m.rulesErr != nil {
+			content.WriteString("\n")
+			content.WriteString(errorStyle.Render("Error: could not save rules: " + m.rulesErr.Error()))
+		}
+		if m.clipboardErr != nil {
+			content.WriteString("\n")
+			content.WriteString(dimStyle.Render("Nothing taken from the clipboard: " + m.clipboardErr.Error()))
+		}
+		content.WriteString("\n")
+		content.WriteString(m.helpFooter())
+
+	case stateRunConfirm:
+		content.WriteString(m.runConfirmView())
+
+	case stateOutput:
+		content.WriteString(m.outputScreen())
+
+	case statePager:
+		content.WriteString(m.pagerScreen())
+
+	case stateFavorites:
+		content.WriteString(promptStyle.Render("Favorites:"))
+		content.WriteString("\n\n")
+		content.WriteString(m.favoritesView())
 		content.WriteString("\n")
-		content.WriteString(helpStyle.Render("Press Enter to generate command • Ctrl+C/Esc to quit"))
+		content.WriteString(m.helpFooter())
+
+	case stateRules:
+		content.WriteString(promptStyle.Render("System prompt rules:"))
+		content.WriteString("\n\n")
+		content.WriteString(m.rulesView())
+		content.WriteString(m.helpFooter())
 
-	case stateLoading:
+	case stateLoading, stateStreaming:
 		content.WriteString(promptStyle.Render("Generating command for:"))
 		content.WriteString("\n\n")
 		if m.prompt != "" {
 			// Show the prompt being processed
 			promptDisplay := lipgloss.NewStyle().
 				Italic(true).
-				Foreground(lipgloss.Color("#6B7280")).
+				Foreground(colors.help).
 				Render("\"" + m.prompt + "\"")
 			content.WriteString(promptDisplay)
 			content.WriteString("\n\n")
 		}
-		content.WriteString(m.spinner.View() + " Thinking...")
-
-	case stateResult:
-		if m.err != nil {
-			content.WriteString(errorStyle.Render("Error: " + m.err.Error()))
+		if m.state == stateStreaming {
+			// Show the command as it's written
+			content.WriteString(m.spinner.View() + " Writing...")
 			content.WriteString("\n")
-			content.WriteString(helpStyle.Render("Press any key to quit"))
+			content.WriteString(m.box(cmdStyle, m.streamedText))
+		} else if m.retryAttempt > 0 {
+			content.WriteString(m.spinner.View() + fmt.Sprintf(" Retrying (%d/%d)...", m.retryAttempt, m.retryLimit))
 		} else {
-			content.WriteString(promptStyle.Render("Generated command:"))
-			content.WriteString("\n")
-			content.WriteString(cmdStyle.Render(m.generatedCmd))
+			content.WriteString(m.spinner.View() + " " + m.loadingHint())
+		}
 
-			// Show verbose prompt if verbose mode is enabled
-			if m.verbose && m.fullPrompt != "" {
-				content.WriteString("\n")
-				content.WriteString(promptStyle.Render("Full prompt sent to AI:"))
+		// Reveal alternatives as they arrive so the first option shows quickly
+		if len(m.alternatives) > 0 {
+			content.WriteString("\n\n")
+			content.WriteString(dimStyle.Render("Generating alternatives..."))
+			for i, alt := range m.alternatives {
 				content.WriteString("\n")
-				content.WriteString(verbosePromptStyle.Render(m.fullPrompt))
+				content.WriteString(dimStyle.Render(fmt.Sprintf("  %d. %s", i+1, alt)))
 			}
+		}
+		content.WriteString("\n")
+		content.WriteString(m.helpFooter())
 
+	case stateResult:
+		if len(m.generatedCmds) > 1 {
+			content.WriteString(m.alternativesView())
 			content.WriteString("\n")
-			content.WriteString(helpStyle.Render("Press Enter to copy to clipboard • E to edit prompt • Any other key to cancel"))
 		}
-
-	case stateEdit:
-		content.WriteString(promptStyle.Render("Edit your prompt:"))
-		content.WriteString("\n\n")
-		content.WriteString(m.textarea.View())
+		content.WriteString(promptStyle.Render("Generated command:"))
+		if m.cached {
+			content.WriteString(dimStyle.Render(" (cached, press r for a fresh one)"))
+		}
 		content.WriteString("\n")
-		content.WriteString(helpStyle.Render("Press Enter to regenerate • Ctrl+C/Esc to quit"))
-	}
-
-	return content.String()
-}
-
-func (m model) generateCommand() tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
-
-		// Get environment information
-		envInfo := getEnvironmentInfo()
+		switch {
+		case m.revealView:
+			content.WriteString(m.box(cmdStyle, revealWhitespace(m.generatedCmd)))
+		case m.prettyView && m.canPrettyFormat():
+			content.WriteString(m.box(cmdStyle, m.displayCommand(prettyFormat(m.generatedCmd))))
+		default:
+			content.WriteString(m.box(cmdStyle, m.displayCommand(m.generatedCmd)))
+		}
 
-		systemPrompt := fmt.Sprintf(`You are a helpful command-line assistant. Given a user's description of what they want to do, generate a single, safe command that accomplishes their goal.
+		// A reply cut off by the token limit may be missing its end
+		content.WriteString(m.truncationWarning())
 
-Environment Information:
-%s
+		// Flag destructive commands before they're copied, not only after
+		if dangerous, reason := isDangerous(m.generatedCmd); dangerous {
+			content.WriteString("\n")
+			content.WriteString(errorStyle.Render("Warning: this command " + reason + ", so copying it needs confirming"))
+		}
 
-Rules:
-1. Return ONLY the command, no explanations or markdown
-2. Make sure the command is safe and won't cause harm
-3. Use commands appropriate for the user's platform and shell
-4. If the request is unclear or potentially dangerous, suggest a safer alternative
-5. For file operations, use relative paths unless absolute paths are specifically requested
-6. Don't include commands that require sudo unless explicitly requested
-7. Consider the user's shell when generating commands (e.g., use appropriate syntax for bash, zsh, fish, etc.)
-8. Take advantage of available environment variables when relevant
+		// Invisible characters can make a command fail after pasting
+		if !m.revealView && hasHiddenChars(m.generatedCmd) {
+			content.WriteString("\n")
+			content.WriteString(errorStyle.Render("Warning: the command contains hidden or unusual characters (press W to reveal)"))
+		}
 
-Examples:
-User: "list all files in current directory"
-Response: ls -la
-
-User: "find all .go files"
-Response: find . -name "*.go"
-
-User: "create a new directory called myproject"
-Response: mkdir myproject`, envInfo)
-
-		// Create the full prompt that includes both system and user messages
-		fullPrompt := fmt.Sprintf("System: %s\n\nUser: %s", systemPrompt, m.prompt)
-
-		message, err := m.anthropicClient.Messages.New(ctx, anthropic.MessageNewParams{
-			Model:     anthropic.ModelClaudeSonnet4_20250514,
-			MaxTokens: 1024,
-			System: []anthropic.TextBlockParam{
-				{Text: systemPrompt},
-			},
-			Messages: []anthropic.MessageParam{
-				{
-					Role: anthropic.MessageParamRoleUser,
-					Content: []anthropic.ContentBlockParamUnion{
-						{
-							OfText: &anthropic.TextBlockParam{
-								Text: m.prompt,
-							},
-						},
-					},
-				},
-			},
-		})
+		// Warn about tools that aren't installed
+		for _, tool := range m.missingTools {
+			content.WriteString("\n")
+			content.WriteString(errorStyle.Render(fmt.Sprintf("Warning: `%s` is not installed", tool)))
+		}
 
-		if err != nil {
-			return cmdGeneratedMsg{err: err, fullPrompt: fullPrompt}
+		// Preview how multiple steps will be joined when copied
+		if steps := m.steps(); m.canJoin() && m.joinMode != joinNewline {
+			content.WriteString("\n")
+			content.WriteString(promptStyle.Render("Will copy as:"))
+			content.WriteString("\n")
+			content.WriteString(m.box(secondaryStyle, joinSteps(steps, m.joinMode)))
 		}
 
-		// Extract the text from the response
-		var cmdText string
-		for _, block := range message.Content {
-			if textBlock := block.AsAny(); textBlock != nil {
-				if tb, ok := textBlock.(anthropic.TextBlock); ok {
-					cmdText = strings.TrimSpace(tb.Text)
-					break
-				}
+		// Show the undo command in a secondary box
+		if m.opts.withUndo {
+			content.WriteString("\n")
+			if m.undoCmd != "" {
+				content.WriteString(promptStyle.Render("Undo command:"))
+				content.WriteString("\n")
+				content.WriteString(m.box(secondaryStyle, m.undoCmd))
+			} else {
+				content.WriteString(dimStyle.Render("No safe undo for this command"))
 			}
 		}
 
-		return cmdGeneratedMsg{cmd: cmdText, fullPrompt: fullPrompt}
-	}
-}
-
-func (m model) executeCommand() tea.Cmd {
-	return func() tea.Msg {
-		// Copy command to clipboard
-		if err := copyToClipboard(m.generatedCmd); err != nil {
-			return cmdCopiedMsg{cmd: "", err: err}
+		// Show the verification command in a secondary box
+		if m.verifyCmd != "" {
+			content.WriteString("\n")
+			content.WriteString(promptStyle.Render("Verify it worked with:"))
+			content.WriteString("\n")
+			content.WriteString(m.box(secondaryStyle, m.verifyCmd))
 		}
 
-		// Return success message with the copied command
-		return cmdCopiedMsg{cmd: m.generatedCmd, err: nil}
-	}
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
+		// Keep the review visually apart from anything that gets copied
+		if m.critiquing {
+			content.WriteString("\n")
+			content.WriteString(m.spinner.View() + " Reviewing command...")
+		} else if m.critique != "" {
+			content.WriteString("\n")
+			content.WriteString(promptStyle.Render("Critique:"))
+			content.WriteString("\n")
+			content.WriteString(m.box(critiqueStyle, m.critique))
+		} else if m.critiqueErr != nil {
+			content.WriteString("\n")
+			content.WriteString(errorStyle.Render("Error: could not get a critique: " + m.critiqueErr.Error()))
+		}
 
-// copyToClipboard copies the command to the clipboard
-func copyToClipboard(command string) error {
-	return clipboard.WriteAll(command)
+		if m.scriptPath != "" {
+			content.WriteString("\n")
+			content.WriteString(promptStyle.Render(glyphs.check + " Saved executable script to " + m.scriptPath))
+		}
+		for _, exported := range m.exported {
+			content.WriteString("\n")
+			content.WriteString(promptStyle.Render(glyphs.check + " Exported steps as " + exported))
+		}
+		if m.exportErr != nil {
+			content.WriteString("\n")
+			content.WriteString(errorStyle.Render("Error: could not export steps: " + m.exportErr.Error()))
+		}
+		if m.favoriteSaved {
+			content.WriteString("\n")
+			content.WriteString(promptStyle.Render(glyphs.check + " Saved to favorites"))
+		}
+		if m.favoriteErr != nil {
+			content.WriteString("\n")
+			content.WriteString(errorStyle.Render("Error: could not save favorite: " + m.favoriteErr.Error()))
+		}
+		if m.inlineRefused != "" {
+			content.WriteString("\n")
+			content.WriteString(errorStyle.Render("Not run because " + m.inlineRefused + "."))
+		}
+		if m.pagerErr != nil {
+			content.WriteString("\n")
+			content.WriteString(errorStyle.Render("Error: could not open pager: " + m.pagerErr.Error()))
+		}
+		if m.explainShellErr != nil {
+			content.WriteString("\n")
+			content.WriteString(errorStyle.Render("Error: could not open explainshell: " + m.explainShellErr.Error()))
+		}
+		if m.scriptErr != nil {
+			content.WriteString("\n")
+			content.WriteString(errorStyle.Render("Error: could not save script: " + m.scriptErr.Error()))
+		}
+
+		// Call out the comment instruction, which is easy to miss in the prompt
+		if instruction := commentStyles[m.opts.commentStyle]; m.verbose && m.opts.asScript && instruction != "" {
+			content.WriteString("\n")
+			content.WriteString(dimStyle.Render(fmt.Sprintf("Comment style (%s): %s", m.opts.commentStyle, instruction)))
+		}
+
+		// Show verbose prompt if verbose mode is enabled
+		if m.verbose && m.fullPrompt != "" {
+			content.WriteString("\n")
+			content.WriteString(dimStyle.Render("Model: " + m.modelName))
+			if usage := m.usageLine(); usage != "" {
+				content.WriteString("\n")
+				content.WriteString(dimStyle.Render(usage))
+			}
+			content.WriteString("\n")
+			content.WriteString(promptStyle.Render("Full prompt sent to AI:"))
+			content.WriteString("\n")
+			content.WriteString(m.box(verbosePromptStyle, m.fullPrompt))
+			if m.promptCopied {
+				content.WriteString("\n")
+				content.WriteString(promptStyle.Render(glyphs.check + " Full prompt copied to clipboard"))
+			}
+			if m.promptCopyErr != nil {
+				content.WriteString("\n")
+				content.WriteString(errorStyle.Render("Error: could not copy the full prompt: " + m.promptCopyErr.Error()))
+			}
+		}
+
+		content.WriteString("\n")
+		content.WriteString(m.helpFooter())
+
+	case stateError:
+		content.WriteString(m.errorView())
+
+	case stateChangeModel:
+		content.WriteString(m.changeModelView())
+
+	case stateEdit:
+		content.WriteString(promptStyle.Render("Edit your prompt:"))
+		content.WriteString("\n\n")
+		content.WriteString(m.textarea.View())
+		content.WriteString("\n")
+		content.WriteString(m.helpFooter())
+
+	case stateEditCmd:
+		content.WriteString(m.editCommandView())
+
+	case stateSaveScript:
+		content.WriteString(promptStyle.Render("Save script as:"))
+		content.WriteString("\n\n")
+		content.WriteString(m.textarea.View())
+		content.WriteString("\n")
+		if errors.Is(m.scriptErr, os.ErrExist) {
+			content.WriteString(errorStyle.Render("A file already exists there, choose another name."))
+			content.WriteString("\n")
+		}
+		content.WriteString(m.helpFooter())
+
+	case stateStrictConfirm:
+		_, reason := isDangerous(m.generatedCmd)
+		content.WriteString(errorStyle.Render("Warning: this command " + reason))
+		content.WriteString("\n")
+		content.WriteString(m.box(cmdStyle, m.generatedCmd))
+		content.WriteString(m.editDiffView())
+		content.WriteString(m.explanationView())
+		content.WriteString("\n")
+		content.WriteString(promptStyle.Render(fmt.Sprintf("Type %q to confirm:", m.confirmPhrase())))
+		content.WriteString("\n\n")
+		content.WriteString(m.textarea.View())
+		if m.confirmMismatch {
+			content.WriteString("\n")
+			content.WriteString(errorStyle.Render("That doesn't match, try again"))
+		}
+		content.WriteString("\n")
+		content.WriteString(m.helpFooter())
+
+	case stateFillInputs:
+		content.WriteString(m.fillInputsView())
+
+	case statePipeConfirm:
+		url, _ := detectPipeToShell(m.generatedCmd)
+		if url == "" {
+			url = "an unknown URL"
+		}
+		content.WriteString(errorStyle.Render("Warning: this command downloads a script and runs it straight away"))
+		content.WriteString("\n")
+		content.WriteString(m.box(cmdStyle, m.generatedCmd))
+		content.WriteString("\n")
+		content.WriteString("It will fetch and execute: " + promptStyle.Render(url))
+		content.WriteString("\n")
+		content.WriteString(dimStyle.Render("Only continue if you trust this source. Consider downloading and reading the script first."))
+		content.WriteString(m.explanationView())
+		content.WriteString("\n")
+		content.WriteString(m.helpFooter())
+
+	case stateExplain:
+		content.WriteString(m.descriptionView())
+
+	case stateExplainConfirm:
+		_, reason := isDangerous(m.generatedCmd)
+		content.WriteString(errorStyle.Render("Warning: this command " + reason))
+		content.WriteString("\n")
+		content.WriteString(m.box(cmdStyle, m.generatedCmd))
+		content.WriteString(m.explanationView())
+		content.WriteString("\n")
+		content.WriteString(m.helpFooter())
+
+	case stateInterrupted:
+		if isConnectionLost(m.err) {
+			content.WriteString(errorStyle.Render("Connection lost while generating"))
+		} else {
+			content.WriteString(errorStyle.Render("Generation was interrupted"))
+		}
+		if m.err != nil {
+			content.WriteString("\n")
+			content.WriteString(dimStyle.Render(m.err.Error()))
+		}
+		content.WriteString("\n")
+		content.WriteString(promptStyle.Render("Received so far:"))
+		content.WriteString("\n")
+		content.WriteString(m.box(secondaryStyle, m.partialCmd))
+		content.WriteString("\n")
+		content.WriteString(m.helpFooter())
+
+	case stateInjectionWarning:
+		content.WriteString(errorStyle.Render("Warning: the context attached to this prompt contains text that looks like instructions to the AI."))
+		content.WriteString("\n")
+		content.WriteString("It will be sent as data, but review it before continuing.")
+		content.WriteString("\n")
+		content.WriteString(m.helpFooter())
+	}
+
+	if m.tokensNote != "" {
+		content.WriteString("\n")
+		content.WriteString(dimStyle.Render("Note: " + m.tokensNote))
+	}
+	if m.temperatureNote != "" {
+		content.WriteString("\n")
+		content.WriteString(dimStyle.Render("Note: " + m.temperatureNote))
+	}
+
+	// A subtle reminder; updating is always left to the user
+	if m.latestVersion != "" {
+		content.WriteString("\n")
+		content.WriteString(dimStyle.Render(fmt.Sprintf("A newer version of ClippyCLI is available: %s (you have %s)", m.latestVersion, version)))
+	}
+
+	// Audit failures never block, but shouldn't go unnoticed either
+	if m.auditErr != nil {
+		content.WriteString("\n")
+		content.WriteString(errorStyle.Render("Warning: could not write audit log: " + m.auditErr.Error()))
+	}
+	if m.historyErr != nil {
+		content.WriteString("\n")
+		content.WriteString(errorStyle.Render("Warning: could not save history: " + m.historyErr.Error()))
+	}
+
+	return m.frame(content.String())
+}
+
+func (m model) generateCommand() tea.Cmd {
+	return m.runGeneration
+}
+
+// runGeneration calls the model and turns its reply into the message the TUI
+// handles. Modes without the TUI call it directly.
+func (m model) runGeneration() tea.Msg {
+	ctx, onText := m.live.begin()
+	defer m.live.end()
+
+	// Get environment information
+	envInfo := getEnvironmentInfo(m.envOptions())
+
+	// Don't send context that looks like it's trying to give the model
+	// instructions without the user's go-ahead
+	if !m.injectionAcked && looksLikeInjection(envInfo) {
+		return injectionWarningMsg{}
+	}
+
+	systemPrompt := m.systemPrompt(envInfo)
+
+	// Create the full prompt that includes both system and user messages
+	fullPrompt := formatFullPrompt(systemPrompt, m.prompt)
+
+	// An identical request made recently doesn't need paying for twice
+	cacheKey := replyCacheKey(m.provider(), m.modelName, m.opts.temperature, m.opts.maxTokens, systemPrompt, m.prompt)
+	if msg, ok := m.cachedGeneration(cacheKey, fullPrompt); ok {
+		return msg
+	}
+
+	// Structured replies aren't readable until they're parsed
+	if m.responseFormat() != "" {
+		onText = nil
+	}
+
+	// A stalled connection shouldn't leave the spinner going forever
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	// A reply cut off partway still returns what already arrived
+	reply, err := m.generateWithRetry(ctx, systemPrompt, []clippy.Message{clippy.UserMessage(m.prompt)}, onText)
+	err = m.timeoutError(ctx, err)
+	if streamInterrupted(reply.Text, err) {
+		return generationInterruptedMsg{partial: reply.Text, err: err}
+	}
+	if err != nil {
+		return cmdGeneratedMsg{err: err, fullPrompt: fullPrompt}
+	}
+
+	msg := m.finishGeneration(reply.Text, fullPrompt)
+	msg.stopReason = reply.StopReason
+	msg.inputTokens = reply.InputTokens
+	msg.outputTokens = reply.OutputTokens
+	// A cut-off reply is worth asking for again
+	if msg.err == nil && reply.StopReason != clippy.StopMaxTokens {
+		m.cacheReply(cacheKey, reply.Text)
+	}
+	return msg
+}
+
+// formatFullPrompt lays out the system and user prompts as shown in verbose mode
+func formatFullPrompt(systemPrompt, userPrompt string) string {
+	return fmt.Sprintf("System: %s\n\nUser: %s", systemPrompt, userPrompt)
+}
+
+// finishGeneration turns the model's reply into a cmdGeneratedMsg, keeping
+// the reply as received for debugging
+func (m model) finishGeneration(reply, fullPrompt string) cmdGeneratedMsg {
+	cmdText := strings.TrimSpace(reply)
+	// The model sometimes puts the command in a code block despite rule 1
+	if m.responseFormat() == "" {
+		cmdText = clippy.SanitizeCommand(cmdText)
+	}
+	if cmdText == "" {
+		return cmdGeneratedMsg{err: clippy.ErrEmptyReply, fullPrompt: fullPrompt, raw: reply}
+	}
+	// Paths with spaces break if the model forgot to quote them
+	spaced := spacedPaths()
+
+	// Structured replies carry extra fields alongside the command
+	if m.responseFormat() != "" {
+		resp, err := parseCommandResponse(cmdText)
+		if err != nil {
+			return cmdGeneratedMsg{err: err, fullPrompt: fullPrompt, raw: reply}
+		}
+		return cmdGeneratedMsg{cmd: quoteUnquotedPaths(resp.Command, spaced), undo: resp.Undo, verify: resp.Verify, inputs: resp.Inputs, fullPrompt: fullPrompt, raw: reply}
+	}
+
+	// Several alternatives come one per line, the first being the best
+	if m.opts.count > 1 {
+		var alts []string
+		for _, alt := range parseAlternatives(cmdText) {
+			alts = append(alts, quoteUnquotedPaths(alt, spaced))
+		}
+		if len(alts) > 0 {
+			return cmdGeneratedMsg{cmd: alts[0], alts: alts, fullPrompt: fullPrompt, raw: reply}
+		}
+	}
+
+	return cmdGeneratedMsg{cmd: quoteUnquotedPaths(cmdText, spaced), fullPrompt: fullPrompt, raw: reply}
+}
+
+// confirmPhrase is what the user must type to confirm a dangerous command:
+// the name of the tool it runs
+func (m model) confirmPhrase() string {
+	if segments := splitSegments(m.generatedCmd); len(segments) > 0 {
+		if tool := primaryTool(segments[0]); tool != "" {
+			return tool
+		}
+	}
+	return "yes"
+}
+
+// steps returns the individual steps of a multi-step command. Scripts are
+// kept whole, so they have no steps.
+func (m model) steps() []string {
+	if m.opts.asScript {
+		return nil
+	}
+	return splitSteps(m.generatedCmd)
+}
+
+// canJoin reports whether the steps can be joined with && or ;, which takes
+// more than one, each a complete command
+func (m model) canJoin() bool {
+	steps := m.steps()
+	return len(steps) > 1 && standaloneSteps(steps)
+}
+
+// joinedCommand is the generated command with its steps joined as chosen.
+// Joined by newlines it's left exactly as generated, keeping indentation.
+func (m model) joinedCommand() string {
+	if m.canJoin() && m.joinMode != joinNewline {
+		return joinSteps(m.steps(), m.joinMode)
+	}
+	return m.generatedCmd
+}
+
+func (m model) executeCommand() tea.Cmd {
+	command := m.joinedCommand()
+	// Only the copy is encoded; the screen keeps showing the readable command
+	if m.opts.urlEncode {
+		command = toShareLink(command)
+	}
+	return m.copyCommand(command)
+}
+
+// copyCommand copies the given command to the clipboard
+func (m model) copyCommand(command string) tea.Cmd {
+	// Write to each requested selection, keeping whichever succeeded
+	if targets := m.opts.clipboardTargets; len(targets) > 0 {
+		return func() tea.Msg {
+			copied, err := copyToTargets(runtime.GOOS, command, targets)
+			if len(copied) == 0 {
+				return cmdCopiedMsg{err: err}
+			}
+			return cmdCopiedMsg{cmd: command, targets: copied, err: err}
+		}
+	}
+
+	// The terminal can reach the clipboard of the machine it runs on
+	if m.opts.osc52 {
+		return func() tea.Msg {
+			if err := copyWithOSC52(command, os.Getenv); err != nil {
+				return cmdCopiedMsg{cmd: command, err: err, printed: true}
+			}
+			return cmdCopiedMsg{cmd: command, targets: []string{osc52Target}}
+		}
+	}
+
+	// With nowhere to copy to, the command is printed for copying by hand
+	if m.noClipboard {
+		return func() tea.Msg {
+			return cmdCopiedMsg{cmd: command, printed: true}
+		}
+	}
+
+	return func() tea.Msg {
+		// Copy command to clipboard
+		if err := copyToClipboard(command); err != nil {
+			return cmdCopiedMsg{cmd: command, err: err, printed: true}
+		}
+
+		// Return success message with the copied command
+		return cmdCopiedMsg{cmd: command, err: nil}
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// clipboardWriteAll writes to the system clipboard; replaced in tests
+var clipboardWriteAll = clipboard.WriteAll
+
+// clipboardReadAll reads the system clipboard; replaced in tests
+var clipboardReadAll = clipboard.ReadAll
+
+// errClipboardUnavailable is reported when a copy seemed to work but the
+// clipboard doesn't hold the command, as happens on some headless Linux setups
+var errClipboardUnavailable = errors.New("the clipboard isn't available, as it didn't keep the command; install xclip or xsel, or use --print to print the command instead")
+
+// copyToClipboard copies the command to the clipboard, reading it back to
+// check the copy took
+func copyToClipboard(command string) error {
+	if err := clipboardWriteAll(command); err != nil {
+		return err
+	}
+	got, err := clipboardReadAll()
+	if err != nil || normalizeClipboard(got) != normalizeClipboard(command) {
+		return errClipboardUnavailable
+	}
+	return nil
+}
+
+// normalizeClipboard evens out the line endings some clipboards convert to
+// or add, so they don't count as a failed copy
+func normalizeClipboard(text string) string {
+	return strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
+
+// seedFromClipboard replaces the prompt being typed with the clipboard's
+// text, leaving it untouched when the clipboard is empty or unreadable
+func (m model) seedFromClipboard() model {
+	m.clipboardErr = nil
+	text, err := clipboardReadAll()
+	if err != nil {
+		m.clipboardErr = err
+		return m
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		m.clipboardErr = errors.New("it's empty")
+		return m
+	}
+	m.textarea.SetValue(text)
+	m.textarea.CursorEnd()
+	return m
+}
+
+// envOptions controls which optional sections getEnvironmentInfo includes
+type envOptions struct {
+	systemStats    bool
+	toolVersions   []string
+	detectVersions bool
+	gitContext     bool
+	noEnv          bool
+	prompt         string
+}
+
+// envOptions derives the environment info options from the model's settings
+func (m model) envOptions() envOptions {
+	return envOptions{
+		systemStats:    m.opts.systemStats,
+		toolVersions:   m.opts.toolVersions,
+		detectVersions: m.opts.detectVersions,
+		gitContext:     m.opts.gitContext,
+		noEnv:          m.opts.noEnv,
+		prompt:         m.prompt,
+	}
 }
 
 // getEnvironmentInfo gathers environment information for the LLM prompt
-func getEnvironmentInfo() string {
+func getEnvironmentInfo(opts envOptions) string {
 	var envInfo strings.Builder
 
 	// Get current shell
-	shell := os.Getenv("SHELL")
+	shellPath := os.Getenv("SHELL")
+	shell := shellPath
 	if shell == "" {
 		shell = "unknown"
+	} else if version := shellVersion(shellPath); version != "" {
+		// Syntax differs between versions, like bash 3.2 on macOS and bash 5
+		shell = fmt.Sprintf("%s (%s)", shellPath, version)
+	}
+	// A shell named in the prompt beats the one we're running under
+	if requested, ok := shellFromPrompt(opts.prompt); ok && requested != filepath.Base(shellPath) {
+		shell = fmt.Sprintf("%s (requested in the prompt; overrides the detected shell %s)", requested, shell)
 	}
 	envInfo.WriteString(fmt.Sprintf("Shell: %s\n", shell))
 
@@ -422,24 +1682,388 @@ func getEnvironmentInfo() string {
 	envInfo.WriteString(fmt.Sprintf("Platform: %s\n", runtime.GOOS))
 	envInfo.WriteString(fmt.Sprintf("Architecture: %s\n", runtime.GOARCH))
 
+	// Where file commands will run, and what's there
+	envInfo.WriteString(workingDirInfo())
+
+	// Whether sudo is needed depends on who we're running as
+	envInfo.WriteString(privilegeInfo(isElevated(), currentUsername()))
+	envInfo.WriteString("\n")
+
 	// Get environment variable keys (but not values for security)
-	envVars := os.Environ()
-	var envKeys []string
-	for _, env := range envVars {
-		if parts := strings.SplitN(env, "=", 2); len(parts) == 2 {
-			envKeys = append(envKeys, parts[0])
-		}
+	if !opts.noEnv {
+		envInfo.WriteString("Available environment variables: ")
+		envInfo.WriteString(strings.Join(envVarNames(os.Environ()), ", "))
 	}
 
-	// Sort environment variable keys for consistent output
-	sort.Strings(envKeys)
+	// Optional system stats for performance-related requests
+	if opts.systemStats {
+		envInfo.WriteString("\n")
+		envInfo.WriteString(getSystemStats())
+	}
 
-	envInfo.WriteString("Available environment variables: ")
-	envInfo.WriteString(strings.Join(envKeys, ", "))
+	// Tool versions so the model targets the right syntax
+	if hints := toolVersionHints(opts.toolVersions, opts.detectVersions, opts.prompt); hints != "" {
+		envInfo.WriteString("\n")
+		envInfo.WriteString(hints)
+	}
+
+	// Working tree changes for git-related requests
+	if opts.gitContext {
+		if git := gitContext(); git != "" {
+			envInfo.WriteString("\n")
+			envInfo.WriteString(git)
+		}
+	}
 
 	return envInfo.String()
 }
 
+// parseArgs parses the command-line arguments (excluding the program name).
+// Anything that isn't a recognized flag becomes part of the prompt.
+func parseArgs(args []string) (options, error) {
+	opts := options{retries: defaultRetries, retryDelay: defaultRetryDelay, timeout: defaultTimeout}
+	var promptArgs []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// Support both "--flag value" and "--flag=value"
+		var inlineValue *string
+		if strings.HasPrefix(arg, "--") {
+			if name, value, ok := strings.Cut(arg, "="); ok {
+				arg, inlineValue = name, &value
+			}
+		}
+		value := func() (string, error) {
+			if inlineValue != nil {
+				return *inlineValue, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("%s requires a value", arg)
+			}
+			i++
+			return args[i], nil
+		}
+
+		switch arg {
+		case "-v":
+			opts.verbose = true
+		case "--system-stats":
+			opts.systemStats = true
+		case "--no-env":
+			opts.noEnv = true
+		case "--yes":
+			opts.yes = true
+		case "--notify":
+			opts.notify = true
+		case "--with-undo":
+			opts.withUndo = true
+		case "--with-verify":
+			opts.withVerify = true
+		case "--url-encode":
+			opts.urlEncode = true
+		case "--ask-inputs":
+			opts.askInputs = true
+		case "--update-check":
+			opts.updateCheck = true
+		case "--no-update-check":
+			opts.noUpdateCheck = true
+		case "--widget":
+			opts.widget = true
+		case "--print":
+			opts.print = true
+		case "--json":
+			opts.jsonOutput = true
+		case "--no-highlight":
+			opts.noHighlight = true
+		case "--no-loading-hints":
+			opts.noLoadingHints = true
+		case "--dry-run":
+			opts.dryRun = true
+		case "-q", "--quiet":
+			opts.quiet = true
+		case "-x", "--execute":
+			opts.execute = true
+		case "--legacy-keys":
+			opts.legacyKeys = true
+		case "--from-clipboard":
+			opts.fromClipboard = true
+		case "--as-script":
+			opts.asScript = true
+		case "--single-line":
+			opts.singleLine = true
+		case "--always-fresh":
+			opts.alwaysFresh = true
+		case "--no-cache":
+			opts.noCache = true
+		case "--strict-confirm":
+			opts.strictConfirm = true
+		case "--tool-version":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			if tool, version, ok := strings.Cut(v, "="); !ok || tool == "" || version == "" {
+				return opts, fmt.Errorf("--tool-version must look like tool=version, got %q", v)
+			}
+			opts.toolVersions = append(opts.toolVersions, v)
+		case "--context":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			if strings.TrimSpace(v) == "" {
+				return opts, errors.New("--context needs some text")
+			}
+			opts.contexts = append(opts.contexts, strings.TrimSpace(v))
+		case "--exec-allow", "--exec-deny":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			if strings.TrimSpace(v) == "" {
+				return opts, fmt.Errorf("%s needs a non-empty pattern", arg)
+			}
+			if arg == "--exec-allow" {
+				opts.execAllow = append(opts.execAllow, v)
+			} else {
+				opts.execDeny = append(opts.execDeny, v)
+			}
+		case "--git-context":
+			opts.gitContext = true
+		case "--detect-versions":
+			opts.detectVersions = true
+		case "--osc52":
+			opts.osc52 = true
+		case "--clipboard-targets":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			if opts.clipboardTargets, err = parseClipboardTargets(v); err != nil {
+				return opts, err
+			}
+		case "--favorites":
+			opts.favorites = true
+		case "--history":
+			opts.history = defaultHistoryCount
+			switch {
+			case inlineValue != nil:
+				n, err := strconv.Atoi(*inlineValue)
+				if err != nil || n <= 0 {
+					return opts, fmt.Errorf("--history must be a positive number, got %q", *inlineValue)
+				}
+				opts.history = n
+			case i+1 < len(args):
+				// The count is optional, so only a number is taken as one
+				if n, err := strconv.Atoi(args[i+1]); err == nil && n > 0 {
+					opts.history = n
+					i++
+				}
+			}
+		case "--max-width":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return opts, fmt.Errorf("--max-width must be a positive number of columns, got %q", v)
+			}
+			opts.maxWidth = n
+		case "--count":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxCount {
+				return opts, fmt.Errorf("--count must be a number from 1 to %d, got %q", maxCount, v)
+			}
+			opts.count = n
+		case "--timeout":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			seconds, err := strconv.Atoi(v)
+			if err != nil || seconds < 0 {
+				return opts, fmt.Errorf("--timeout must be a number of seconds, or 0 for none, got %q", v)
+			}
+			opts.timeout = time.Duration(seconds) * time.Second
+		case "--retries":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return opts, fmt.Errorf("--retries must be zero or a positive number, got %q", v)
+			}
+			opts.retries = n
+		case "--retry-delay":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			ms, err := strconv.Atoi(v)
+			if err != nil || ms <= 0 {
+				return opts, fmt.Errorf("--retry-delay must be a positive number of milliseconds, got %q", v)
+			}
+			opts.retryDelay = time.Duration(ms) * time.Millisecond
+		case "--replay":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return opts, fmt.Errorf("--replay must be a positive number, got %q", v)
+			}
+			opts.replay = n
+		case "--provider":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			if !clippy.KnownProvider(v) {
+				return opts, fmt.Errorf("--provider must be %s, got %q", clippy.ProviderNames(), v)
+			}
+			opts.provider = v
+		case "--max-tokens":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return opts, fmt.Errorf("--max-tokens must be a positive number, got %q", v)
+			}
+			opts.maxTokens = n
+		case "--temperature":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			t, err := strconv.ParseFloat(v, 64)
+			if err != nil || math.IsNaN(t) {
+				return opts, fmt.Errorf("--temperature must be a number from 0 to 1, got %q", v)
+			}
+			opts.temperature = &t
+		case "--model":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			if v == "" {
+				return opts, errors.New("--model requires a model name")
+			}
+			opts.model = v
+		case "--comment-style":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			if _, ok := commentStyles[v]; !ok {
+				return opts, fmt.Errorf("--comment-style must be none, minimal, or verbose, got %q", v)
+			}
+			opts.commentStyle = v
+		case "--lang":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			if !langRe.MatchString(v) {
+				return opts, fmt.Errorf("--lang must be a language code like es or a name like Spanish, got %q", v)
+			}
+			opts.lang = v
+		case "--theme":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			if _, ok := themes[v]; !ok {
+				return opts, fmt.Errorf("--theme must be dark or light, got %q", v)
+			}
+			opts.theme = v
+		case "--theme-color":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			if _, _, err := parseThemeColor(v); err != nil {
+				return opts, err
+			}
+			opts.themeColors = append(opts.themeColors, v)
+		case "--idle-timeout":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			seconds, err := strconv.Atoi(v)
+			if err != nil || seconds <= 0 {
+				return opts, fmt.Errorf("--idle-timeout must be a positive number of seconds, got %q", v)
+			}
+			opts.idleTimeout = time.Duration(seconds) * time.Second
+		case "--export-make", "--export-just":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			if arg == "--export-make" {
+				opts.exportMake = v
+			} else {
+				opts.exportJust = v
+			}
+		case "--audit-log":
+			v, err := value()
+			if err != nil {
+				return opts, err
+			}
+			opts.auditLog = v
+		default:
+			promptArgs = append(promptArgs, args[i])
+		}
+	}
+
+	// "clippycli rules" on its own opens the rules screen
+	if len(promptArgs) == 1 && promptArgs[0] == "rules" {
+		opts.editRules = true
+		promptArgs = nil
+	}
+
+	// "clippycli daemon" on its own waits for the global hotkey
+	if len(promptArgs) == 1 && promptArgs[0] == "daemon" {
+		opts.daemon = true
+		promptArgs = nil
+	}
+
+	// Alternatives are single commands, with nowhere to put the extra fields
+	if opts.count > 1 && (opts.withUndo || opts.withVerify || opts.askInputs || opts.asScript) {
+		return opts, errors.New("--count can't be combined with --with-undo, --with-verify, --ask-inputs or --as-script")
+	}
+
+	if opts.jsonOutput && (opts.print || opts.widget) {
+		return opts, errors.New("--json can't be combined with --print or --widget")
+	}
+
+	if opts.singleLine && opts.asScript {
+		return opts, errors.New("--single-line can't be combined with --as-script")
+	}
+
+	if len(promptArgs) > 0 {
+		if opts.favorites {
+			return opts, errors.New("--favorites copies a saved command, so it can't be given a prompt")
+		}
+		if opts.replay > 0 {
+			return opts, errors.New("--replay takes its prompt from the history, so it can't be given one too")
+		}
+		opts.prompt = strings.Join(promptArgs, " ")
+	}
+
+	return opts, nil
+}
+
 func main() {
 	// Handle help flags
 	if len(os.Args) > 1 && (os.Args[1] == "--help" || os.Args[1] == "-h") {
@@ -451,49 +2075,274 @@ Usage:
 Examples:
   clippycli                           # Interactive mode
   clippycli "list all files"          # Quick mode with auto-generation
+  echo "list all files" | clippycli   # Read the prompt from stdin
   clippycli -v "find large files"     # Verbose mode showing full AI prompt
+  clippycli --system-stats "what is using my disk"
+  clippycli rules                     # Choose which system prompt rules are sent
+  clippycli install-widget [bash|zsh] # Print a Ctrl+G binding that fills in your command line
+  clippycli install-widget zsh --rc   # Load that binding from ~/.zshrc (or ~/.bashrc)
+  clippycli daemon                    # Stay running and pop up a prompt on a global hotkey
+  clippycli daemon trigger            # Pop up the daemon's prompt (bind this to a shortcut)
+  clippycli completion [bash|zsh|fish] # Print a completion script for the shell
 
 Options:
   -h, --help                          # Show this help message
+  -V, --version                       # Show the version, commit, and build date
   -v                                  # Verbose mode: show full prompt sent to AI
+  -x, --execute                       # Press Shift+R on the result to run the command
+  --system-stats                      # Include CPU, memory, and disk stats in the prompt
+  --notify                            # Show a desktop notification when the command is ready
+  --with-undo                         # Also generate a command that reverses the result
+  --with-verify                       # Also generate a command that checks the result worked
+  --ask-inputs                        # Prompt for values only you know, like a search pattern
+  --as-script                         # Generate a reusable script with argument parsing
+  --single-line                       # Ask for a one-line command, never several lines
+  --count N                           # Generate N alternative commands to choose from (max 10)
+  --comment-style STYLE               # none, minimal, or verbose comments in scripts
+  --lang LANG                         # Write explanations in LANG, e.g. es (default English)
+  --strict-confirm                    # Type the tool name to confirm dangerous commands
+  --yes                               # Copy the command without reviewing it, unless it's dangerous
+  --widget                            # Print only the command, for shell key bindings
+  --print                             # Print only the command to stdout, for scripts
+  --json                              # Print the command, model and tokens as JSON, for programs
+  --dry-run                           # Print the prompt that would be sent, without calling the API
+  -q, --quiet                         # Copy without printing the banner afterwards
+  --update-check                      # Check for a newer release at most once a day
+  --no-update-check                   # Don't check for a newer release this run
+  --provider NAME                     # Model provider: anthropic, ollama or openai
+  --model NAME                        # Model to use, e.g. haiku, sonnet or opus for Claude
+  --max-tokens N                      # Limit replies to N tokens (default 1024)
+  --temperature T                     # Sample at T, from 0 (most predictable) to 1
+  --max-width COLUMNS                 # Draw the UI at most COLUMNS wide (default 100)
+  --theme NAME                        # Color theme: dark (default) or light
+  --theme-color NAME=COLOR            # Override one theme color, e.g. help=#333333 (repeatable)
+  --no-highlight                      # Show the command without syntax highlighting
+  --no-loading-hints                  # Just say "Thinking..." while waiting, without rotating hints
+  --timeout SECONDS                   # Give up on a generation after SECONDS (default 30)
+  --retries N                         # Retry busy or failing API requests N times (default 3)
+  --retry-delay MS                    # Wait MS milliseconds before the first retry (default 500)
+  --legacy-keys                       # Quit on any unrecognized key in the result view
+  --from-clipboard                    # Start with the clipboard's text as the prompt
+  --idle-timeout SECONDS              # Quit without copying after SECONDS with no keypress
+  --export-make PATH                  # Write the steps as Makefile targets to PATH
+  --export-just PATH                  # Write the steps as justfile recipes to PATH
+  --audit-log PATH                    # Log overrides of safety warnings to PATH
+  --url-encode                        # Copy an explainshell.com share link instead
+  --clipboard-targets LIST            # Copy to each of primary,clipboard (X11/Wayland)
+  --osc52                             # Copy through the terminal (default over SSH)
+  --tool-version TOOL=VERSION         # Target a specific tool version (repeatable)
+  --context TEXT                      # Add TEXT to every prompt, e.g. "on the prod cluster" (repeatable)
+  --exec-allow PATTERN                # Only ever run commands matching PATTERN (repeatable)
+  --exec-deny PATTERN                 # Never run commands matching PATTERN (repeatable)
+  --detect-versions                   # Detect versions of tools mentioned in the prompt
+  --git-context                       # Include git status and a diff summary in the prompt
+  --no-env                            # Don't send environment variable names
+  --always-fresh                      # Always call the API, never reuse cached results
+  --no-cache                          # Call the API even if the same request was just made
+  --history [N]                       # Print the last N (default 20) generated commands
+  --favorites                         # Pick a favorite (saved with f) to copy, without the API
+  --replay N                          # Regenerate the Nth most recent prompt in the history
 
 Environment Variables:
-  ANTHROPIC_API_KEY                   # Required: Your Anthropic API key
+  ANTHROPIC_API_KEY                   # Your Anthropic API key (required by default)
+  OPENAI_API_KEY                      # Your OpenAI API key, for --provider openai
+  OPENAI_BASE_URL                     # Send OpenAI requests to a compatible server
+  OLLAMA_HOST                         # Ollama server, default http://localhost:11434
+  CLIPPY_PROVIDER                     # Default provider: anthropic, ollama or openai
+  CLIPPY_MODEL                        # Default model, e.g. haiku, sonnet or opus
+  CLIPPY_MAX_TOKENS                   # Default reply limit in tokens
+  CLIPPY_UPDATE_CHECK=1               # Check for a newer release at most once a day
+  NO_COLOR                            # Turn off colors, as when output isn't a terminal
 
 For more information, visit: https://github.com/benmyles/cliclippy
 `)
 		os.Exit(0)
 	}
 
-	// Check for API key
-	if os.Getenv("ANTHROPIC_API_KEY") == "" {
-		fmt.Fprintf(os.Stderr, "Error: ANTHROPIC_API_KEY environment variable is required\n")
-		fmt.Fprintf(os.Stderr, "Please set your Anthropic API key: export ANTHROPIC_API_KEY=your_key_here\n")
-		os.Exit(1)
+	// Printing the version needs neither the API nor a key
+	if len(os.Args) > 1 && (os.Args[1] == "--version" || os.Args[1] == "-V") {
+		fmt.Println(versionLine(debug.ReadBuildInfo))
+		os.Exit(0)
+	}
+
+	// Setting up the shell widget doesn't talk to the API
+	if len(os.Args) > 1 && os.Args[1] == "install-widget" {
+		os.Exit(runInstallWidget(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	// Completion scripts are static, built from the known flags
+	if len(os.Args) > 1 && os.Args[1] == "completion" {
+		os.Exit(runCompletion(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	// Triggering the daemon only pokes the one already running
+	if len(os.Args) == 3 && os.Args[1] == "daemon" && os.Args[2] == "trigger" {
+		if err := sendTrigger(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Fall back to ASCII borders and no emoji in non-UTF-8 locales
+	if !supportsUTF8() {
+		setGlyphs(asciiGlyphs)
+	}
+
+	// Leave escape codes out of logs and of terminals that asked for no color
+	plain := plainOutput(os.Getenv, os.Stdout)
+	if plain {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+
+	// Apply project defaults from the nearest .clippyrc, below explicit flags
+	args := os.Args[1:]
+	if cwd, err := os.Getwd(); err == nil {
+		if args, err = withDirDefaults(cwd, args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// User-wide defaults from config.toml sit below everything else
+	if path, err := defaultConfigPath(); err == nil {
+		if args, err = withConfigDefaults(path, args, os.Getenv); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
 	// Parse command-line arguments
-	var verbose bool
-	var initialPrompt string
-	var promptArgs []string
+	opts, err := parseArgs(args)
+	if err != nil {
+		exitWithError(opts, err)
+	}
 
-	for i := 1; i < len(os.Args); i++ {
-		arg := os.Args[i]
-		if arg == "-v" {
-			verbose = true
-		} else {
-			promptArgs = append(promptArgs, arg)
+	// Reading the history doesn't talk to the API
+	if opts.history > 0 {
+		path, err := defaultHistoryPath()
+		if err != nil {
+			exitWithError(opts, err)
 		}
+		os.Exit(runHistory(path, opts.history, os.Stdout, os.Stderr))
 	}
 
-	if len(promptArgs) > 0 {
-		initialPrompt = strings.Join(promptArgs, " ")
+	if opts.theme != "" || len(opts.themeColors) > 0 {
+		setTheme(resolveTheme(opts.theme, opts.themeColors))
+	}
+
+	// Check for the chosen provider's API key
+	if opts.provider, err = resolveProvider(opts.provider, os.Getenv); err != nil {
+		exitWithError(opts, err)
+	}
+
+	if opts.maxTokens, err = resolveMaxTokens(opts.maxTokens, os.Getenv); err != nil {
+		exitWithError(opts, err)
+	}
+
+	// Check the model before the TUI starts, so a typo isn't a failed request
+	if opts.model == "" {
+		opts.model = os.Getenv(modelEnv)
+	}
+	if opts.model, err = clippy.ResolveModel(opts.provider, opts.model); err != nil {
+		exitWithError(opts, err)
+	}
+
+	// Local providers don't need one, and nor does a dry run or copying a favorite
+	if keyEnv := clippy.KeyEnv(opts.provider); keyEnv != "" && os.Getenv(keyEnv) == "" && !opts.dryRun && !opts.favorites {
+		if opts.jsonOutput {
+			exitWithError(opts, fmt.Errorf("%s environment variable is required for the %s provider", keyEnv, opts.provider))
+		}
+		fmt.Fprintf(os.Stderr, "Error: %s environment variable is required for the %s provider\n", keyEnv, opts.provider)
+		fmt.Fprintf(os.Stderr, "Please set your API key: export %s=your_key_here\n", keyEnv)
+		os.Exit(1)
+	}
+
+	// Replaying regenerates an earlier prompt, still open to editing
+	if opts.replay > 0 {
+		path, err := defaultHistoryPath()
+		if err == nil {
+			opts.prompt, err = replayPrompt(path, opts.replay)
+		}
+		if err != nil {
+			exitWithError(opts, err)
+		}
+	}
+
+	// A prompt piped in works like one given as an argument
+	piped := opts.prompt == "" && !opts.daemon && !opts.editRules && !opts.favorites && isRedirected(os.Stdin)
+	if piped {
+		if opts.prompt, err = readStdinPrompt(os.Stdin); err != nil {
+			exitWithError(opts, err)
+		}
+	}
+
+	// Load which system prompt rules the user has turned off
+	m := newModel(opts)
+	if path, err := defaultRulesPath(); err == nil {
+		m.rulesPath = path
+		if m.disabledRules, err = loadDisabledRules(path); err != nil {
+			exitWithError(opts, err)
+		}
+	}
+
+	if path, err := defaultHistoryPath(); err == nil {
+		m.historyPath = path
+	}
+	if path, err := defaultReplyCachePath(); err == nil {
+		m.replyCachePath = path
+	}
+
+	if path, err := defaultFavoritesPath(); err == nil {
+		m.favoritesPath = path
+	}
+	if opts.favorites {
+		if m.favorites, err = loadFavorites(m.favoritesPath); err != nil {
+			exitWithError(opts, err)
+		}
+		if len(m.favorites) == 0 {
+			fmt.Println("No favorites yet. Press f on a generated command to save one.")
+			os.Exit(0)
+		}
 	}
 
-	p := tea.NewProgram(
-		initialModel(initialPrompt, verbose),
-		tea.WithAltScreen(),
-	)
+	// Over SSH or on a bare server there may be no clipboard to copy to
+	m.opts.osc52 = useOSC52(m.opts.osc52, os.Getenv)
+	m.noClipboard = clipboard.Unsupported && len(m.opts.clipboardTargets) == 0 && !m.opts.osc52
+
+	// Checking for updates is opt-in, with the flag, config key or variable
+	if os.Getenv(updateCheckEnv) == "1" {
+		m.opts.updateCheck = true
+	}
+	if path, err := defaultUpdateStatePath(); err == nil {
+		m.updateStatePath = path
+	}
+
+	// A dry run never reaches the API, whatever mode it's combined with
+	if opts.dryRun {
+		os.Exit(runDryRun(m, os.Stdout, os.Stderr))
+	}
+
+	// Shell widgets want just the command, not the TUI
+	if opts.widget {
+		os.Exit(runWidget(m, os.Stdout, os.Stderr))
+	}
+	if opts.print {
+		os.Exit(runPrint(m, os.Stdout, os.Stderr))
+	}
+	if opts.jsonOutput {
+		os.Exit(runJSON(m, os.Stdout))
+	}
+	if opts.daemon {
+		os.Exit(runDaemon(m, os.Stdout, os.Stderr))
+	}
+
+	programOpts := []tea.ProgramOption{tea.WithAltScreen()}
+	// Stdin was used up by the prompt, so keys come from the terminal
+	if piped {
+		programOpts = append(programOpts, tea.WithInputTTY())
+	}
+	p := tea.NewProgram(m, programOpts...)
 
 	finalModel, err := p.Run()
 	if err != nil {
@@ -501,29 +2350,13 @@ For more information, visit: https://github.com/benmyles/cliclippy
 		os.Exit(1)
 	}
 
+	// Run the command now the terminal is back to normal
+	if m, ok := finalModel.(model); ok && m.runCmd != "" {
+		os.Exit(runInShell(shellCommand(context.Background(), runtime.GOOS, os.Getenv("SHELL"), m.runCmd), os.Stdin, os.Stdout, os.Stderr))
+	}
+
 	// Show the actual command that was copied to clipboard with styling
 	if m, ok := finalModel.(model); ok && m.copiedCmd != "" {
-		// Print styled success message
-		successHeader := lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#059669")).
-			Render("✓ Command copied to clipboard:")
-
-		commandDisplay := lipgloss.NewStyle().
-			Background(lipgloss.Color("#1F2937")).
-			Foreground(lipgloss.Color("#F9FAFB")).
-			Padding(0, 1).
-			MarginTop(1).
-			MarginBottom(1).
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("#6B7280")).
-			Render(m.copiedCmd)
-
-		helpText := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#6B7280")).
-			Italic(true).
-			Render("Paste with Ctrl+V (or Cmd+V on macOS)")
-
-		fmt.Printf("\n%s\n%s\n%s\n\n", successHeader, commandDisplay, helpText)
+		m.printCopied(plain, os.Stdout, os.Stderr)
 	}
 }
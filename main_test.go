@@ -43,8 +43,8 @@ func TestInitialModel(t *testing.T) {
 	}
 
 	// Test that anthropic client is initialized
-	if model.anthropicClient == nil {
-		t.Error("Expected anthropic client to be initialized")
+	if model.generator == nil {
+		t.Error("Expected a generator to be initialized")
 	}
 
 	// Test that verbose is set correctly
@@ -73,8 +73,8 @@ func TestInitialModelWithPrompt(t *testing.T) {
 	}
 
 	// Test that anthropic client is initialized
-	if model.anthropicClient == nil {
-		t.Error("Expected anthropic client to be initialized")
+	if model.generator == nil {
+		t.Error("Expected a generator to be initialized")
 	}
 }
 
@@ -191,3 +191,88 @@ func TestFullPromptStorage(t *testing.T) {
 		t.Fatal("Expected updatedModel to be of type model")
 	}
 }
+
+func TestParseArgs(t *testing.T) {
+	opts, err := parseArgs([]string{"-v", "--system-stats", "--notify", "find", "large files"})
+	if err != nil {
+		t.Fatalf("parseArgs failed: %v", err)
+	}
+
+	if !opts.verbose {
+		t.Error("Expected verbose to be true")
+	}
+
+	if !opts.systemStats {
+		t.Error("Expected systemStats to be true")
+	}
+
+	if !opts.notify {
+		t.Error("Expected notify to be true")
+	}
+
+	if opts.prompt != "find large files" {
+		t.Errorf("Expected prompt to be %q, got %q", "find large files", opts.prompt)
+	}
+}
+
+// stubClipboard replaces the clipboard with one in memory for the duration
+// of a test and returns a pointer to the last value written
+func stubClipboard(t *testing.T) *string {
+	t.Helper()
+
+	var written string
+	orig := clipboardWriteAll
+	clipboardWriteAll = func(text string) error {
+		written = text
+		return nil
+	}
+	origRead := clipboardReadAll
+	clipboardReadAll = func() (string, error) { return written, nil }
+	t.Cleanup(func() {
+		clipboardWriteAll = orig
+		clipboardReadAll = origRead
+	})
+
+	return &written
+}
+
+func TestParseArgsValues(t *testing.T) {
+	// Values can follow the flag or be attached with =
+	for _, args := range [][]string{
+		{"--audit-log", "/tmp/audit.jsonl", "list files"},
+		{"--audit-log=/tmp/audit.jsonl", "list files"},
+	} {
+		opts, err := parseArgs(args)
+		if err != nil {
+			t.Fatalf("parseArgs(%q) failed: %v", args, err)
+		}
+		if opts.auditLog != "/tmp/audit.jsonl" {
+			t.Errorf("Expected auditLog to be %q, got %q", "/tmp/audit.jsonl", opts.auditLog)
+		}
+		if opts.prompt != "list files" {
+			t.Errorf("Expected prompt to be %q, got %q", "list files", opts.prompt)
+		}
+	}
+
+	// A missing value is an error
+	if _, err := parseArgs([]string{"--audit-log"}); err == nil {
+		t.Error("Expected an error when --audit-log has no value")
+	}
+}
+
+func TestParseArgsToolVersion(t *testing.T) {
+	opts, err := parseArgs([]string{"--tool-version", "docker=20.10", "--tool-version=git=2.30", "--detect-versions"})
+	if err != nil {
+		t.Fatalf("parseArgs failed: %v", err)
+	}
+	if len(opts.toolVersions) != 2 || opts.toolVersions[1] != "git=2.30" {
+		t.Errorf("Expected two tool version hints, got %q", opts.toolVersions)
+	}
+	if !opts.detectVersions {
+		t.Error("Expected detectVersions to be true")
+	}
+
+	if _, err := parseArgs([]string{"--tool-version", "docker"}); err == nil {
+		t.Error("Expected an error for a hint without a version")
+	}
+}
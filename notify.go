@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runNotifyCmd runs a notification command; replaced in tests
+var runNotifyCmd = func(cmd *exec.Cmd) error {
+	return cmd.Run()
+}
+
+// notifyCommand builds the command that shows a desktop notification on goos,
+// or nil if the platform isn't supported
+func notifyCommand(goos, title, body string) *exec.Cmd {
+	switch goos {
+	case "darwin":
+		script := "display notification " + appleScriptString(body) + " with title " + appleScriptString(title)
+		return exec.Command("osascript", "-e", script)
+	case "windows":
+		script := `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode(` + powerShellString(title) + `)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode(` + powerShellString(body) + `)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('ClippyCLI').Show([Windows.UI.Notifications.ToastNotification]::new($template))`
+		return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.Command("notify-send", "--app-name=ClippyCLI", title, body)
+	}
+	return nil
+}
+
+// notify shows a desktop notification, failing silently if notifications
+// aren't available
+func notify(title, body string) {
+	cmd := notifyCommand(runtime.GOOS, title, body)
+	if cmd == nil {
+		return
+	}
+	_ = runNotifyCmd(cmd)
+}
+
+// notifyCmd wraps notify in a tea.Cmd so it doesn't block the UI
+func notifyCmd(title, body string) tea.Cmd {
+	return func() tea.Msg {
+		notify(title, body)
+		return nil
+	}
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// powerShellString quotes s as a single-quoted PowerShell string literal
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNotifyCommand(t *testing.T) {
+	tests := []struct {
+		goos     string
+		program  string
+		contains []string
+	}{
+		{"darwin", "osascript", []string{`display notification "ls \"a b\""`, `with title "Command ready"`}},
+		{"linux", "notify-send", []string{"Command ready", `ls "a b"`}},
+		{"windows", "powershell", []string{"ToastNotificationManager", `'Command ready'`, `'ls "a b"'`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			cmd := notifyCommand(tt.goos, "Command ready", `ls "a b"`)
+			if cmd == nil {
+				t.Fatalf("Expected a notification command for %s", tt.goos)
+			}
+
+			if cmd.Args[0] != tt.program {
+				t.Errorf("Expected program %q, got %q", tt.program, cmd.Args[0])
+			}
+
+			args := strings.Join(cmd.Args, " ")
+			for _, want := range tt.contains {
+				if !strings.Contains(args, want) {
+					t.Errorf("Expected args to contain %q, got %q", want, args)
+				}
+			}
+		})
+	}
+
+	// Unsupported platforms should get no command
+	if cmd := notifyCommand("plan9", "title", "body"); cmd != nil {
+		t.Error("Expected no notification command for an unsupported platform")
+	}
+}
+
+func TestNotifyUsesStub(t *testing.T) {
+	if notifyCommand(runtime.GOOS, "t", "b") == nil {
+		t.Skip("notifications not supported on this platform")
+	}
+
+	var ran *exec.Cmd
+	orig := runNotifyCmd
+	runNotifyCmd = func(cmd *exec.Cmd) error {
+		ran = cmd
+		return exec.ErrNotFound
+	}
+	defer func() { runNotifyCmd = orig }()
+
+	// Failures should be swallowed silently
+	notify("Command ready", "ls -la")
+
+	if ran == nil {
+		t.Fatal("Expected notify to run the notification command")
+	}
+}
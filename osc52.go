@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/base64"
+	"io"
+	"os"
+	"strings"
+)
+
+// osc52Target is how a copy through the terminal is named in the banner
+const osc52Target = "terminal clipboard"
+
+// osc52Writer is where the escape sequence goes; replaced in tests
+var osc52Writer io.Writer = os.Stdout
+
+// useOSC52 reports whether to copy through the terminal rather than the
+// system clipboard: when asked with --osc52, or over SSH, where the system
+// clipboard is on another machine
+func useOSC52(flag bool, getenv func(string) string) bool {
+	return flag || getenv("SSH_TTY") != ""
+}
+
+// osc52Sequence returns the escape sequence asking the terminal to put text
+// on its clipboard. Inside tmux it's wrapped so tmux passes it on to the
+// outer terminal rather than swallowing it.
+func osc52Sequence(text string, tmux bool) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if tmux {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
+
+// copyWithOSC52 copies text through the terminal. There's no reading it back,
+// so whether it arrived depends on the terminal allowing it.
+func copyWithOSC52(text string, getenv func(string) string) error {
+	_, err := io.WriteString(osc52Writer, osc52Sequence(text, getenv("TMUX") != ""))
+	return err
+}
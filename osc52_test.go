@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestOSC52Sequence(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("ls -la | grep 'é'"))
+	if got, want := osc52Sequence("ls -la | grep 'é'", false), "\x1b]52;c;"+encoded+"\a"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// tmux passes it on when wrapped, with its escapes doubled
+	if got, want := osc52Sequence("ls", true), "\x1bPtmux;\x1b\x1b]52;c;bHM=\a\x1b\\"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestUseOSC52(t *testing.T) {
+	if useOSC52(false, noEnv) {
+		t.Error("Expected the system clipboard by default")
+	}
+	if !useOSC52(true, noEnv) {
+		t.Error("Expected --osc52 to turn it on")
+	}
+	env := map[string]string{"SSH_TTY": "/dev/pts/0"}
+	if !useOSC52(false, func(k string) string { return env[k] }) {
+		t.Error("Expected it to be used over SSH")
+	}
+}
+
+func TestCopyWithOSC52(t *testing.T) {
+	written := stubClipboard(t)
+	var out bytes.Buffer
+	orig := osc52Writer
+	osc52Writer = &out
+	t.Cleanup(func() { osc52Writer = orig })
+
+	m := newModel(options{osc52: true})
+	m.generatedCmd = "ls -la"
+	msg := m.executeCommand()().(cmdCopiedMsg)
+	if msg.err != nil || msg.cmd != "ls -la" || *written != "" {
+		t.Fatalf("Expected the copy to go through the terminal, got %+v", msg)
+	}
+	if !strings.Contains(out.String(), base64.StdEncoding.EncodeToString([]byte("ls -la"))) {
+		t.Errorf("Expected the encoded command to be written, got %q", out.String())
+	}
+
+	updated, _ := m.Update(msg)
+	if banner := updated.(model).copiedBanner(true); !strings.Contains(banner, "copied to terminal clipboard:") {
+		t.Errorf("Expected the banner to say where it went, got %q", banner)
+	}
+}
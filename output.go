@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// noColorEnv turns styling off when set to anything, see https://no-color.org
+const noColorEnv = "NO_COLOR"
+
+// plainOutput reports whether output should be left unstyled: NO_COLOR is
+// set, or stdout is going to a file or pipe rather than a terminal
+func plainOutput(getenv func(string) string, stdout *os.File) bool {
+	return getenv(noColorEnv) != "" || isRedirected(stdout)
+}
+
+// copiedBanner is printed once the TUI exits to show what was copied. When
+// plain, it's bare text suited to logging.
+func (m model) copiedBanner(plain bool) string {
+	// A box's border would get in the way of selecting the command by hand
+	if m.copyFailed {
+		header := "No clipboard available, so copy the command from here:"
+		if !plain {
+			header = lipgloss.NewStyle().Bold(true).Foreground(colors.prompt).Render(header)
+		}
+		return fmt.Sprintf("\n%s\n\n%s\n\n", header, m.copiedCmd)
+	}
+
+	destination := "clipboard"
+	if len(m.copiedTargets) > 0 {
+		destination = strings.Join(m.copiedTargets, " and ")
+	}
+	what := "Command"
+	if m.opts.urlEncode {
+		what = "Share link"
+	}
+	header := glyphs.check + " " + what + " copied to " + destination + ":"
+	help := "Paste with Ctrl+V (or Cmd+V on macOS)"
+	if plain {
+		return fmt.Sprintf("\n%s\n\n%s\n\n%s\n\n", header, m.copiedCmd, help)
+	}
+
+	successHeader := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colors.prompt).
+		Render(header)
+
+	commandDisplay := lipgloss.NewStyle().
+		Background(colors.commandBackground).
+		Foreground(colors.command).
+		Padding(0, 1).
+		MarginTop(1).
+		MarginBottom(1).
+		Border(glyphs.border).
+		BorderForeground(colors.border).
+		Render(m.copiedCmd)
+
+	helpText := lipgloss.NewStyle().
+		Foreground(colors.help).
+		Italic(true).
+		Render(help)
+
+	return fmt.Sprintf("\n%s\n%s\n%s\n\n", successHeader, commandDisplay, helpText)
+}
+
+// printCopied reports what was copied once the TUI exits. With --quiet the
+// banner is left out, and a command that wasn't run is noted on stderr instead.
+// A command that couldn't be copied is printed alone.
+func (m model) printCopied(plain bool, stdout, stderr io.Writer) {
+	switch {
+	case m.opts.quiet && m.copyFailed:
+		fmt.Fprintln(stdout, m.copiedCmd)
+	case !m.opts.quiet:
+		fmt.Fprint(stdout, m.copiedBanner(plain))
+	}
+	if m.runRefused == "" {
+		return
+	}
+	if m.opts.quiet {
+		fmt.Fprintf(stderr, "Not run because %s.\n", m.runRefused)
+	} else {
+		fmt.Fprintf(stdout, "Not run because %s.\n\n", m.runRefused)
+	}
+}
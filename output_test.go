@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlainOutput(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if !plainOutput(noEnv, f) {
+		t.Error("Expected output redirected to a file to be plain")
+	}
+	env := map[string]string{noColorEnv: "1"}
+	if !plainOutput(func(k string) string { return env[k] }, f) {
+		t.Errorf("Expected $%s to turn styling off", noColorEnv)
+	}
+}
+
+func TestCopiedBannerPlain(t *testing.T) {
+	m := newModel(options{})
+	m.copiedCmd = "ls -la"
+	m.copiedTargets = []string{"primary", "clipboard"}
+
+	banner := m.copiedBanner(true)
+	if strings.Contains(banner, "\x1b[") || strings.Contains(banner, glyphs.border.Left) {
+		t.Errorf("Expected no escape codes or border, got %q", banner)
+	}
+	if !strings.Contains(banner, "copied to primary and clipboard:\n\nls -la\n\n") {
+		t.Errorf("Expected the header and the bare command, got %q", banner)
+	}
+	if !strings.Contains(m.copiedBanner(false), glyphs.border.Left) {
+		t.Error("Expected the command to be boxed when styled")
+	}
+}
+
+func TestPrintCopiedQuiet(t *testing.T) {
+	m := newModel(options{quiet: true})
+	m.copiedCmd = "rm -rf build"
+	m.runRefused = "it recursively force-deletes files"
+
+	var stdout, stderr bytes.Buffer
+	m.printCopied(true, &stdout, &stderr)
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no banner, got %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Not run because it recursively force-deletes files") {
+		t.Errorf("Expected the refusal on stderr, got %q", stderr.String())
+	}
+
+	m.opts.quiet = false
+	stdout.Reset()
+	m.printCopied(true, &stdout, &stderr)
+	if !strings.Contains(stdout.String(), "rm -rf build") || !strings.Contains(stdout.String(), "Not run because") {
+		t.Errorf("Expected the banner and refusal without --quiet, got %q", stdout.String())
+	}
+}
+
+func TestParseArgsQuiet(t *testing.T) {
+	for _, flag := range []string{"-q", "--quiet"} {
+		if opts, err := parseArgs([]string{flag, "list files"}); err != nil || !opts.quiet || opts.prompt != "list files" {
+			t.Errorf("Expected %s to set quiet, got %+v (err %v)", flag, opts.quiet, err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pagerClosedMsg is sent when the user exits the pager
+type pagerClosedMsg struct {
+	err error
+}
+
+// pagerViewMsg asks for content to be shown in the built-in pager, when
+// there's no external one to use
+type pagerViewMsg struct {
+	content string
+}
+
+// errNoPager is returned when $PAGER isn't set and neither less nor more is
+// installed
+var errNoPager = errors.New("no pager found; set $PAGER")
+
+// pagerCommand builds a command that shows content in the user's $PAGER,
+// falling back to less or more when it isn't set
+func pagerCommand(content string) (*exec.Cmd, error) {
+	args := strings.Fields(os.Getenv("PAGER"))
+	if len(args) == 0 {
+		for _, pager := range []string{"less", "more"} {
+			if toolAvailable(pager) {
+				args = []string{pager}
+				break
+			}
+		}
+	}
+	if len(args) == 0 {
+		return nil, errNoPager
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(content)
+	return cmd, nil
+}
+
+// openPager suspends the TUI while the generated command is shown in a pager
+func (m model) openPager() tea.Cmd {
+	return showInPager(m.generatedCmd + "\n")
+}
+
+// showInPager suspends the TUI while content is shown in a pager, or shows
+// it in the built-in one when there's no pager to run
+func showInPager(content string) tea.Cmd {
+	cmd, err := pagerCommand(content)
+	if errors.Is(err, errNoPager) {
+		return func() tea.Msg { return pagerViewMsg{content: content} }
+	}
+	if err != nil {
+		return func() tea.Msg { return pagerClosedMsg{err: err} }
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return pagerClosedMsg{err: err}
+	})
+}
+
+// openBuiltinPager shows content in a scrolling view sized to the terminal
+func (m model) openBuiltinPager(content string) model {
+	m.state = statePager
+	m.pagerErr = nil
+	m.outputView = viewport.New(m.outputWidth(), m.outputHeight())
+	m.outputView.SetContent(strings.TrimRight(content, "\n"))
+	return m
+}
+
+// pagerScreen is the built-in pager
+func (m model) pagerScreen() string {
+	var content strings.Builder
+	content.WriteString(m.outputView.View())
+	content.WriteString("\n\n")
+	content.WriteString(m.helpFooter())
+	return content.String()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPagerCommandFromEnv(t *testing.T) {
+	t.Setenv("PAGER", "less -R")
+
+	cmd, err := pagerCommand("ls -la\n")
+	if err != nil {
+		t.Fatalf("pagerCommand failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(cmd.Args, []string{"less", "-R"}) {
+		t.Errorf("Expected pager args [less -R], got %q", cmd.Args)
+	}
+
+	// The command is piped in unchanged
+	input, err := io.ReadAll(cmd.Stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(input) != "ls -la\n" {
+		t.Errorf("Expected the raw command on stdin, got %q", input)
+	}
+}
+
+func TestPagerCommandFallback(t *testing.T) {
+	t.Setenv("PAGER", "")
+
+	fakePath(t, "more")
+	cmd, err := pagerCommand("ls")
+	if err != nil {
+		t.Fatalf("pagerCommand failed: %v", err)
+	}
+	if !strings.HasSuffix(cmd.Args[0], "more") {
+		t.Errorf("Expected to fall back to more, got %q", cmd.Args[0])
+	}
+
+	fakePath(t)
+	if _, err := pagerCommand("ls"); err == nil {
+		t.Error("Expected an error when no pager is available")
+	}
+}
+
+func TestBuiltinPagerFallback(t *testing.T) {
+	t.Setenv("PAGER", "")
+	fakePath(t)
+
+	m := initialModel("list files", false)
+	updatedModel, _ := m.Update(cmdGeneratedMsg{cmd: "ls -la"})
+	var msgs []tea.Msg
+	for _, msg := range collectMsgs(showInPager("first line\nsecond line\n")) {
+		msgs = append(msgs, msg)
+		updatedModel, _ = updatedModel.Update(msg)
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Expected one message, got %v", msgs)
+	}
+	if _, ok := msgs[0].(pagerViewMsg); !ok {
+		t.Fatalf("Expected the built-in pager without a pager installed, got %T", msgs[0])
+	}
+
+	m = updatedModel.(model)
+	if m.state != statePager || m.pagerErr != nil {
+		t.Fatalf("Expected the built-in pager to open, got state %d, %v", m.state, m.pagerErr)
+	}
+	view := m.View()
+	if !strings.Contains(view, "first line") || !strings.Contains(view, "second line") {
+		t.Errorf("Expected the content in the built-in pager, got %q", view)
+	}
+
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if updatedModel.(model).state != stateResult {
+		t.Error("Expected q to close the built-in pager")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// appName names ClippyCLI's directory inside each per-user location
+const appName = "clippycli"
+
+// dirKind is a class of per-user file, which each OS keeps in its own place
+type dirKind int
+
+const (
+	configKind dirKind = iota // Settings the user may edit, like rules and favorites
+	dataKind                  // State worth keeping, like history
+	cacheKind                 // Anything that's safe to delete
+)
+
+// configDir returns the directory for ClippyCLI's settings
+func configDir() (string, error) {
+	return appDir(configKind, runtime.GOOS, os.Getenv, os.UserHomeDir)
+}
+
+// dataDir returns the directory for ClippyCLI's saved state
+func dataDir() (string, error) {
+	return appDir(dataKind, runtime.GOOS, os.Getenv, os.UserHomeDir)
+}
+
+// cacheDir returns the directory for ClippyCLI's caches
+func cacheDir() (string, error) {
+	return appDir(cacheKind, runtime.GOOS, os.Getenv, os.UserHomeDir)
+}
+
+// appDir resolves where files of the given kind live on goos:
+//
+//   - Windows: %AppData% for settings, %LocalAppData% for data and caches
+//   - macOS: ~/Library/Application Support, or ~/Library/Caches for caches
+//   - Elsewhere: $XDG_CONFIG_HOME, $XDG_DATA_HOME and $XDG_CACHE_HOME, falling
+//     back to ~/.config, ~/.local/share and ~/.cache
+func appDir(kind dirKind, goos string, getenv func(string) string, home func() (string, error)) (string, error) {
+	switch goos {
+	case "windows":
+		name := "LocalAppData"
+		if kind == configKind {
+			name = "AppData"
+		}
+		dir := getenv(name)
+		if dir == "" {
+			return "", errors.New("%" + name + "% is not set")
+		}
+		if kind == cacheKind {
+			return filepath.Join(dir, appName, "cache"), nil
+		}
+		return filepath.Join(dir, appName), nil
+
+	case "darwin", "ios":
+		h, err := home()
+		if err != nil {
+			return "", err
+		}
+		if kind == cacheKind {
+			return filepath.Join(h, "Library", "Caches", appName), nil
+		}
+		return filepath.Join(h, "Library", "Application Support", appName), nil
+	}
+
+	env, fallback := "XDG_CONFIG_HOME", ".config"
+	switch kind {
+	case dataKind:
+		env, fallback = "XDG_DATA_HOME", filepath.Join(".local", "share")
+	case cacheKind:
+		env, fallback = "XDG_CACHE_HOME", ".cache"
+	}
+	// The spec says relative paths are invalid and should be ignored
+	if dir := getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName), nil
+	}
+	h, err := home()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(h, fallback, appName), nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppDir(t *testing.T) {
+	home := func() (string, error) { return "/home/ada", nil }
+	env := func(vars map[string]string) func(string) string {
+		return func(name string) string { return vars[name] }
+	}
+
+	tests := []struct {
+		name     string
+		kind     dirKind
+		goos     string
+		vars     map[string]string
+		expected string
+	}{
+		{"linux config", configKind, "linux", nil, "/home/ada/.config/clippycli"},
+		{"linux data", dataKind, "linux", nil, "/home/ada/.local/share/clippycli"},
+		{"linux cache", cacheKind, "linux", nil, "/home/ada/.cache/clippycli"},
+		{"xdg config", configKind, "linux", map[string]string{"XDG_CONFIG_HOME": "/xdg/config"}, "/xdg/config/clippycli"},
+		{"xdg data", dataKind, "freebsd", map[string]string{"XDG_DATA_HOME": "/xdg/data"}, "/xdg/data/clippycli"},
+		{"xdg cache", cacheKind, "linux", map[string]string{"XDG_CACHE_HOME": "/xdg/cache"}, "/xdg/cache/clippycli"},
+		{"relative xdg ignored", configKind, "linux", map[string]string{"XDG_CONFIG_HOME": "config"}, "/home/ada/.config/clippycli"},
+		{"macos config", configKind, "darwin", nil, "/home/ada/Library/Application Support/clippycli"},
+		{"macos data", dataKind, "darwin", nil, "/home/ada/Library/Application Support/clippycli"},
+		{"macos cache", cacheKind, "darwin", nil, "/home/ada/Library/Caches/clippycli"},
+		{"macos ignores xdg", configKind, "darwin", map[string]string{"XDG_CONFIG_HOME": "/xdg/config"}, "/home/ada/Library/Application Support/clippycli"},
+		{"windows config", configKind, "windows", map[string]string{"AppData": "/Users/ada/AppData/Roaming", "LocalAppData": "/Users/ada/AppData/Local"}, "/Users/ada/AppData/Roaming/clippycli"},
+		{"windows data", dataKind, "windows", map[string]string{"AppData": "/Users/ada/AppData/Roaming", "LocalAppData": "/Users/ada/AppData/Local"}, "/Users/ada/AppData/Local/clippycli"},
+		{"windows cache", cacheKind, "windows", map[string]string{"LocalAppData": "/Users/ada/AppData/Local"}, "/Users/ada/AppData/Local/clippycli/cache"},
+	}
+
+	for _, tt := range tests {
+		result, err := appDir(tt.kind, tt.goos, env(tt.vars), home)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if result != filepath.FromSlash(tt.expected) {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, result)
+		}
+	}
+}
+
+func TestAppDirErrors(t *testing.T) {
+	noEnv := func(string) string { return "" }
+	noHome := func() (string, error) { return "", errors.New("no home") }
+
+	if _, err := appDir(configKind, "windows", noEnv, noHome); err == nil {
+		t.Error("Expected an error on Windows without %AppData%")
+	}
+	if _, err := appDir(dataKind, "linux", noEnv, noHome); err == nil {
+		t.Error("Expected an error without a home directory or XDG variable")
+	}
+	if _, err := appDir(dataKind, "linux", func(string) string { return "/xdg/data" }, noHome); err != nil {
+		t.Errorf("Expected an XDG variable to be enough without a home directory, got %v", err)
+	}
+}
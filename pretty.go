@@ -0,0 +1,95 @@
+package main
+
+import "strings"
+
+// prettyIndent is how far continuation lines are indented in the pretty view
+const prettyIndent = "  "
+
+// prettyFormat breaks a one-liner across lines after each top-level &&, ||,
+// pipe, and semicolon, indenting the continuation lines. Operators stay at
+// the end of their line, where the shell expects more input, so the result
+// runs the same as the original. Quoted strings and $(...) subshells are
+// never split.
+func prettyFormat(cmd string) string {
+	var lines []string
+	var current strings.Builder
+	var quote rune
+	escaped := false
+	depth := 0
+
+	breakAfter := func(op string) {
+		line := strings.TrimRight(current.String(), " \t")
+		if op == ";" || op == "" {
+			line += op
+		} else {
+			line += " " + op
+		}
+		lines = append(lines, line)
+		current.Reset()
+	}
+
+	runes := []rune(strings.TrimSpace(cmd))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth > 0:
+			// Inside a subshell, leave everything as it is
+		case r == '\n':
+			breakAfter("")
+			i = skipSpaces(runes, i)
+			continue
+		case r == '&' && i+1 < len(runes) && runes[i+1] == '&',
+			r == '|' && i+1 < len(runes) && runes[i+1] == '|':
+			breakAfter(string(runes[i : i+2]))
+			i = skipSpaces(runes, i+1)
+			continue
+		case r == ';' && i+1 < len(runes) && runes[i+1] == ';':
+			// ;; ends a case branch, so it's left alone
+			current.WriteString(";;")
+			i++
+			continue
+		case r == '|' || r == ';':
+			if i == len(runes)-1 {
+				break
+			}
+			breakAfter(string(r))
+			i = skipSpaces(runes, i)
+			continue
+		}
+		current.WriteRune(r)
+	}
+	lines = append(lines, current.String())
+
+	for i := 1; i < len(lines); i++ {
+		lines[i] = prettyIndent + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// skipSpaces returns the index of the last space or tab following runes[i]
+func skipSpaces(runes []rune, i int) int {
+	for i+1 < len(runes) && (runes[i+1] == ' ' || runes[i+1] == '\t') {
+		i++
+	}
+	return i
+}
+
+// canPrettyFormat reports whether the pretty view would differ from the raw
+// command. Scripts are already laid out across lines.
+func (m model) canPrettyFormat() bool {
+	return !m.opts.asScript && m.generatedCmd != "" && prettyFormat(m.generatedCmd) != m.generatedCmd
+}
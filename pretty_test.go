@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPrettyFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"single command", "ls -la", "ls -la"},
+		{"chained", "cd src && make build | tee build.log; echo done", "cd src &&\n  make build |\n  tee build.log;\n  echo done"},
+		{"or", "test -f x || touch x", "test -f x ||\n  touch x"},
+		{"quoted operators", `echo "a && b | c; d" && ls`, "echo \"a && b | c; d\" &&\n  ls"},
+		{"single quotes", `grep 'x|y' file | wc -l`, "grep 'x|y' file |\n  wc -l"},
+		{"subshell", `echo $(ls | wc -l) && date`, "echo $(ls | wc -l) &&\n  date"},
+		{"escaped pipe", `echo a \| b`, `echo a \| b`},
+		{"redirect", "make 2>&1 | less", "make 2>&1 |\n  less"},
+		{"trailing semicolon", "ls;", "ls;"},
+		{"case branch", "case $x in a) echo a;; esac", "case $x in a) echo a;; esac"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prettyFormat(tt.input); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPrettyFormatPreservesCommand(t *testing.T) {
+	// Joining the lines back up gives the same words in the same order
+	cmd := `find . -name "*.go" -print0 | xargs -0 grep -l "TODO: fix && ship" && echo found || echo none`
+	pretty := prettyFormat(cmd)
+	if strings.Join(strings.Fields(pretty), " ") != strings.Join(strings.Fields(cmd), " ") {
+		t.Errorf("Expected the pretty form to keep every word, got %q", pretty)
+	}
+
+	// Every line but the last ends with an operator, so the shell keeps reading
+	lines := strings.Split(pretty, "\n")
+	for _, line := range lines[:len(lines)-1] {
+		if !strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "&&") && !strings.HasSuffix(line, "||") {
+			t.Errorf("Expected line %q to end with an operator", line)
+		}
+	}
+}
+
+func TestPrettyToggle(t *testing.T) {
+	written := stubClipboard(t)
+
+	var m tea.Model = initialModel("build", false)
+	m, _ = m.Update(cmdGeneratedMsg{cmd: "cd src && make"})
+	if strings.Contains(m.(model).View(), "cd src &&\n") {
+		t.Error("Expected the raw command to be shown by default")
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'b'}})
+	if !m.(model).prettyView {
+		t.Fatal("Expected B to switch to the pretty view")
+	}
+	if !strings.Contains(m.(model).View(), "make") || m.(model).generatedCmd != "cd src && make" {
+		t.Error("Expected the pretty view not to change the command")
+	}
+
+	// Enter still copies the raw one-liner
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	cmd()
+	if *written != "cd src && make" {
+		t.Errorf("Expected the raw command to be copied, got %q", *written)
+	}
+
+	// Shift+B copies the pretty form
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'B'}})
+	cmd()
+	if *written != "cd src &&\n  make" {
+		t.Errorf("Expected the pretty form to be copied, got %q", *written)
+	}
+}
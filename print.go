@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// runPrint implements --print: it generates a command for the prompt given
+// on the command line and writes it to stdout, unstyled, for use in scripts
+// like $(clippycli --print "..."). It returns the process exit code.
+func runPrint(m model, stdout, stderr io.Writer) int {
+	if m.prompt == "" {
+		fmt.Fprintln(stderr, "Error: --print needs a prompt on the command line")
+		return 1
+	}
+
+	// stdout is for the command alone, and --quiet drops warnings too
+	if !m.opts.quiet {
+		for _, note := range []string{m.tokensNote, m.temperatureNote} {
+			if note != "" {
+				fmt.Fprintf(stderr, "Warning: %s\n", note)
+			}
+		}
+	}
+
+	cmd, err := generationResult(m.runGeneration())
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, cmd)
+
+	// Nothing stops to confirm a downloaded script here, so say what it
+	// fetches even with --quiet
+	if warning := pipeToShellWarning(cmd); warning != "" {
+		fmt.Fprintf(stderr, "Warning: %s\n", warning)
+	}
+	return 0
+}
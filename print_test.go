@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunPrint(t *testing.T) {
+	m := newModel(options{prompt: "list go files", print: true})
+	m.generator = fakeGenerator{text: "find . -name '*.go'\n"}
+
+	var stdout, stderr bytes.Buffer
+	if code := runPrint(m, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr %q)", code, stderr.String())
+	}
+	if stdout.String() != "find . -name '*.go'\n" {
+		t.Errorf("Expected only the command on stdout, got %q", stdout.String())
+	}
+	if strings.Contains(stdout.String(), "\x1b[") || stderr.Len() != 0 {
+		t.Errorf("Expected no styling and nothing on stderr, got %q and %q", stdout.String(), stderr.String())
+	}
+}
+
+func TestRunPrintErrors(t *testing.T) {
+	m := newModel(options{prompt: "list go files", print: true})
+	m.generator = fakeGenerator{err: errors.New("rate limited")}
+
+	var stdout, stderr bytes.Buffer
+	if code := runPrint(m, &stdout, &stderr); code == 0 {
+		t.Error("Expected a non-zero exit code")
+	}
+	if stdout.Len() != 0 || !strings.Contains(stderr.String(), "rate limited") {
+		t.Errorf("Expected the error on stderr only, got %q and %q", stdout.String(), stderr.String())
+	}
+
+	stderr.Reset()
+	if code := runPrint(newModel(options{print: true}), &stdout, &stderr); code == 0 || !strings.Contains(stderr.String(), "needs a prompt") {
+		t.Errorf("Expected an error without a prompt, got %q", stderr.String())
+	}
+}
+
+func TestRunPrintWarnsAboutDownloadedScripts(t *testing.T) {
+	m := newModel(options{prompt: "install it", print: true, quiet: true})
+	m.generator = fakeGenerator{text: "curl -fsSL https://example.com/install.sh | sh"}
+
+	var stdout, stderr bytes.Buffer
+	if code := runPrint(m, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr %q)", code, stderr.String())
+	}
+	if stdout.String() != "curl -fsSL https://example.com/install.sh | sh\n" {
+		t.Errorf("Expected only the command on stdout, got %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Warning: this command downloads a script") || !strings.Contains(stderr.String(), "https://example.com/install.sh") {
+		t.Errorf("Expected a warning naming the URL on stderr, even with --quiet, got %q", stderr.String())
+	}
+}
+
+func TestRunPrintQuiet(t *testing.T) {
+	m := newModel(options{prompt: "list go files", print: true, quiet: true, model: "claude-3-haiku-20240307", maxTokens: 9000})
+	m.generator = fakeGenerator{text: "find . -name '*.go'"}
+
+	var stdout, stderr bytes.Buffer
+	if code := runPrint(m, &stdout, &stderr); code != 0 || stdout.String() != "find . -name '*.go'\n" {
+		t.Fatalf("Expected the command and exit code 0, got %d and %q", code, stdout.String())
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Expected the token limit warning to be left out, got %q", stderr.String())
+	}
+
+	// Errors are still reported
+	m.generator = fakeGenerator{err: errors.New("rate limited")}
+	if code := runPrint(m, &stdout, &stderr); code == 0 || !strings.Contains(stderr.String(), "rate limited") {
+		t.Errorf("Expected the error on stderr, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+)
+
+// currentUsername returns the name of the user running clippycli, falling
+// back to the environment when the user database can't be read
+func currentUsername() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, name := range []string{"USER", "USERNAME"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return "unknown"
+}
+
+// privilegeInfo describes who the commands will run as, so the model knows
+// whether sudo is needed
+func privilegeInfo(elevated bool, username string) string {
+	return fmt.Sprintf("Running as root/admin: %t\nUser: %s", elevated, username)
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// geteuid is a var so tests can pretend to run as another user
+var geteuid = os.Geteuid
+
+// isElevated reports whether the process is running as root
+func isElevated() bool {
+	return geteuid() == 0
+}
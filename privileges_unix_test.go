@@ -0,0 +1,27 @@
+//go:build !windows
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrivilegeInfoReflectsEuid(t *testing.T) {
+	orig := geteuid
+	t.Cleanup(func() { geteuid = orig })
+
+	geteuid = func() int { return 0 }
+	if !strings.Contains(getEnvironmentInfo(envOptions{}), "Running as root/admin: true") {
+		t.Error("Expected euid 0 to be reported as root")
+	}
+
+	geteuid = func() int { return 1000 }
+	envInfo := getEnvironmentInfo(envOptions{})
+	if !strings.Contains(envInfo, "Running as root/admin: false") {
+		t.Error("Expected a regular euid not to be reported as root")
+	}
+	if !strings.Contains(envInfo, "User: "+currentUsername()) {
+		t.Error("Expected the username in the environment info")
+	}
+}
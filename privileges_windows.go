@@ -0,0 +1,10 @@
+//go:build windows
+
+package main
+
+import "golang.org/x/sys/windows"
+
+// isElevated reports whether the process has an elevated (administrator) token
+func isElevated() bool {
+	return windows.GetCurrentProcessToken().IsElevated()
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/benmyles/clippycli/clippy"
+)
+
+// singleLineInstruction asks for a one-liner, for --single-line
+const singleLineInstruction = "Put the whole command on a single line: chain steps with && or ; rather than newlines, and don't use line continuations or heredocs."
+
+// promptOptions are the settings that shape the system prompt
+type promptOptions struct {
+	asScript      bool
+	withUndo      bool
+	withVerify    bool
+	askInputs     bool
+	commentStyle  string
+	disabledRules map[string]bool
+	avoidTools    []string
+	spacedPaths   []string // Nearby paths containing spaces, which need quoting
+	count         int      // How many alternative commands to ask for
+	singleLine    bool     // Ask for the command on one line
+	lang          string   // Language for explanatory text, or empty for English
+	contexts      []string // Standing context from --context, for every request
+}
+
+// promptOptions collects the model's settings that affect the system prompt
+func (m model) promptOptions() promptOptions {
+	return promptOptions{
+		asScript:      m.opts.asScript,
+		withUndo:      m.opts.withUndo,
+		withVerify:    m.opts.withVerify,
+		askInputs:     m.opts.askInputs,
+		commentStyle:  m.opts.commentStyle,
+		disabledRules: m.disabledRules,
+		avoidTools:    m.avoidTools,
+		count:         m.opts.count,
+		singleLine:    m.opts.singleLine,
+		lang:          m.opts.lang,
+		contexts:      m.opts.contexts,
+	}
+}
+
+// staticPrompt holds the parts of the system prompt that only depend on the
+// options, on either side of the environment info
+type staticPrompt struct {
+	head string // The task, before the environment info
+	tail string // Rules, examples, and the response format
+}
+
+// staticPrompts memoizes staticPrompt by the options that affect it
+var staticPrompts sync.Map
+
+// cacheKey identifies the options that shape the static parts of the prompt.
+// avoidTools and spacedPaths are left out because they're appended per request.
+func (o promptOptions) cacheKey() string {
+	var disabled []string
+	for id, off := range o.disabledRules {
+		if off {
+			disabled = append(disabled, id)
+		}
+	}
+	sort.Strings(disabled)
+	return fmt.Sprintf("%t|%t|%t|%t|%s|%s", o.asScript, o.withUndo, o.withVerify, o.askInputs, o.commentStyle, strings.Join(disabled, ","))
+}
+
+// static builds, or reuses, the parts of the prompt that don't change
+// between generations
+func (o promptOptions) static() staticPrompt {
+	key := o.cacheKey()
+	if cached, ok := staticPrompts.Load(key); ok {
+		return cached.(staticPrompt)
+	}
+
+	task, output := clippy.CommandTask, "the command"
+	if o.asScript {
+		task, output = scriptTask, "the script"
+		if instruction := commentStyles[o.commentStyle]; instruction != "" {
+			task += "\n- " + instruction
+		}
+	}
+
+	tail := "Rules:\n" + clippy.AssembleRules(o.disabledRules, output)
+	// One-liner examples would contradict the script format
+	if !o.asScript {
+		tail += "\n\n" + clippy.CommandExamples
+	}
+
+	s := staticPrompt{head: task, tail: tail}
+	staticPrompts.Store(key, s)
+	return s
+}
+
+// buildSystemPrompt assembles the system prompt around the given environment
+// info. Only the environment and the tools to avoid change between requests;
+// everything else comes from the memoized static parts.
+func buildSystemPrompt(opts promptOptions, envInfo string) string {
+	s := opts.static()
+
+	var prompt strings.Builder
+	prompt.WriteString(s.head)
+	prompt.WriteString("\n\nEnvironment Information:\n")
+	prompt.WriteString(clippy.ContextSection("environment", envInfo))
+	// The user gave this, so unlike the environment it's meant to steer
+	if note := userContextNote(opts.contexts); note != "" {
+		prompt.WriteString("\n\n")
+		prompt.WriteString(note)
+	}
+	prompt.WriteString("\n\n")
+	prompt.WriteString(s.tail)
+
+	if len(opts.avoidTools) > 0 {
+		prompt.WriteString(fmt.Sprintf("\n\nThese tools are NOT installed, so don't use them: %s", strings.Join(opts.avoidTools, ", ")))
+	}
+	if opts.singleLine {
+		prompt.WriteString("\n\n")
+		prompt.WriteString(singleLineInstruction)
+	}
+	if note := quotingNote(opts.spacedPaths); note != "" {
+		prompt.WriteString("\n\n")
+		prompt.WriteString(note)
+	}
+	if instruction := langInstruction(opts.lang); instruction != "" {
+		prompt.WriteString("\n\n")
+		prompt.WriteString(instruction)
+	}
+
+	// The format comes last since it overrides rule 1 and the examples
+	if format := opts.responseFormat(); format != "" {
+		prompt.WriteString("\n\n")
+		prompt.WriteString(format)
+	}
+	if opts.count > 1 {
+		prompt.WriteString("\n\n")
+		prompt.WriteString(alternativesFormat(opts.count))
+	}
+
+	return prompt.String()
+}
+
+// userContextNote tells the model about the setting the user works in, as
+// given with --context, so it needn't be repeated in every prompt
+func userContextNote(contexts []string) string {
+	if len(contexts) == 0 {
+		return ""
+	}
+	var note strings.Builder
+	note.WriteString("The user's context, which applies to every request:")
+	for _, c := range contexts {
+		note.WriteString("\n- ")
+		note.WriteString(c)
+	}
+	return note.String()
+}
+
+// systemPrompt returns the system prompt to send with the given environment info
+func (m model) systemPrompt(envInfo string) string {
+	opts := m.promptOptions()
+	opts.spacedPaths = spacedPaths()
+	return buildSystemPrompt(opts, envInfo)
+}
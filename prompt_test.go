@@ -0,0 +1,132 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benmyles/clippycli/clippy"
+)
+
+func TestBuildSystemPromptDefaults(t *testing.T) {
+	prompt := buildSystemPrompt(promptOptions{}, "Shell: /bin/zsh")
+
+	for _, want := range []string{clippy.CommandTask, "<context name=\"environment\">", "Shell: /bin/zsh", "1. Return ONLY the command", clippy.CommandExamples} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected default prompt to contain %q", want)
+		}
+	}
+	for _, unwanted := range []string{"Response format", "NOT installed", "reusable shell script"} {
+		if strings.Contains(prompt, unwanted) {
+			t.Errorf("Expected default prompt not to contain %q", unwanted)
+		}
+	}
+}
+
+func TestBuildSystemPromptMatchesLibrary(t *testing.T) {
+	// Without options the TUI sends what clippy.Generate does
+	if got, want := buildSystemPrompt(promptOptions{}, "Shell: /bin/zsh"), clippy.SystemPrompt("Shell: /bin/zsh"); got != want {
+		t.Errorf("Expected the default prompt to match the library's, got %q, want %q", got, want)
+	}
+}
+
+func TestBuildSystemPromptOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     promptOptions
+		contains []string
+		omits    []string
+	}{
+		{"script", promptOptions{asScript: true}, []string{"reusable shell script", "Return ONLY the script"}, []string{clippy.CommandExamples, clippy.CommandTask}},
+		{"undo", promptOptions{withUndo: true}, []string{"Response format", `"undo"`}, []string{`"verify"`}},
+		{"verify", promptOptions{withVerify: true}, []string{"Response format", `"verify"`}, []string{`"undo"`}},
+		{"inputs", promptOptions{askInputs: true}, []string{"Response format", `"inputs"`}, nil},
+		{"disabled rule", promptOptions{disabledRules: map[string]bool{"no-sudo": true}}, nil, []string{"require sudo"}},
+		{"avoid tools", promptOptions{avoidTools: []string{"rg", "fd"}}, []string{"NOT installed, so don't use them: rg, fd"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt := buildSystemPrompt(tt.opts, "Shell: /bin/bash")
+			for _, want := range tt.contains {
+				if !strings.Contains(prompt, want) {
+					t.Errorf("Expected prompt to contain %q", want)
+				}
+			}
+			for _, unwanted := range tt.omits {
+				if strings.Contains(prompt, unwanted) {
+					t.Errorf("Expected prompt not to contain %q", unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildSystemPromptReusesStaticParts(t *testing.T) {
+	opts := promptOptions{withUndo: true, disabledRules: map[string]bool{"env-vars": true}}
+
+	// Only the environment changes between these prompts
+	first := buildSystemPrompt(opts, "Shell: /bin/bash")
+	second := buildSystemPrompt(opts, "Shell: /bin/zsh")
+	if strings.Replace(first, "/bin/bash", "/bin/zsh", 1) != second {
+		t.Error("Expected prompts to differ only in the environment info")
+	}
+
+	if _, ok := staticPrompts.Load(opts.cacheKey()); !ok {
+		t.Error("Expected the static parts to be memoized")
+	}
+
+	// Options that change the static parts get their own entry
+	other := promptOptions{withUndo: true}
+	if other.cacheKey() == opts.cacheKey() {
+		t.Error("Expected different disabled rules to use a different cache key")
+	}
+	if strings.Contains(buildSystemPrompt(other, ""), "\n9. ") == strings.Contains(first, "\n9. ") {
+		t.Error("Expected the memoized prompt for one set of rules not to leak into another")
+	}
+
+	// The order disabled rules are listed in doesn't matter
+	a := promptOptions{disabledRules: map[string]bool{"safe": true, "no-sudo": true}}
+	b := promptOptions{disabledRules: map[string]bool{"no-sudo": true, "safe": true, "env-vars": false}}
+	if a.cacheKey() != b.cacheKey() {
+		t.Error("Expected equivalent rule settings to share a cache key")
+	}
+}
+
+func TestSingleLine(t *testing.T) {
+	opts, err := parseArgs([]string{"--single-line", "back up my home directory"})
+	if err != nil || !opts.singleLine {
+		t.Fatalf("Expected --single-line to be set, got %v (err %v)", opts.singleLine, err)
+	}
+	if _, err := parseArgs([]string{"--single-line", "--as-script"}); err == nil {
+		t.Error("Expected --single-line with --as-script to be rejected")
+	}
+
+	if prompt := newModel(opts).systemPrompt("Shell: /bin/zsh"); !strings.Contains(prompt, singleLineInstruction) {
+		t.Errorf("Expected the prompt to ask for one line, got %q", prompt)
+	}
+	if prompt := buildSystemPrompt(promptOptions{}, ""); strings.Contains(prompt, singleLineInstruction) {
+		t.Error("Expected multi-line commands to be allowed by default")
+	}
+}
+
+func TestUserContext(t *testing.T) {
+	opts, err := parseArgs([]string{"--context", "in a Kubernetes cluster named prod", "--context= using Helm 3 ", "list pods"})
+	if err != nil {
+		t.Fatalf("parseArgs failed: %v", err)
+	}
+	if len(opts.contexts) != 2 || opts.contexts[1] != "using Helm 3" {
+		t.Fatalf("Expected both contexts to stack, got %q", opts.contexts)
+	}
+	if _, err := parseArgs([]string{"--context", " "}); err == nil {
+		t.Error("Expected an empty context to be rejected")
+	}
+
+	prompt := newModel(opts).systemPrompt("Shell: /bin/zsh")
+	expected := "The user's context, which applies to every request:\n- in a Kubernetes cluster named prod\n- using Helm 3"
+	if !strings.Contains(prompt, expected) {
+		t.Errorf("Expected the prompt to include the context, got %q", prompt)
+	}
+	if strings.Contains(buildSystemPrompt(promptOptions{}, ""), "The user's context") {
+		t.Error("Expected no context section by default")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/benmyles/clippycli/clippy"
+)
+
+const (
+	// providerEnv picks the model provider when --provider isn't given
+	providerEnv = "CLIPPY_PROVIDER"
+	// modelEnv picks the model when --model isn't given
+	modelEnv = "CLIPPY_MODEL"
+	// maxTokensEnv limits reply length when --max-tokens isn't given
+	maxTokensEnv = "CLIPPY_MAX_TOKENS"
+)
+
+// resolveProvider picks the provider named by the flag, then $CLIPPY_PROVIDER.
+// With neither, it's Anthropic unless only an OpenAI key is set.
+func resolveProvider(name string, getenv func(string) string) (string, error) {
+	if name == "" {
+		name = strings.ToLower(getenv(providerEnv))
+	}
+	if name == "" {
+		if getenv(clippy.KeyEnv("anthropic")) == "" && getenv(clippy.KeyEnv("openai")) != "" {
+			return "openai", nil
+		}
+		return "anthropic", nil
+	}
+	if !clippy.KnownProvider(name) {
+		return "", fmt.Errorf("unknown provider %q, expected %s", name, clippy.ProviderNames())
+	}
+	return name, nil
+}
+
+// resolveMaxTokens picks the reply limit from the flag, then
+// $CLIPPY_MAX_TOKENS, leaving zero for clippy.DefaultMaxTokens
+func resolveMaxTokens(n int, getenv func(string) string) (int, error) {
+	if n > 0 {
+		return n, nil
+	}
+	v := getenv(maxTokensEnv)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("$%s must be a positive number, got %q", maxTokensEnv, v)
+	}
+	return n, nil
+}
+
+// newGenerator returns the generator for provider with the model, reply
+// limit and temperature in opts
+func newGenerator(provider string, opts options) clippy.Generator {
+	g := clippy.NewGenerator(provider, opts.model, opts.maxTokens)
+	if opts.temperature != nil {
+		g = clippy.WithTemperature(g, *opts.temperature)
+	}
+	return g
+}
+
+// provider returns the provider in use, which like clippy.NewGenerator falls
+// back to Anthropic when none was resolved
+func (m model) provider() string {
+	if clippy.KnownProvider(m.opts.provider) {
+		return m.opts.provider
+	}
+	return "anthropic"
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/benmyles/clippycli/clippy"
+)
+
+// fakeGenerator replies with fixed text and stop reason without calling any API
+type fakeGenerator struct {
+	text, stopReason          string
+	inputTokens, outputTokens int
+	err                       error
+}
+
+func (g fakeGenerator) Generate(_ context.Context, _ string, _ []clippy.Message, onText func(string)) (clippy.Generation, error) {
+	if onText != nil && g.text != "" {
+		onText(g.text)
+	}
+	return clippy.Generation{Text: g.text, StopReason: g.stopReason, InputTokens: g.inputTokens, OutputTokens: g.outputTokens}, g.err
+}
+
+func (g fakeGenerator) WarmUp(context.Context) error { return nil }
+
+func TestResolveProvider(t *testing.T) {
+	tests := []struct {
+		name string
+		flag string
+		env  map[string]string
+		want string
+	}{
+		{"default", "", nil, "anthropic"},
+		{"anthropic key", "", map[string]string{"ANTHROPIC_API_KEY": "a"}, "anthropic"},
+		{"only openai key", "", map[string]string{"OPENAI_API_KEY": "o"}, "openai"},
+		{"both keys prefer anthropic", "", map[string]string{"ANTHROPIC_API_KEY": "a", "OPENAI_API_KEY": "o"}, "anthropic"},
+		{"env var", "", map[string]string{providerEnv: "OpenAI", "ANTHROPIC_API_KEY": "a"}, "openai"},
+		{"flag beats env var", "anthropic", map[string]string{providerEnv: "openai"}, "anthropic"},
+	}
+	for _, tt := range tests {
+		got, err := resolveProvider(tt.flag, func(k string) string { return tt.env[k] })
+		if err != nil || got != tt.want {
+			t.Errorf("%s: Expected %q, got %q (err %v)", tt.name, tt.want, got, err)
+		}
+	}
+
+	if _, err := resolveProvider("", func(k string) string {
+		if k == providerEnv {
+			return "gemini"
+		}
+		return ""
+	}); err == nil {
+		t.Error("Expected an unknown provider in the environment to be an error")
+	}
+}
+
+func TestParseArgsProvider(t *testing.T) {
+	opts, err := parseArgs([]string{"--provider", "openai", "list files"})
+	if err != nil || opts.provider != "openai" {
+		t.Errorf("Expected provider openai, got %q (err %v)", opts.provider, err)
+	}
+	if _, err := parseArgs([]string{"--provider=gemini"}); err == nil {
+		t.Error("Expected an unknown provider to be rejected")
+	}
+
+	opts, err = parseArgs([]string{"--provider", "ollama", "--model", "llama3"})
+	if err != nil || opts.provider != "ollama" || opts.model != "llama3" {
+		t.Errorf("Expected provider ollama with model llama3, got %q, %q (err %v)", opts.provider, opts.model, err)
+	}
+}
+
+func TestMaxTokensStopWarns(t *testing.T) {
+	m := initialModel("write a backup script", false)
+	m.generator = fakeGenerator{text: "tar czf backup.tgz \\\n  --exclude", stopReason: clippy.StopMaxTokens}
+
+	msg, ok := m.generateCommand()().(cmdGeneratedMsg)
+	if !ok || msg.stopReason != clippy.StopMaxTokens {
+		t.Fatalf("Expected the stop reason to be captured, got %#v", msg)
+	}
+
+	updated, _ := m.Update(msg)
+	if view := updated.View(); !strings.Contains(view, "may be truncated") || !strings.Contains(view, "--max-tokens") {
+		t.Errorf("Expected a truncation warning, got %q", view)
+	}
+
+	// A reply that finished normally gets no warning
+	m.generator = fakeGenerator{text: "ls -la", stopReason: "end_turn"}
+	updated, _ = m.Update(m.generateCommand()())
+	if strings.Contains(updated.View(), "may be truncated") {
+		t.Error("Expected no truncation warning for a complete reply")
+	}
+}
+
+func TestParseArgsMaxTokens(t *testing.T) {
+	opts, err := parseArgs([]string{"--max-tokens", "4096"})
+	if err != nil || opts.maxTokens != 4096 {
+		t.Errorf("Expected max tokens 4096, got %d (err %v)", opts.maxTokens, err)
+	}
+	for _, bad := range []string{"0", "-5", "lots"} {
+		if _, err := parseArgs([]string{"--max-tokens", bad}); err == nil {
+			t.Errorf("Expected --max-tokens %s to be rejected", bad)
+		}
+	}
+}
+
+func TestModelThreadedIntoRequests(t *testing.T) {
+	m := newModel(options{model: string(anthropic.ModelClaude3_5HaikuLatest)})
+	if m.modelName != string(anthropic.ModelClaude3_5HaikuLatest) {
+		t.Errorf("Expected the model to be stored, got %q", m.modelName)
+	}
+	if m := newModel(options{}); m.modelName != string(anthropic.ModelClaudeSonnet4_20250514) {
+		t.Errorf("Expected the default model to be stored, got %q", m.modelName)
+	}
+}
+
+func TestResolveMaxTokens(t *testing.T) {
+	env := map[string]string{maxTokensEnv: "4096"}
+	getenv := func(k string) string { return env[k] }
+	if n, err := resolveMaxTokens(0, getenv); err != nil || n != 4096 {
+		t.Errorf("Expected $%s to be used, got %d (err %v)", maxTokensEnv, n, err)
+	}
+	if n, _ := resolveMaxTokens(512, getenv); n != 512 {
+		t.Errorf("Expected the flag to win, got %d", n)
+	}
+	if n, err := resolveMaxTokens(0, func(string) string { return "" }); err != nil || n != 0 {
+		t.Errorf("Expected zero for the default, got %d (err %v)", n, err)
+	}
+	env[maxTokensEnv] = "-5"
+	if _, err := resolveMaxTokens(0, getenv); err == nil {
+		t.Error("Expected an error for a negative limit")
+	}
+}
+
+func TestNewModelCapsMaxTokens(t *testing.T) {
+	m := newModel(options{provider: "anthropic", model: "claude-3-haiku-20240307", maxTokens: 9000})
+	if m.opts.maxTokens != 4096 || !strings.Contains(m.View(), "Note: claude-3-haiku-20240307 replies with at most 4096 tokens") {
+		t.Errorf("Expected the model to start with a capped limit and a note, got %d", m.opts.maxTokens)
+	}
+}
+
+func TestParseArgsTemperature(t *testing.T) {
+	opts, err := parseArgs([]string{"--temperature", "0", "list files"})
+	if err != nil || opts.temperature == nil || *opts.temperature != 0 {
+		t.Errorf("Expected a temperature of 0 to be set, got %v (err %v)", opts.temperature, err)
+	}
+	if opts, _ := parseArgs([]string{"list files"}); opts.temperature != nil {
+		t.Errorf("Expected no temperature by default, got %v", *opts.temperature)
+	}
+	for _, bad := range []string{"warm", "NaN", ""} {
+		if _, err := parseArgs([]string{"--temperature=" + bad}); err == nil || !strings.Contains(err.Error(), "--temperature must be a number") {
+			t.Errorf("%q: expected an error, got %v", bad, err)
+		}
+	}
+}
+
+func TestNewModelClampsTemperature(t *testing.T) {
+	hot := 1.7
+	m := newModel(options{provider: "anthropic", temperature: &hot})
+	if *m.opts.temperature != 1 || !strings.Contains(m.View(), "Note: temperature must be between 0 and 1, so 1.7 was changed to 1") {
+		t.Errorf("Expected the temperature to be clamped with a note, got %v", *m.opts.temperature)
+	}
+
+	var stderr bytes.Buffer
+	m.prompt = "list files"
+	m.generator = fakeGenerator{text: "ls"}
+	runPrint(m, io.Discard, &stderr)
+	if !strings.Contains(stderr.String(), "Warning: temperature must be between 0 and 1") {
+		t.Errorf("Expected --print to warn about the clamped temperature, got %q", stderr.String())
+	}
+}
+
+func TestOllamaUnreachableShowsInResult(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Setenv("OLLAMA_HOST", server.URL)
+	server.Close()
+
+	m := initialModel("list files", false)
+	m.generator = clippy.NewGenerator("ollama", "llama3", 0)
+	msg, ok := m.generateCommand()().(cmdGeneratedMsg)
+	if !ok || msg.err == nil {
+		t.Fatalf("Expected a cmdGeneratedMsg with an error, got %#v", msg)
+	}
+
+	updated, _ := m.Update(msg)
+	if updated.(model).state != stateError {
+		t.Errorf("Expected state to be stateError, got %v", updated.(model).state)
+	}
+	if view := updated.View(); !strings.Contains(view, "ollama serve") {
+		t.Errorf("Expected the view to suggest starting Ollama, got %q", view)
+	}
+}
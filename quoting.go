@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxScannedEntries caps how much of a huge directory is read
+	maxScannedEntries = 1000
+	// maxNamedPaths caps how many spaced names are listed in the prompt
+	maxNamedPaths = 10
+)
+
+// spacedPaths returns the paths around the working directory that need
+// quoting: the directory itself and the names of its entries, when they
+// contain whitespace
+func spacedPaths() []string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	return spacedPathsIn(dir)
+}
+
+// spacedPathsIn returns dir and the names of its entries that contain
+// whitespace, with dir first if it does
+func spacedPathsIn(dir string) []string {
+	var paths []string
+	if strings.ContainsAny(dir, " \t") {
+		paths = append(paths, dir)
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		return paths
+	}
+	defer f.Close()
+	entries, _ := f.ReadDir(maxScannedEntries)
+
+	var names []string
+	for _, entry := range entries {
+		if strings.ContainsAny(entry.Name(), " \t") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return append(paths, names...)
+}
+
+// quotingNote asks the model to quote paths when some nearby ones contain
+// spaces, naming a few so it knows which
+func quotingNote(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	named := paths
+	if len(named) > maxNamedPaths {
+		named = named[:maxNamedPaths]
+	}
+	quoted := make([]string, len(named))
+	for i, p := range named {
+		quoted[i] = shellQuote(p)
+	}
+	return fmt.Sprintf("Some paths here contain spaces, such as %s. Always quote file and directory paths so they survive word splitting.", strings.Join(quoted, ", "))
+}
+
+// shellQuote quotes s for a POSIX shell, preferring single quotes
+func shellQuote(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	return `"` + r.Replace(s) + `"`
+}
+
+// quoteUnquotedPaths quotes the places where cmd uses one of knownFiles
+// without quoting it. Only exact, whole-word matches outside quotes and
+// escapes are touched, so anything ambiguous is left as the model wrote it.
+func quoteUnquotedPaths(cmd string, knownFiles []string) string {
+	// Try longer names first so "my file.txt" beats "my file"
+	var names []string
+	for _, f := range knownFiles {
+		if strings.ContainsAny(f, " \t") {
+			names = append(names, f)
+		}
+	}
+	if len(names) == 0 {
+		return cmd
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	var out strings.Builder
+	var quote byte
+	escaped := false
+	for i := 0; i < len(cmd); i++ {
+		c := cmd[i]
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case i == 0 || isWordBoundary(cmd[i-1]):
+			if name := matchPathAt(cmd, i, names); name != "" {
+				out.WriteString(shellQuote(name))
+				i += len(name) - 1
+				continue
+			}
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
+
+// matchPathAt returns the first of names that appears in cmd at i as a
+// whole word
+func matchPathAt(cmd string, i int, names []string) string {
+	for _, name := range names {
+		end := i + len(name)
+		if strings.HasPrefix(cmd[i:], name) && (end == len(cmd) || isWordBoundary(cmd[end])) {
+			return name
+		}
+	}
+	return ""
+}
+
+// isWordBoundary reports whether c separates shell words
+func isWordBoundary(c byte) bool {
+	return strings.IndexByte(" \t\n;|&()<>", c) >= 0
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// spacedDir creates a directory holding files with and without spaces
+func spacedDir(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "My Projects")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"notes.txt", "my file.txt", "my file", "Tax Return 2024.pdf", "it's here.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSpacedPathsIn(t *testing.T) {
+	dir := spacedDir(t)
+	paths := spacedPathsIn(dir)
+	expected := []string{dir, "Tax Return 2024.pdf", "it's here.md", "my file", "my file.txt"}
+	if strings.Join(paths, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %q, got %q", expected, paths)
+	}
+}
+
+func TestQuoteUnquotedPaths(t *testing.T) {
+	dir := spacedDir(t)
+	known := spacedPathsIn(dir)
+
+	tests := []struct {
+		cmd      string
+		expected string
+	}{
+		{"cat my file.txt", "cat 'my file.txt'"},
+		{"rm my file", "rm 'my file'"},
+		{"cp my file.txt my file.bak", "cp 'my file.txt' my file.bak"},
+		{"wc -l my file.txt|sort", "wc -l 'my file.txt'|sort"},
+		{"open Tax Return 2024.pdf && ls", "open 'Tax Return 2024.pdf' && ls"},
+		{"cat it's here.md", `cat "it's here.md"`},
+		{"cd " + dir, "cd '" + dir + "'"},
+		// Already quoted or escaped paths are left alone
+		{`cat "my file.txt"`, `cat "my file.txt"`},
+		{"cat 'my file.txt'", "cat 'my file.txt'"},
+		{`cat my\ file.txt`, `cat my\ file.txt`},
+		// Only whole words that exactly match a known file
+		{"cat notmy file.txt", "cat notmy file.txt"},
+		{"cat ./my file.txt", "cat ./my file.txt"},
+		{"grep my file.txtx", "grep my file.txtx"},
+		{"ls notes.txt", "ls notes.txt"},
+	}
+
+	for _, tt := range tests {
+		if result := quoteUnquotedPaths(tt.cmd, known); result != tt.expected {
+			t.Errorf("quoteUnquotedPaths(%q) = %q; want %q", tt.cmd, result, tt.expected)
+		}
+	}
+}
+
+func TestQuotingNoteInPrompt(t *testing.T) {
+	dir := spacedDir(t)
+	t.Chdir(dir)
+
+	prompt := initialModel("list files", false).systemPrompt("Shell: /bin/zsh")
+	if !strings.Contains(prompt, "Always quote file and directory paths") || !strings.Contains(prompt, "'my file.txt'") {
+		t.Errorf("Expected the prompt to ask for quoted paths, got:\n%s", prompt)
+	}
+
+	// Nothing is added when no paths have spaces
+	if note := quotingNote(spacedPathsIn(t.TempDir())); note != "" {
+		t.Errorf("Expected no note without spaced paths, got %q", note)
+	}
+}
+
+func TestFinishGenerationQuotesKnownFiles(t *testing.T) {
+	t.Chdir(spacedDir(t))
+	msg := initialModel("show the notes", false).finishGeneration("cat my file.txt\n", "")
+	if msg.cmd != "cat 'my file.txt'" {
+		t.Errorf("Expected the known file to be quoted, got %q", msg.cmd)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// commandResponse is the structured reply requested from the model when
+// fields beyond the command itself are needed
+type commandResponse struct {
+	Command string          `json:"command"`
+	Undo    string          `json:"undo"`
+	Verify  string          `json:"verify"`
+	Inputs  []requiredInput `json:"inputs"`
+}
+
+// parseCommandResponse parses a structured JSON reply from the model,
+// tolerating surrounding prose or code fences
+func parseCommandResponse(s string) (commandResponse, error) {
+	var resp commandResponse
+
+	start := strings.Index(s, "{")
+	end := strings.LastIndex(s, "}")
+	if start < 0 || end < start {
+		return resp, errors.New("model did not return the expected JSON response")
+	}
+
+	if err := json.Unmarshal([]byte(s[start:end+1]), &resp); err != nil {
+		return resp, fmt.Errorf("could not parse model response: %w", err)
+	}
+
+	resp.Command = strings.TrimSpace(resp.Command)
+	if resp.Command == "" {
+		return resp, errors.New("model response did not include a command")
+	}
+
+	resp.Undo = optionalCommand(resp.Undo)
+	resp.Verify = optionalCommand(resp.Verify)
+	resp.Inputs = cleanInputs(resp.Command, resp.Inputs)
+
+	return resp, nil
+}
+
+// optionalCommand normalizes an optional command field, treating "none" as empty
+func optionalCommand(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "none") {
+		return ""
+	}
+	return s
+}
+
+// responseFormat describes the JSON reply the model should produce, or ""
+// when a plain command is expected
+func (m model) responseFormat() string {
+	return m.promptOptions().responseFormat()
+}
+
+// responseFormat describes the JSON reply for these options
+func (o promptOptions) responseFormat() string {
+	if !o.withUndo && !o.withVerify && !o.askInputs {
+		return ""
+	}
+
+	fields := []string{`"command": "<the command>"`}
+	var notes []string
+	if o.withUndo {
+		fields = append(fields, `"undo": "<a command that reverses its effects>"`)
+		notes = append(notes, `Use an empty string for "undo" when the command has no side effects or there is no safe way to reverse it.`)
+	}
+	if o.withVerify {
+		fields = append(fields, `"verify": "<a safe, read-only command that checks the command worked>"`)
+		notes = append(notes, `The "verify" command must not modify anything (e.g. after "mkdir foo", verify with "ls -d foo").`)
+	}
+	if o.askInputs {
+		fields = append(fields, `"inputs": [{"placeholder": "<PLACEHOLDER>", "description": "<what the user should provide>"}]`)
+		notes = append(notes, inputsFormatNote)
+	}
+
+	return fmt.Sprintf(`Response format (this overrides rule 1 and the examples above):
+Respond with ONLY a JSON object, no markdown, of the form:
+{%s}
+%s`, strings.Join(fields, ", "), strings.Join(notes, "\n"))
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/benmyles/clippycli/clippy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseCommandResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		command     string
+		undo        string
+		expectError bool
+	}{
+		{"plain pair", `{"command": "mv a b", "undo": "mv b a"}`, "mv a b", "mv b a", false},
+		{"fenced", "```json\n{\"command\": \"mkdir foo\", \"undo\": \"rmdir foo\"}\n```", "mkdir foo", "rmdir foo", false},
+		{"no undo", `{"command": "ls -la", "undo": ""}`, "ls -la", "", false},
+		{"undo none", `{"command": "rm file", "undo": "none"}`, "rm file", "", false},
+		{"missing command", `{"undo": "mv b a"}`, "", "", true},
+		{"not json", "mv a b", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := parseCommandResponse(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %q", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCommandResponse failed: %v", err)
+			}
+			if resp.Command != tt.command {
+				t.Errorf("Expected command %q, got %q", tt.command, resp.Command)
+			}
+			if resp.Undo != tt.undo {
+				t.Errorf("Expected undo %q, got %q", tt.undo, resp.Undo)
+			}
+		})
+	}
+}
+
+func TestWithUndoResponseFormat(t *testing.T) {
+	testModel := initialModel("rename a to b", false)
+	if testModel.responseFormat() != "" {
+		t.Error("Expected no structured response format by default")
+	}
+
+	testModel.opts.withUndo = true
+	if !strings.Contains(testModel.responseFormat(), `"undo"`) {
+		t.Error("Expected the response format to request an undo command")
+	}
+}
+
+func TestCopyUndoAction(t *testing.T) {
+	written := stubClipboard(t)
+
+	testModel := initialModel("rename a to b", false)
+	testModel.opts.withUndo = true
+	updatedModel, _ := testModel.Update(cmdGeneratedMsg{cmd: "mv a b", undo: "mv b a"})
+
+	m := updatedModel.(model)
+	if m.undoCmd != "mv b a" {
+		t.Fatalf("Expected undoCmd to be %q, got %q", "mv b a", m.undoCmd)
+	}
+	if !strings.Contains(m.View(), "Undo command:") {
+		t.Error("Expected the result view to show the undo command")
+	}
+
+	// Pressing u should copy the undo command rather than the command
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")})
+	if cmd == nil {
+		t.Fatal("Expected a copy command after pressing u")
+	}
+
+	msg, ok := cmd().(cmdCopiedMsg)
+	if !ok {
+		t.Fatal("Expected the copy command to produce a cmdCopiedMsg")
+	}
+	if msg.cmd != "mv b a" || *written != "mv b a" {
+		t.Errorf("Expected the undo command to be copied, got %q", *written)
+	}
+}
+
+func TestParseCommandVerifyPair(t *testing.T) {
+	resp, err := parseCommandResponse(`{"command": "mkdir foo", "verify": "ls -d foo"}`)
+	if err != nil {
+		t.Fatalf("parseCommandResponse failed: %v", err)
+	}
+	if resp.Command != "mkdir foo" || resp.Verify != "ls -d foo" {
+		t.Errorf("Expected command/verify pair, got %+v", resp)
+	}
+
+	// Both extras can be requested together
+	testModel := initialModel("make a directory", false)
+	testModel.opts.withUndo = true
+	testModel.opts.withVerify = true
+	format := testModel.responseFormat()
+	if !strings.Contains(format, `"undo"`) || !strings.Contains(format, `"verify"`) {
+		t.Errorf("Expected the format to request undo and verify, got %q", format)
+	}
+}
+
+func TestCopyVerifyAction(t *testing.T) {
+	written := stubClipboard(t)
+
+	testModel := initialModel("make a directory", false)
+	testModel.opts.withVerify = true
+	updatedModel, _ := testModel.Update(cmdGeneratedMsg{cmd: "mkdir foo", verify: "ls -d foo"})
+
+	if !strings.Contains(updatedModel.View(), "ls -d foo") {
+		t.Error("Expected the result view to show the verification command")
+	}
+
+	_, cmd := updatedModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	if cmd == nil {
+		t.Fatal("Expected a copy command after pressing t")
+	}
+	if msg, ok := cmd().(cmdCopiedMsg); !ok || msg.cmd != "ls -d foo" || *written != "ls -d foo" {
+		t.Errorf("Expected the verification command to be copied, got %q", *written)
+	}
+}
+
+func TestRawResponseIsKept(t *testing.T) {
+	testModel := initialModel("rename a to b", false)
+	testModel.opts.withUndo = true
+
+	// The raw reply survives even when it can't be parsed
+	reply := "Sure! ```json\n{\"command\": \"mv a b\"\n```\n"
+	msg := testModel.finishGeneration(reply, "")
+	if msg.err == nil {
+		t.Fatal("Expected the malformed reply not to parse")
+	}
+	if msg.raw != reply {
+		t.Errorf("Expected the raw reply %q on the message, got %q", reply, msg.raw)
+	}
+
+	plain := initialModel("list files", false).finishGeneration("  ls -la\n", "")
+	if plain.cmd != "ls -la" || plain.raw != "  ls -la\n" {
+		t.Errorf("Expected a trimmed command and untouched raw reply, got %q and %q", plain.cmd, plain.raw)
+	}
+}
+
+func TestCopyRawAction(t *testing.T) {
+	written := stubClipboard(t)
+
+	testModel := initialModel("rename a to b", false)
+	reply := `{"command": "mv a b", "undo": "mv b a"}` + "\n"
+	updatedModel, _ := testModel.Update(cmdGeneratedMsg{err: errors.New("bad reply"), raw: reply})
+	m := updatedModel.(model)
+	if m.rawResponse != reply {
+		t.Fatalf("Expected the raw reply to be stored, got %q", m.rawResponse)
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	if cmd == nil {
+		t.Fatal("Expected a copy command after pressing o")
+	}
+	if msg, ok := cmd().(cmdCopiedMsg); !ok || msg.cmd != reply || *written != reply {
+		t.Errorf("Expected the raw reply to be copied, got %q", *written)
+	}
+}
+
+func TestCopyFullPromptAction(t *testing.T) {
+	written := stubClipboard(t)
+	fullPrompt := "System: be brief\n\nUser: list files"
+
+	// Without -v there's no prompt on screen to copy
+	m := initialModel("list files", false)
+	updated, _ := m.Update(cmdGeneratedMsg{cmd: "ls", fullPrompt: fullPrompt})
+	if _, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")}); cmd != nil {
+		t.Error("Expected p to do nothing outside verbose mode")
+	}
+
+	m = initialModel("list files", true)
+	updated, _ = m.Update(cmdGeneratedMsg{cmd: "ls", fullPrompt: fullPrompt})
+	_, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	if cmd == nil {
+		t.Fatal("Expected a copy command after pressing p")
+	}
+	msg, ok := cmd().(promptCopiedMsg)
+	if !ok || msg.err != nil || *written != fullPrompt {
+		t.Fatalf("Expected the full prompt to be copied, got %q", *written)
+	}
+
+	// Copying the prompt keeps ClippyCLI open, with its own confirmation
+	updated, cmd = updated.Update(msg)
+	if cmd != nil || updated.(model).state != stateResult {
+		t.Error("Expected to stay on the result screen")
+	}
+	if view := updated.View(); !strings.Contains(view, "Full prompt copied to clipboard") {
+		t.Errorf("Expected a confirmation for the prompt, got %q", view)
+	}
+}
+
+func TestSanitizeCommand(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "ls -la", "ls -la"},
+		{"fenced with tag", "```bash\nfind . -name '*.go'\n```", "find . -name '*.go'"},
+		{"fenced without tag", "```\nls -la\n```\n", "ls -la"},
+		{"fenced on one line", "```ls -la```", "ls -la"},
+		{"multi-line fenced", "```sh\ncd build\nmake | tee log\n```", "cd build\nmake | tee log"},
+		{"inline backticks", "  `du -sh *`  ", "du -sh *"},
+		{"command substitution", "`which go` version", "`which go` version"},
+		{"two substitutions", "`pwd` and `date`", "`pwd` and `date`"},
+		{"fence only", "```bash", ""},
+	}
+	for _, tt := range tests {
+		if got := clippy.SanitizeCommand(tt.in); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestFencedReplyIsSanitized(t *testing.T) {
+	m := newModel(options{prompt: "list files"})
+	m.generator = fakeGenerator{text: "```bash\nls -la\n```"}
+	if msg := m.runGeneration().(cmdGeneratedMsg); msg.cmd != "ls -la" || msg.raw != "```bash\nls -la\n```" {
+		t.Errorf("Expected the fences stripped from the command but kept in the raw reply, got %q and %q", msg.cmd, msg.raw)
+	}
+
+	m.opts.count = 2
+	m.generator = fakeGenerator{text: "```\n1. `ls -la`\n2. ls -A\n```"}
+	if msg := m.runGeneration().(cmdGeneratedMsg); strings.Join(msg.alts, "|") != "ls -la|ls -A" {
+		t.Errorf("Expected fences and backticks stripped from alternatives, got %q", msg.alts)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/benmyles/clippycli/clippy"
+)
+
+const (
+	// defaultRetries is how many times a transient failure is retried
+	// unless --retries says otherwise
+	defaultRetries = 3
+	// defaultRetryDelay is the wait before the first retry, doubling each time
+	defaultRetryDelay = 500 * time.Millisecond
+)
+
+// isTransient reports whether a failure is likely to go away by itself, so
+// it's worth retrying without asking
+func (c errorCategory) isTransient() bool {
+	return c == errorBusy || c == errorServer || c == errorNetwork
+}
+
+// generateWithRetry calls the generator, retrying transient failures with
+// exponential backoff. A reply that failed partway isn't retried, since the
+// user can choose what to do with what arrived.
+func (m model) generateWithRetry(ctx context.Context, systemPrompt string, messages []clippy.Message, onText func(string)) (clippy.Generation, error) {
+	for attempt := 0; ; attempt++ {
+		reply, err := m.generator.Generate(ctx, systemPrompt, messages, onText)
+		if err == nil || attempt >= m.opts.retries || streamInterrupted(reply.Text, err) ||
+			!classifyError(err).isTransient() || ctx.Err() != nil {
+			return reply, err
+		}
+
+		m.live.retrying(attempt+1, m.opts.retries)
+		select {
+		case <-ctx.Done():
+			return reply, err
+		case <-time.After(m.opts.retryDelay << attempt):
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benmyles/clippycli/clippy"
+)
+
+// flakyGenerator fails with the given errors in turn, then replies with text
+type flakyGenerator struct {
+	errs  []error
+	text  string
+	calls *int
+}
+
+func (g flakyGenerator) Generate(_ context.Context, _ string, _ []clippy.Message, _ func(string)) (clippy.Generation, error) {
+	*g.calls++
+	if *g.calls <= len(g.errs) {
+		return clippy.Generation{}, g.errs[*g.calls-1]
+	}
+	return clippy.Generation{Text: g.text}, nil
+}
+
+func (g flakyGenerator) WarmUp(context.Context) error { return nil }
+
+func retryModel(g clippy.Generator) model {
+	m := newModel(options{prompt: "list files", retries: 3, retryDelay: time.Millisecond})
+	m.generator = g
+	return m
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	calls := 0
+	m := retryModel(flakyGenerator{errs: []error{anthropicError(529), anthropicError(429)}, text: "ls", calls: &calls})
+
+	msg, ok := m.generateCommand()().(cmdGeneratedMsg)
+	if !ok || msg.err != nil || msg.cmd != "ls" {
+		t.Fatalf("Expected the third attempt to succeed, got %#v", msg)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	calls := 0
+	busy := []error{anthropicError(529), anthropicError(529), anthropicError(529), anthropicError(529), anthropicError(529)}
+	m := retryModel(flakyGenerator{errs: busy, calls: &calls})
+
+	if msg := m.generateCommand()().(cmdGeneratedMsg); msg.err == nil {
+		t.Error("Expected the error once the retries ran out")
+	}
+	if calls != 4 {
+		t.Errorf("Expected the first attempt and 3 retries, got %d calls", calls)
+	}
+}
+
+func TestNoRetryForPermanentFailures(t *testing.T) {
+	for _, status := range []int{400, 401, 404} {
+		calls := 0
+		m := retryModel(flakyGenerator{errs: []error{anthropicError(status)}, text: "ls", calls: &calls})
+		if msg := m.generateCommand()().(cmdGeneratedMsg); msg.err == nil || calls != 1 {
+			t.Errorf("Status %d: expected a single failed attempt, got %d calls (err %v)", status, calls, msg.err)
+		}
+	}
+}
+
+func TestRetryShownWhileLoading(t *testing.T) {
+	m := retryModel(fakeGenerator{})
+	updated, cmd := m.Update(cmdStreamChunkMsg{retry: 2, retries: 3})
+	m = updated.(model)
+	if !strings.Contains(m.View(), "Retrying (2/3)...") {
+		t.Errorf("Expected the retry to be shown, got %q", m.View())
+	}
+	if cmd == nil {
+		t.Error("Expected to keep listening for the retried reply")
+	}
+
+	updated, _ = m.Update(cmdGeneratedMsg{cmd: "ls"})
+	if m = updated.(model); m.retryAttempt != 0 {
+		t.Error("Expected the retry count to be cleared once the command arrives")
+	}
+}
+
+func TestParseArgsRetries(t *testing.T) {
+	opts, err := parseArgs(nil)
+	if err != nil || opts.retries != defaultRetries || opts.retryDelay != defaultRetryDelay {
+		t.Errorf("Expected the default retries, got %d and %v (err %v)", opts.retries, opts.retryDelay, err)
+	}
+	opts, err = parseArgs([]string{"--retries", "0", "--retry-delay", "250"})
+	if err != nil || opts.retries != 0 || opts.retryDelay != 250*time.Millisecond {
+		t.Errorf("Expected no retries after 250ms, got %d and %v (err %v)", opts.retries, opts.retryDelay, err)
+	}
+	if _, err := parseArgs([]string{"--retries", "-1"}); err == nil {
+		t.Error("Expected an error for negative retries")
+	}
+}
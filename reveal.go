@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// revealWhitespace makes every character of s visible, so stray whitespace or
+// invisible characters that break pasting stand out. Spaces become dots,
+// trailing spaces get their own marker, and tabs, line endings, non-breaking
+// spaces, and anything else outside printable ASCII are spelled out.
+func revealWhitespace(s string) string {
+	var out strings.Builder
+	for _, line := range strings.SplitAfter(s, "\n") {
+		body := strings.TrimSuffix(line, "\n")
+		crlf := strings.HasSuffix(body, "\r")
+		body = strings.TrimSuffix(body, "\r")
+		trimmed := strings.TrimRight(body, " ")
+
+		for _, r := range trimmed {
+			out.WriteString(revealRune(r))
+		}
+		out.WriteString(strings.Repeat(glyphs.trailingSpace, len(body)-len(trimmed)))
+
+		if crlf {
+			out.WriteString("<CR>")
+		}
+		if strings.HasSuffix(line, "\n") {
+			out.WriteString("<LF>\n")
+		}
+	}
+	return out.String()
+}
+
+// revealRune returns the visible form of a single character
+func revealRune(r rune) string {
+	switch {
+	case r == ' ':
+		return glyphs.space
+	case r == '\t':
+		return "<TAB>"
+	case r == '\r':
+		return "<CR>"
+	case r == '\u00a0':
+		return "<NBSP>"
+	case r > unicode.MaxASCII || !unicode.IsPrint(r):
+		return fmt.Sprintf("<U+%04X>", r)
+	}
+	return string(r)
+}
+
+// hasHiddenChars reports whether s contains anything revealWhitespace would
+// flag beyond ordinary spaces and line breaks
+func hasHiddenChars(s string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasSuffix(strings.TrimSuffix(line, "\r"), " ") {
+			return true
+		}
+		for _, r := range line {
+			if r != ' ' && revealRune(r) != string(r) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRevealWhitespace(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain", "ls -la", "ls·-la"},
+		{"tab", "cut -d\t-f1", "cut·-d<TAB>-f1"},
+		{"trailing spaces", "ls  ", "ls␣␣"},
+		{"non-breaking space", "ls\u00a0-la", "ls<NBSP>-la"},
+		{"zero-width space", "ls\u200b -la", "ls<U+200B>·-la"},
+		{"smart quote", "echo \u201chi\u201d", "echo·<U+201C>hi<U+201D>"},
+		{"control character", "ls\x1b", "ls<U+001B>"},
+		{"multiple lines", "cd src \r\nmake", "cd·src␣<CR><LF>\nmake"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := revealWhitespace(tt.input); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRevealWhitespaceASCII(t *testing.T) {
+	setGlyphs(asciiGlyphs)
+	t.Cleanup(func() { setGlyphs(unicodeGlyphs) })
+
+	if got := revealWhitespace("ls -la "); got != "ls.-la<SP>" {
+		t.Errorf("Expected ASCII markers, got %q", got)
+	}
+}
+
+func TestHasHiddenChars(t *testing.T) {
+	for _, s := range []string{"ls ", "ls\t-la", "ls\u00a0-la", "echo \u2014"} {
+		if !hasHiddenChars(s) {
+			t.Errorf("Expected %q to have hidden characters", s)
+		}
+	}
+	for _, s := range []string{"ls -la", "cd src\nmake"} {
+		if hasHiddenChars(s) {
+			t.Errorf("Expected %q not to have hidden characters", s)
+		}
+	}
+}
+
+func TestRevealToggle(t *testing.T) {
+	var m tea.Model = initialModel("list", false)
+	m, _ = m.Update(cmdGeneratedMsg{cmd: "ls\u00a0-la"})
+	if !strings.Contains(m.(model).View(), "press W to reveal") {
+		t.Error("Expected a warning about hidden characters")
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'w'}})
+	if !m.(model).revealView || !strings.Contains(m.(model).View(), "<NBSP>") {
+		t.Error("Expected W to reveal the non-breaking space")
+	}
+	if m.(model).generatedCmd != "ls\u00a0-la" {
+		t.Error("Expected revealing not to change the command")
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'w'}})
+	if m.(model).revealView {
+		t.Error("Expected W to toggle the view back")
+	}
+}
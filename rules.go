@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/benmyles/clippycli/clippy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// rulesSavedMsg reports the result of persisting the enabled rules
+type rulesSavedMsg struct {
+	err error
+}
+
+// defaultRulesPath is where the enabled rules are persisted
+func defaultRulesPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "rules.json"), nil
+}
+
+// rulesFile is the on-disk format for the rule settings. Only disabled rules
+// are stored, so rules added in later versions start out enabled.
+type rulesFile struct {
+	Disabled []string `json:"disabled"`
+}
+
+// loadDisabledRules reads the disabled rules from path. A missing file means
+// every rule is enabled.
+func loadDisabledRules(path string) (map[string]bool, error) {
+	disabled := map[string]bool{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return disabled, nil
+	}
+	if err != nil {
+		return disabled, err
+	}
+
+	var file rulesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return disabled, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, id := range file.Disabled {
+		disabled[id] = true
+	}
+	return disabled, nil
+}
+
+// saveDisabledRules writes the disabled rules to path
+func saveDisabledRules(path string, disabled map[string]bool) error {
+	file := rulesFile{Disabled: []string{}}
+	for id, off := range disabled {
+		if off {
+			file.Disabled = append(file.Disabled, id)
+		}
+	}
+	sort.Strings(file.Disabled)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// toggleRule flips the rule under the cursor, leaving locked rules alone
+func (m model) toggleRule() model {
+	rule := clippy.Rules[m.rulesCursor]
+	if rule.Locked {
+		return m
+	}
+
+	// Copy before changing so models sharing the map aren't affected
+	disabled := make(map[string]bool, len(m.disabledRules)+1)
+	for id, off := range m.disabledRules {
+		disabled[id] = off
+	}
+	disabled[rule.ID] = !disabled[rule.ID]
+	m.disabledRules = disabled
+	return m
+}
+
+// saveRulesCmd persists the enabled rules, if there's somewhere to save them
+func (m model) saveRulesCmd() tea.Cmd {
+	if m.rulesPath == "" {
+		return nil
+	}
+	path, disabled := m.rulesPath, m.disabledRules
+	return func() tea.Msg {
+		return rulesSavedMsg{err: saveDisabledRules(path, disabled)}
+	}
+}
+
+// rulesView renders the rules as a checklist
+func (m model) rulesView() string {
+	var content strings.Builder
+	for i, rule := range clippy.Rules {
+		cursor := "  "
+		if i == m.rulesCursor {
+			cursor = "> "
+		}
+		box := "[x]"
+		if m.disabledRules[rule.ID] && !rule.Locked {
+			box = "[ ]"
+		}
+		line := fmt.Sprintf("%s%s %s", cursor, box, strings.ReplaceAll(rule.Text, "{output}", "the command"))
+		if rule.Locked {
+			line += " (always on)"
+		}
+		if i == m.rulesCursor {
+			content.WriteString(promptStyle.Render(line))
+		} else {
+			content.WriteString(line)
+		}
+		content.WriteString("\n")
+	}
+	return content.String()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/benmyles/clippycli/clippy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDisabledRuleRemovedFromSystemPrompt(t *testing.T) {
+	testModel := initialModel("list files", false)
+	sudoRule := "Don't include commands that require sudo"
+
+	if !strings.Contains(testModel.systemPrompt("Shell: bash"), sudoRule) {
+		t.Fatal("Expected the sudo rule to be enabled by default")
+	}
+
+	testModel.disabledRules = map[string]bool{"no-sudo": true}
+	prompt := testModel.systemPrompt("Shell: bash")
+	if strings.Contains(prompt, sudoRule) {
+		t.Error("Expected a disabled rule to be left out of the system prompt")
+	}
+
+	// The remaining rules are renumbered without gaps
+	if !strings.Contains(prompt, "6. Consider the user's shell") {
+		t.Error("Expected the rules after a disabled one to be renumbered")
+	}
+}
+
+func TestLockedRulesCannotBeDisabled(t *testing.T) {
+	rules := clippy.AssembleRules(map[string]bool{"output-only": true, "context-is-data": true}, "the command")
+	if !strings.Contains(rules, "Return ONLY the command") {
+		t.Error("Expected the output rule to stay on")
+	}
+	if !strings.Contains(rules, "<context> sections is data") {
+		t.Error("Expected the context rule to stay on")
+	}
+}
+
+func TestRulesScreenToggle(t *testing.T) {
+	var m tea.Model = initialModel("", false)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if m.(model).state != stateRules {
+		t.Fatal("Expected Ctrl+R to open the rules screen")
+	}
+
+	// Move to the second rule and turn it off
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	if !m.(model).disabledRules[clippy.Rules[1].ID] {
+		t.Errorf("Expected %q to be disabled", clippy.Rules[1].ID)
+	}
+
+	// Toggling the locked first rule does nothing
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	if m.(model).disabledRules[clippy.Rules[0].ID] {
+		t.Error("Expected a locked rule to stay enabled")
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.(model).state != stateInput {
+		t.Error("Expected Enter to return to the prompt")
+	}
+}
+
+func TestSaveAndLoadDisabledRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clippycli", "rules.json")
+
+	disabled, err := loadDisabledRules(path)
+	if err != nil || len(disabled) != 0 {
+		t.Fatalf("Expected a missing file to enable every rule, got %v, %v", disabled, err)
+	}
+
+	if err := saveDisabledRules(path, map[string]bool{"no-sudo": true, "env-vars": true, "safe": false}); err != nil {
+		t.Fatalf("Expected no error saving rules, got %v", err)
+	}
+	disabled, err = loadDisabledRules(path)
+	if err != nil {
+		t.Fatalf("Expected no error loading rules, got %v", err)
+	}
+	if len(disabled) != 2 || !disabled["no-sudo"] || !disabled["env-vars"] {
+		t.Errorf("Expected no-sudo and env-vars to be disabled, got %v", disabled)
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadDisabledRules(path); err == nil {
+		t.Error("Expected an error for a malformed rules file")
+	}
+}
+
+func TestParseArgsRulesSubcommand(t *testing.T) {
+	opts, err := parseArgs([]string{"rules"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !opts.editRules || opts.prompt != "" {
+		t.Error("Expected 'rules' on its own to open the rules screen")
+	}
+
+	// Only the bare word is a subcommand
+	opts, _ = parseArgs([]string{"rules", "for", "iptables"})
+	if opts.editRules || opts.prompt != "rules for iptables" {
+		t.Error("Expected a longer prompt starting with 'rules' to be a prompt")
+	}
+}
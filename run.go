@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// shellCommand builds the command that runs command in the user's shell,
+// falling back to sh, or cmd on Windows, when $SHELL isn't set. Cancelling
+// ctx kills it.
+func shellCommand(ctx context.Context, goos, shell, command string) *exec.Cmd {
+	if shell == "" {
+		if goos == "windows" {
+			return exec.CommandContext(ctx, "cmd", "/C", command)
+		}
+		shell = "/bin/sh"
+	}
+	return exec.CommandContext(ctx, shell, "-c", command)
+}
+
+// runInShell runs cmd attached to the given streams and returns its exit
+// code, so ClippyCLI can exit with the same status
+func runInShell(cmd *exec.Cmd, stdin io.Reader, stdout, stderr io.Writer) int {
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+	err := cmd.Run()
+	if code, ok := exitStatus(err); ok {
+		return code
+	}
+	fmt.Fprintf(stderr, "Error: could not run command: %v\n", err)
+	return 1
+}
+
+// exitStatus returns the exit code reported by a finished command's error,
+// or false if it didn't exit by itself, having been killed by a signal or
+// never started at all
+func exitStatus(err error) (int, bool) {
+	if err == nil {
+		return 0, true
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode(), true
+	}
+	return 0, false
+}
+
+// runOrCopy runs the command after the TUI exits if the execution policy
+// allows it, and otherwise copies it, recording why it wasn't run. The copy
+// asks for the same confirmation as Enter would.
+func (m model) runOrCopy() (model, tea.Cmd) {
+	command := m.joinedCommand()
+	if ok, reason := m.execPolicy().permits(command); !ok {
+		m.runRefused = reason
+		return m.copyOrConfirm()
+	}
+	m.runCmd = command
+	return m, tea.Quit
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// outputChrome is the rows the output view uses around the output itself
+	outputChrome = 10
+	// minOutputHeight keeps some output visible in a very short terminal
+	minOutputHeight = 5
+	// stopWait is how long a stopped command's children get to let go of its
+	// output before it's given up on
+	stopWait = time.Second
+)
+
+// errRunStopped reports a command run with ! that was stopped with Ctrl+C
+var errRunStopped = errors.New("stopped")
+
+// runOutput collects a command's stdout and stderr as they're written, so the
+// output view can show them while it runs
+type runOutput struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *runOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Write(p)
+}
+
+func (o *runOutput) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+// runFinishedMsg reports that the command run with ! has exited
+type runFinishedMsg struct {
+	exitCode int
+	err      error // It was stopped, killed or couldn't be started
+}
+
+// shellName is the shell commands run in, for the confirmation question
+func shellName() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return filepath.Base(shell)
+	}
+	if runtime.GOOS == "windows" {
+		return "cmd"
+	}
+	return "sh"
+}
+
+// runConfirmView asks before running the command. Dangerous commands never
+// get this far, since the execution policy refuses them.
+func (m model) runConfirmView() string {
+	var content strings.Builder
+	content.WriteString(promptStyle.Render(fmt.Sprintf("Run this command in %s?", shellName())))
+	content.WriteString("\n\n")
+	content.WriteString(m.box(cmdStyle, m.displayCommand(m.joinedCommand())))
+	content.WriteString("\n")
+	content.WriteString(m.helpFooter())
+	return content.String()
+}
+
+// confirmRunInline asks before running the command with !, unless the
+// execution policy won't run it anyway, in which case it says why at once
+func (m model) confirmRunInline() model {
+	if ok, reason := m.execPolicy().permits(m.joinedCommand()); !ok {
+		m.inlineRefused = reason
+		return m
+	}
+	m.state = stateRunConfirm
+	return m
+}
+
+// runInline runs the command in the user's shell without leaving the TUI,
+// capturing its output for the output view. The execution policy is checked
+// first, as for running after exit, so dangerous commands and downloaded
+// scripts are never run.
+func (m model) runInline() (model, tea.Cmd) {
+	command := m.joinedCommand()
+	if ok, reason := m.execPolicy().permits(command); !ok {
+		m.state = stateResult
+		m.inlineRefused = reason
+		return m, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	output := &runOutput{}
+	cmd := shellCommand(ctx, runtime.GOOS, os.Getenv("SHELL"), command)
+	cmd.Stdout, cmd.Stderr = output, output
+	// Children left holding the output open mustn't keep it running forever
+	cmd.WaitDelay = stopWait
+
+	m.state = stateOutput
+	m.inlineRefused = ""
+	m.inlineOutput = output
+	m.inlineCancel = cancel
+	m.inlineRunning = true
+	m.inlineExitCode = 0
+	m.inlineErr = nil
+	m.outputView = viewport.New(m.outputWidth(), m.outputHeight())
+	run := func() tea.Msg {
+		err := cmd.Run()
+		stopped := ctx.Err() != nil
+		cancel()
+		if code, ok := exitStatus(err); ok && !stopped {
+			return runFinishedMsg{exitCode: code}
+		}
+		if stopped {
+			err = errRunStopped
+		}
+		return runFinishedMsg{exitCode: -1, err: err}
+	}
+	return m, tea.Batch(m.spinner.Tick, run)
+}
+
+// stopInline kills the running command, which then reports itself finished
+func (m model) stopInline() model {
+	if m.inlineCancel != nil {
+		m.inlineCancel()
+	}
+	return m
+}
+
+// outputWidth and outputHeight size the scrolling output to the terminal
+func (m model) outputWidth() int {
+	if w := m.contentWidth(); w > 0 {
+		return w
+	}
+	return defaultMaxWidth
+}
+
+func (m model) outputHeight() int {
+	if m.height <= 0 {
+		return 20
+	}
+	return max(minOutputHeight, m.height-outputChrome)
+}
+
+// refreshOutput shows what the command has written so far, following the
+// end unless the user has scrolled back
+func (m model) refreshOutput() model {
+	if m.inlineOutput == nil {
+		return m
+	}
+	following := m.outputView.AtBottom()
+	m.outputView.SetContent(strings.TrimRight(m.inlineOutput.String(), "\n"))
+	if following {
+		m.outputView.GotoBottom()
+	}
+	return m
+}
+
+// outputStatus says whether the command is still running or how it ended
+func (m model) outputStatus() string {
+	switch {
+	case m.inlineRunning:
+		return m.spinner.View() + " Running " + m.joinedCommand()
+	case errors.Is(m.inlineErr, errRunStopped):
+		return errorStyle.Render("Stopped")
+	case m.inlineErr != nil:
+		return errorStyle.Render("Error: could not run command: " + m.inlineErr.Error())
+	case m.inlineExitCode == 0:
+		return promptStyle.Render(glyphs.check + " Exited with code 0")
+	}
+	return errorStyle.Render(fmt.Sprintf("Exited with code %d", m.inlineExitCode))
+}
+
+// outputScreen shows the command's output as it runs, scrollable once it's
+// longer than the screen
+func (m model) outputScreen() string {
+	var content strings.Builder
+	content.WriteString(m.outputStatus())
+	content.WriteString("\n\n")
+	if m.inlineOutput == nil || m.inlineOutput.String() == "" {
+		content.WriteString(dimStyle.Render("(no output)"))
+	} else {
+		content.WriteString(m.outputView.View())
+	}
+	content.WriteString("\n\n")
+	content.WriteString(m.helpFooter())
+	return content.String()
+}
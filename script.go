@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// scriptSavedMsg reports the result of saving a generated script
+type scriptSavedMsg struct {
+	path string
+	err  error
+}
+
+// scriptTask replaces the one-off command task in the system prompt when
+// generating a reusable script
+const scriptTask = `You are a helpful command-line assistant. Given a user's description of what they want to do, generate a small, safe, reusable shell script that accomplishes their goal.
+
+The script must:
+- Start with a shebang line for the user's shell
+- Take the values a user would want to vary as positional arguments ($1, $2, ...) instead of hardcoding them
+- Print a usage message and exit non-zero when required arguments are missing or -h/--help is passed`
+
+// commentStyles are the instructions added to scriptTask for each
+// --comment-style. Without one, the model decides how much to comment.
+var commentStyles = map[string]string{
+	"none":    "Contain no comments other than the shebang line",
+	"minimal": "Keep comments to a one-line summary at the top and a note on anything non-obvious",
+	"verbose": "Comment every step, explaining what it does and why, for someone learning shell scripting",
+}
+
+// saveScript writes script to a new executable file at path. It won't
+// replace a file that's already there, and reports os.ErrExist instead.
+func saveScript(path, script string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(script + "\n"); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// saveScriptCmd saves the generated script without blocking the UI
+func (m model) saveScriptCmd(path string) tea.Cmd {
+	script := m.generatedCmd
+	return func() tea.Msg {
+		return scriptSavedMsg{path: path, err: saveScript(path, script)}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAsScriptPrompt(t *testing.T) {
+	testModel := initialModel("back up a directory", false)
+
+	prompt := testModel.systemPrompt("Shell: /bin/bash")
+	if strings.Contains(prompt, "reusable shell script") {
+		t.Error("Expected the default prompt to ask for a single command")
+	}
+	if !strings.Contains(prompt, "Examples:") {
+		t.Error("Expected the default prompt to include command examples")
+	}
+
+	testModel.opts.asScript = true
+	prompt = testModel.systemPrompt("Shell: /bin/bash")
+	for _, want := range []string{"reusable shell script", "$1", "usage message", "Return ONLY the script"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected the script prompt to contain %q", want)
+		}
+	}
+	if strings.Contains(prompt, "Examples:") {
+		t.Error("Expected the script prompt to omit one-liner examples")
+	}
+}
+
+func TestSavedScriptIsExecutable(t *testing.T) {
+	testModel := initialModel("back up a directory", false)
+	testModel.opts.asScript = true
+	updatedModel, _ := testModel.Update(cmdGeneratedMsg{cmd: "#!/bin/sh\ntar czf \"$2\" \"$1\""})
+	m := updatedModel.(model)
+
+	// Scripts are copied whole rather than split into steps
+	if len(m.steps()) != 0 {
+		t.Errorf("Expected scripts to have no steps, got %d", len(m.steps()))
+	}
+
+	path := filepath.Join(t.TempDir(), "backup.sh")
+	msg, ok := m.saveScriptCmd(path)().(scriptSavedMsg)
+	if !ok || msg.err != nil {
+		t.Fatalf("Expected the script to be saved, got %+v", msg)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat saved script: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+		t.Errorf("Expected saved script to be executable, got mode %v", info.Mode())
+	}
+
+	updatedModel, _ = m.Update(msg)
+	if !strings.Contains(updatedModel.View(), "Saved executable script to "+path) {
+		t.Error("Expected the result view to confirm the saved script")
+	}
+}
+
+func TestSaveScriptKeepsExistingFile(t *testing.T) {
+	testModel := initialModel("back up a directory", false)
+	testModel.opts.asScript = true
+	updatedModel, _ := testModel.Update(cmdGeneratedMsg{cmd: "#!/bin/sh\ntar czf \"$2\" \"$1\""})
+
+	path := filepath.Join(t.TempDir(), "script.sh")
+	if err := os.WriteFile(path, []byte("keep me\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m := updatedModel.(model)
+	m.textarea.SetValue(path)
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	for _, msg := range collectMsgs(cmd) {
+		updatedModel, _ = updatedModel.Update(msg)
+	}
+
+	m = updatedModel.(model)
+	if m.state != stateSaveScript || m.textarea.Value() != path {
+		t.Errorf("Expected to be asked for another name, got state %d", m.state)
+	}
+	if !strings.Contains(m.View(), "A file already exists there") {
+		t.Error("Expected the save prompt to say the file exists")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "keep me\n" {
+		t.Errorf("Expected the existing file to be left alone, got %q", data)
+	}
+}
+
+func TestCommentStylePrompt(t *testing.T) {
+	for style, instruction := range commentStyles {
+		opts, err := parseArgs([]string{"--as-script", "--comment-style", style, "back up a directory"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		testModel := newModel(opts)
+		prompt := testModel.systemPrompt("Shell: /bin/bash")
+		if !strings.Contains(prompt, "- "+instruction) {
+			t.Errorf("Expected the %s prompt to contain %q", style, instruction)
+		}
+		for other, otherInstruction := range commentStyles {
+			if other != style && strings.Contains(prompt, otherInstruction) {
+				t.Errorf("Expected the %s prompt not to contain the %s instruction", style, other)
+			}
+		}
+
+		// The applied instruction is shown in verbose mode
+		testModel.verbose = true
+		testModel.state = stateResult
+		testModel.generatedCmd = "#!/bin/bash"
+		if !strings.Contains(testModel.View(), "Comment style ("+style+")") {
+			t.Errorf("Expected verbose mode to show the %s comment style", style)
+		}
+	}
+
+	// One-off commands aren't scripts, so the style doesn't apply
+	testModel := initialModel("back up a directory", false)
+	testModel.opts.commentStyle = "verbose"
+	if strings.Contains(testModel.systemPrompt("Shell: /bin/bash"), commentStyles["verbose"]) {
+		t.Error("Expected the comment style to apply only to scripts")
+	}
+
+	if _, err := parseArgs([]string{"--comment-style", "chatty"}); err == nil {
+		t.Error("Expected an unknown comment style to be rejected")
+	}
+}
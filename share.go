@@ -0,0 +1,13 @@
+package main
+
+import "net/url"
+
+// explainShellURL is the base of the links produced by --url-encode
+const explainShellURL = "https://explainshell.com/explain"
+
+// toShareLink turns cmd into an explainshell.com link. Percent-encoding keeps
+// pipes, quotes, and spaces intact when pasted into chat tools that would
+// otherwise mangle them, and the link explains the command to whoever opens it.
+func toShareLink(cmd string) string {
+	return explainShellURL + "?" + url.Values{"cmd": {cmd}}.Encode()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestToShareLink(t *testing.T) {
+	cmd := `ps aux | grep "my app" && echo done`
+	link := toShareLink(cmd)
+
+	expected := "https://explainshell.com/explain?cmd=ps+aux+%7C+grep+%22my+app%22+%26%26+echo+done"
+	if link != expected {
+		t.Errorf("Expected %q, got %q", expected, link)
+	}
+
+	// The link must decode back to exactly the original command
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("Expected a valid URL, got %v", err)
+	}
+	if got := parsed.Query().Get("cmd"); got != cmd {
+		t.Errorf("Expected the link to decode to %q, got %q", cmd, got)
+	}
+}
+
+func TestURLEncodeCopiesShareLink(t *testing.T) {
+	written := stubClipboard(t)
+
+	testModel := initialModel("find processes", false)
+	testModel.opts.urlEncode = true
+	testModel.generatedCmd = "ps aux | grep node"
+
+	msg := testModel.executeCommand()().(cmdCopiedMsg)
+	if msg.cmd != toShareLink("ps aux | grep node") || *written != msg.cmd {
+		t.Errorf("Expected the share link to be copied, got %q", *written)
+	}
+
+	// The result view still shows the readable command
+	testModel.state = stateResult
+	if view := testModel.View(); !strings.Contains(view, "ps aux | grep node") {
+		t.Error("Expected the command to be displayed unencoded")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// shellNames matches the shells a prompt can ask for by name
+const shellNames = `bash|zsh|fish|powershell|pwsh|ksh|dash|tcsh|csh|sh|cmd\.exe|cmd`
+
+// explicitShellRes match a prompt asking for a specific shell, like "in zsh"
+// or "a bash script". A bare mention isn't enough: "find fish photos" isn't
+// asking for fish syntax.
+var explicitShellRes = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(?:in|using|with|for)\s+(?:a\s+|the\s+)?(` + shellNames + `)\b`),
+	regexp.MustCompile(`(?i)\b(` + shellNames + `)\s+(?:script|command|syntax|one-?liner|function|alias|loop|shell)s?\b`),
+}
+
+// shellAliases maps alternate names to the shell they refer to
+var shellAliases = map[string]string{
+	"pwsh":    "powershell",
+	"cmd.exe": "cmd",
+}
+
+// shellFromPrompt returns the shell the prompt explicitly asks for, which
+// takes priority over the detected $SHELL. When several are named, the first
+// one mentioned wins.
+func shellFromPrompt(prompt string) (string, bool) {
+	best, bestPos := "", -1
+	for _, re := range explicitShellRes {
+		if loc := re.FindStringSubmatchIndex(prompt); loc != nil && (bestPos < 0 || loc[2] < bestPos) {
+			best, bestPos = strings.ToLower(prompt[loc[2]:loc[3]]), loc[2]
+		}
+	}
+	if bestPos < 0 {
+		return "", false
+	}
+	if alias, ok := shellAliases[best]; ok {
+		best = alias
+	}
+	return best, true
+}
+
+// maxShellVersionLen caps the version line, in case a shell prints a banner
+const maxShellVersionLen = 120
+
+// shellVersionCache remembers each shell's version line for the life of the
+// process
+var shellVersionCache sync.Map
+
+// shellVersion returns the first line of "shell --version", like "GNU bash,
+// version 3.2.57(1)-release", or "" if the shell doesn't support it
+func shellVersion(shell string) string {
+	if cached, ok := shellVersionCache.Load(shell); ok {
+		return cached.(string)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var version string
+	// Shells without --version, like dash, fail or try to run it as a script
+	if out, err := exec.CommandContext(ctx, shell, "--version").Output(); err == nil {
+		line, _, _ := strings.Cut(string(out), "\n")
+		version = strings.TrimSpace(line)
+		if len(version) > maxShellVersionLen {
+			version = version[:maxShellVersionLen]
+		}
+	}
+
+	shellVersionCache.Store(shell, version)
+	return version
+}
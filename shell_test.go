@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestShellFromPrompt(t *testing.T) {
+	tests := []struct {
+		prompt string
+		shell  string
+		found  bool
+	}{
+		{"write a bash script that backs up my home directory", "bash", true},
+		{"loop over files in zsh", "zsh", true},
+		{"set an environment variable using PowerShell", "powershell", true},
+		{"list services with pwsh", "powershell", true},
+		{"delete temp files in cmd.exe", "cmd", true},
+		{"a POSIX sh script to check disk space", "sh", true},
+		{"a fish function to jump to my projects", "fish", true},
+		{"bash one-liner for zsh users", "bash", true},
+		{"find fish photos in my pictures", "", false},
+		{"list all files", "", false},
+		{"connect using ssh to my server", "", false},
+		{"open the command line history", "", false},
+	}
+
+	for _, tt := range tests {
+		shell, found := shellFromPrompt(tt.prompt)
+		if shell != tt.shell || found != tt.found {
+			t.Errorf("shellFromPrompt(%q): expected (%q, %v), got (%q, %v)", tt.prompt, tt.shell, tt.found, shell, found)
+		}
+	}
+}
+
+func TestPromptShellOverridesDetected(t *testing.T) {
+	// A fish that isn't installed, so there's no version to detect
+	fish := filepath.Join(t.TempDir(), "fish")
+	t.Setenv("SHELL", fish)
+
+	envInfo := getEnvironmentInfo(envOptions{prompt: "write a bash script to rotate logs"})
+	if !strings.Contains(envInfo, "Shell: bash (requested in the prompt; overrides the detected shell "+fish+")") {
+		t.Errorf("Expected the requested shell to override fish, got %q", strings.SplitN(envInfo, "\n", 2)[0])
+	}
+
+	envInfo = getEnvironmentInfo(envOptions{prompt: "rotate logs"})
+	if !strings.Contains(envInfo, "Shell: "+fish+"\n") {
+		t.Error("Expected the detected shell when the prompt doesn't name one")
+	}
+
+	// Asking for the shell you're already in isn't an override
+	envInfo = getEnvironmentInfo(envOptions{prompt: "a fish function to rotate logs"})
+	if !strings.Contains(envInfo, "Shell: "+fish+"\n") {
+		t.Error("Expected no override note when the prompt names the detected shell")
+	}
+}
+
+func TestShellVersionDetected(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script to stand in for the shell")
+	}
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "bash")
+	script := "#!/bin/sh\necho 'GNU bash, version 3.2.57(1)-release (arm64-apple-darwin23)'\necho 'Copyright (C) 2007 Free Software Foundation, Inc.'\n"
+	if err := os.WriteFile(fake, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SHELL", fake)
+
+	envInfo := getEnvironmentInfo(envOptions{})
+	if !strings.Contains(envInfo, "Shell: "+fake+" (GNU bash, version 3.2.57(1)-release (arm64-apple-darwin23))\n") {
+		t.Errorf("Expected the first line of the version, got %q", strings.SplitN(envInfo, "\n", 2)[0])
+	}
+
+	// Shells that can't report a version are still named
+	failing := filepath.Join(dir, "dash")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\nexit 2\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SHELL", failing)
+	if envInfo := getEnvironmentInfo(envOptions{}); !strings.Contains(envInfo, "Shell: "+failing+"\n") {
+		t.Errorf("Expected just the shell's path, got %q", strings.SplitN(envInfo, "\n", 2)[0])
+	}
+}
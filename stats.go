@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// getSystemStats summarizes CPU, memory, and disk usage for performance-related
+// prompts, omitting anything that can't be determined on this platform
+func getSystemStats() string {
+	var stats strings.Builder
+
+	stats.WriteString("System stats:\n")
+	stats.WriteString(fmt.Sprintf("CPU cores: %d\n", runtime.NumCPU()))
+
+	if total, ok := totalMemory(); ok {
+		stats.WriteString(fmt.Sprintf("Total memory: %s\n", formatBytes(total)))
+	}
+
+	if total, free, ok := diskUsage("."); ok {
+		stats.WriteString(fmt.Sprintf("Disk (current filesystem): %s used of %s, %s free\n",
+			formatBytes(total-free), formatBytes(total), formatBytes(free)))
+	}
+
+	return strings.TrimRight(stats.String(), "\n")
+}
+
+// formatBytes renders a byte count using binary units
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package main
+
+// totalMemory is not implemented on this platform
+func totalMemory() (uint64, bool) {
+	return 0, false
+}
+
+// diskUsage is not implemented on this platform
+func diskUsage(path string) (total, free uint64, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSystemStatsSection(t *testing.T) {
+	// Stats should be omitted unless explicitly requested
+	info := getEnvironmentInfo(envOptions{})
+	if strings.Contains(info, "System stats:") {
+		t.Error("Expected system stats to be omitted by default")
+	}
+
+	info = getEnvironmentInfo(envOptions{systemStats: true})
+	if !strings.Contains(info, "System stats:") {
+		t.Fatal("Expected system stats section when enabled")
+	}
+	if !strings.Contains(info, "CPU cores:") {
+		t.Error("Expected CPU core count in system stats")
+	}
+
+	// Memory and disk are only available on supported platforms
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		if !strings.Contains(info, "Total memory:") {
+			t.Error("Expected total memory in system stats")
+		}
+		if !strings.Contains(info, "Disk (current filesystem):") {
+			t.Error("Expected disk usage in system stats")
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n        uint64
+		expected string
+	}{
+		{512, "512 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{8 * 1024 * 1024 * 1024, "8.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		if result := formatBytes(tt.n); result != tt.expected {
+			t.Errorf("formatBytes(%d) = %q; want %q", tt.n, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxStdinPrompt caps how much piped input is read as the prompt
+const maxStdinPrompt = 64 * 1024
+
+// isRedirected reports whether f is a pipe or file rather than a terminal
+func isRedirected(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// readStdinPrompt reads a prompt piped in, as in
+// echo "find duplicate files" | clippycli
+func readStdinPrompt(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxStdinPrompt))
+	if err != nil {
+		return "", err
+	}
+	prompt := strings.TrimSpace(string(data))
+	if prompt == "" {
+		return "", errors.New("nothing was piped in to use as the prompt; pipe in a description, or pass one as an argument")
+	}
+	return prompt, nil
+}
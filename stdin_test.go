@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadStdinPrompt(t *testing.T) {
+	prompt, err := readStdinPrompt(strings.NewReader("  find duplicate files\n"))
+	if err != nil || prompt != "find duplicate files" {
+		t.Errorf("Expected the trimmed prompt, got %q (err %v)", prompt, err)
+	}
+
+	if _, err := readStdinPrompt(strings.NewReader(" \n\n")); err == nil || !strings.Contains(err.Error(), "nothing was piped in") {
+		t.Errorf("Expected a helpful error for empty input, got %v", err)
+	}
+}
+
+func TestStdinIsPiped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.txt")
+	if err := os.WriteFile(path, []byte("list files"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if !isRedirected(f) {
+		t.Error("Expected a redirected file to count as piped input")
+	}
+
+	if devNull, err := os.Open(os.DevNull); err == nil {
+		defer devNull.Close()
+		if isRedirected(devNull) {
+			t.Error("Expected a character device not to count as piped input")
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import "strings"
+
+// joinMode controls how multiple steps are combined into one command
+type joinMode int
+
+const (
+	joinNewline   joinMode = iota // One step per line
+	joinAnd                       // Stop at the first failing step
+	joinSemicolon                 // Run every step regardless of failures
+)
+
+// String returns the separator shown to the user for the join mode
+func (j joinMode) String() string {
+	switch j {
+	case joinAnd:
+		return "&&"
+	case joinSemicolon:
+		return ";"
+	default:
+		return "newline"
+	}
+}
+
+// next cycles to the following join mode
+func (j joinMode) next() joinMode {
+	return (j + 1) % 3
+}
+
+// joinSteps combines steps using the given join mode. Trailing separators
+// already on a step are replaced, while continuations like a trailing pipe
+// are kept so the step flows into the next one. A trailing backslash is
+// dropped when joining onto one line, where it would escape the space.
+func joinSteps(steps []string, mode joinMode) string {
+	var joined strings.Builder
+
+	for i, step := range steps {
+		step = strings.TrimSpace(step)
+		continuation := false
+
+		switch {
+		case strings.HasSuffix(step, "&&") && !strings.HasSuffix(step, `\&&`):
+			step = strings.TrimSpace(strings.TrimSuffix(step, "&&"))
+		case strings.HasSuffix(step, ";") && !strings.HasSuffix(step, `\;`):
+			step = strings.TrimSpace(strings.TrimSuffix(step, ";"))
+		case strings.HasSuffix(step, "|"):
+			continuation = true
+		case strings.HasSuffix(step, "\\"):
+			continuation = true
+			if mode != joinNewline {
+				step = strings.TrimSpace(strings.TrimSuffix(step, "\\"))
+			}
+		}
+
+		joined.WriteString(step)
+		if i == len(steps)-1 {
+			break
+		}
+
+		switch {
+		case mode == joinNewline:
+			joined.WriteString("\n")
+		case continuation:
+			joined.WriteString(" ")
+		case mode == joinAnd:
+			joined.WriteString(" && ")
+		default:
+			joined.WriteString("; ")
+		}
+	}
+
+	return joined.String()
+}
+
+// splitSteps splits a multi-line command into its non-empty steps
+func splitSteps(cmd string) []string {
+	var steps []string
+	for _, line := range strings.Split(cmd, "\n") {
+		if strings.TrimSpace(line) != "" {
+			steps = append(steps, strings.TrimSpace(line))
+		}
+	}
+	return steps
+}
+
+var (
+	// blockClosers are words that continue or end a construct begun on an
+	// earlier line, like done or fi, so can't start a command of their own
+	blockClosers = map[string]bool{"then": true, "else": true, "elif": true, "fi": true, "do": true, "done": true, "esac": true, "}": true, ")": true}
+	// blockOpeners are words that leave a construct open for the lines after
+	blockOpeners = map[string]bool{"then": true, "else": true, "do": true, "in": true, "{": true, "(": true}
+)
+
+// standaloneSteps reports whether every step is a complete command, so the
+// steps can be joined with && or ; like separate commands. Lines of a loop,
+// if, case or function spread over several lines aren't, and neither are
+// heredocs or quotes left open across lines.
+func standaloneSteps(steps []string) bool {
+	for _, step := range steps {
+		fields := strings.Fields(step)
+		if len(fields) == 0 {
+			continue
+		}
+		last := strings.TrimSuffix(fields[len(fields)-1], ";")
+		if blockClosers[strings.TrimSuffix(fields[0], ";")] || blockOpeners[last] || strings.HasSuffix(last, "{") {
+			return false
+		}
+		if strings.Contains(step, ";;") || strings.Contains(strings.ReplaceAll(step, "<<<", ""), "<<") || hasOpenQuote(step) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasOpenQuote reports whether s ends inside a quote that a later line closes
+func hasOpenQuote(s string) bool {
+	var quote byte
+	escaped := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		}
+	}
+	return quote != 0
+}
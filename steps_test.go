@@ -0,0 +1,138 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestJoinSteps(t *testing.T) {
+	steps := []string{"mkdir build", "cd build", "cmake .."}
+
+	tests := []struct {
+		mode     joinMode
+		expected string
+	}{
+		{joinNewline, "mkdir build\ncd build\ncmake .."},
+		{joinAnd, "mkdir build && cd build && cmake .."},
+		{joinSemicolon, "mkdir build; cd build; cmake .."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			if result := joinSteps(steps, tt.mode); result != tt.expected {
+				t.Errorf("joinSteps(%v) = %q; want %q", tt.mode, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJoinStepsWithTrailingOperators(t *testing.T) {
+	tests := []struct {
+		name     string
+		steps    []string
+		mode     joinMode
+		expected string
+	}{
+		{"trailing and replaced", []string{"make &&", "make install"}, joinSemicolon, "make; make install"},
+		{"trailing semicolon replaced", []string{"cd src;", "ls"}, joinAnd, "cd src && ls"},
+		{"pipe continues", []string{"ps aux |", "grep go"}, joinAnd, "ps aux | grep go"},
+		{"backslash continues", []string{"find . \\", "-name x"}, joinSemicolon, "find . -name x"},
+		{"newline keeps backslash", []string{"find . \\", "-name x"}, joinNewline, "find . \\\n-name x"},
+		{"newline keeps pipe", []string{"ps aux |", "grep go"}, joinNewline, "ps aux |\ngrep go"},
+		{"escaped semicolon kept", []string{`find . -exec rm {} \;`, "ls"}, joinAnd, `find . -exec rm {} \; && ls`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := joinSteps(tt.steps, tt.mode); result != tt.expected {
+				t.Errorf("joinSteps(%q, %v) = %q; want %q", tt.steps, tt.mode, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStandaloneSteps(t *testing.T) {
+	tests := []struct {
+		cmd        string
+		standalone bool
+	}{
+		{"mkdir build\ncd build\ncmake ..", true},
+		{"ps aux |\ngrep go", true},
+		{"for f in *.log; do gzip \"$f\"; done\nls", true},
+		{"for f in *; do\n  echo $f\ndone", false},
+		{"if [ -d build ]\nthen\n  rm build\nfi", false},
+		{"while read line; do\necho $line\ndone < list", false},
+		{"case $1 in\n  start) run ;;\nesac", false},
+		{"setup() {\n  mkdir build\n}", false},
+		{"cat <<EOF > notes\nhello\nEOF", false},
+		{"echo 'first\nsecond'", false},
+	}
+	for _, tt := range tests {
+		if got := standaloneSteps(splitSteps(tt.cmd)); got != tt.standalone {
+			t.Errorf("%q: expected standalone=%v, got %v", tt.cmd, tt.standalone, got)
+		}
+	}
+}
+
+func TestJoinNeedsStandaloneSteps(t *testing.T) {
+	testModel := initialModel("compress logs", false)
+	updatedModel, _ := testModel.Update(cmdGeneratedMsg{cmd: "for f in *; do\n  echo $f\ndone"})
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	m := updatedModel.(model)
+	if m.joinMode != joinNewline || strings.Contains(m.View(), "to change join") {
+		t.Error("Expected j to be unavailable for a loop spread over several lines")
+	}
+
+	// A join chosen earlier doesn't apply to a command that can't be joined
+	m.joinMode = joinAnd
+	if m.joinedCommand() != m.generatedCmd {
+		t.Errorf("Expected the loop to be copied as is, got %q", m.joinedCommand())
+	}
+}
+
+func TestCycleJoinMode(t *testing.T) {
+	testModel := initialModel("build project", false)
+	updatedModel, _ := testModel.Update(cmdGeneratedMsg{cmd: "mkdir build\ncd build"})
+
+	// Pressing j should cycle the join mode and preview the result
+	updatedModel, _ = updatedModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	m := updatedModel.(model)
+	if m.joinMode != joinAnd {
+		t.Fatalf("Expected join mode to be &&, got %v", m.joinMode)
+	}
+
+	view := m.View()
+	if !strings.Contains(view, "mkdir build && cd build") {
+		t.Error("Expected the view to preview the joined steps")
+	}
+	if !strings.Contains(view, "join: &&") {
+		t.Error("Expected the footer to show the current join mode")
+	}
+}
+
+func TestMultiLineCommandCopiedVerbatim(t *testing.T) {
+	written := stubClipboard(t)
+	reply := "for f in *.log; do\n  gzip \"$f\"\ndone\n"
+
+	m := initialModel("compress every log file", false)
+	m.generator = fakeGenerator{text: reply}
+	msg := m.generateCommand()().(cmdGeneratedMsg)
+	if msg.cmd != "for f in *.log; do\n  gzip \"$f\"\ndone" {
+		t.Fatalf("Expected the lines to be kept, got %q", msg.cmd)
+	}
+
+	updated, _ := m.Update(msg)
+	view := updated.View()
+	for _, line := range []string{"for f in *.log; do", `gzip "$f"`, "done"} {
+		if !strings.Contains(view, line) {
+			t.Errorf("Expected the result box to show %q, got %q", line, view)
+		}
+	}
+
+	copied := updated.(model).executeCommand()().(cmdCopiedMsg)
+	if copied.err != nil || *written != msg.cmd {
+		t.Errorf("Expected the newlines and indentation to be copied, got %q (err %v)", *written, copied.err)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// streamInterrupted decides how a failed stream is reported: with output
+// already received it's an interruption the user can recover from, otherwise
+// it's an ordinary error
+func streamInterrupted(partial string, err error) bool {
+	return err != nil && strings.TrimSpace(partial) != ""
+}
+
+// isConnectionLost reports whether err looks like the network dropped, as
+// opposed to the API rejecting the request
+func isConnectionLost(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"syscall"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestStreamDropKeepsPartialOutput(t *testing.T) {
+	dropped := fmt.Errorf("reading stream: %w", syscall.ECONNRESET)
+	reply, err := fakeGenerator{text: "find . -na", err: dropped}.Generate(context.Background(), "", nil, nil)
+	if !streamInterrupted(reply.Text, err) {
+		t.Fatal("Expected a drop after some output to count as an interruption")
+	}
+	if !isConnectionLost(err) {
+		t.Error("Expected a reset connection to be reported as lost")
+	}
+
+	// The user is offered retrying or keeping what arrived
+	testModel := initialModel("find go files", false)
+	updatedModel, _ := testModel.Update(generationInterruptedMsg{partial: reply.Text, err: err})
+	m := updatedModel.(model)
+	if m.state != stateInterrupted || m.partialCmd != "find . -na" {
+		t.Fatalf("Expected the interrupted state with the partial output, got state %v and %q", m.state, m.partialCmd)
+	}
+	view := m.View()
+	for _, want := range []string{"Connection lost", "R/S to start over", "K to keep what arrived"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected the view to contain %q, got %q", want, view)
+		}
+	}
+
+	// Keeping the partial output shows it as the result
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+	if cmd == nil {
+		t.Fatal("Expected keeping the partial output to produce a result")
+	}
+	msg, ok := cmd().(cmdGeneratedMsg)
+	if !ok || msg.cmd != "find . -na" {
+		t.Fatalf("Expected the partial output as the generated command, got %+v", msg)
+	}
+	updatedModel, _ = updatedModel.Update(msg)
+	if got := updatedModel.(model); got.state != stateResult || got.generatedCmd != "find . -na" {
+		t.Errorf("Expected the result view with the partial command, got state %v and %q", got.state, got.generatedCmd)
+	}
+
+	// Retrying starts a fresh generation
+	updatedModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if got := updatedModel.(model); got.state != stateLoading || got.partialCmd != "" {
+		t.Error("Expected R to retry from scratch")
+	}
+}
+
+func TestStreamFailureWithoutOutput(t *testing.T) {
+	if streamInterrupted("", io.ErrUnexpectedEOF) {
+		t.Error("Expected a failure before any output to be an ordinary error")
+	}
+	if isConnectionLost(errors.New("invalid x-api-key")) {
+		t.Error("Expected an API error not to count as a lost connection")
+	}
+}
+
+func TestKeepPartialNeedsPlainReplies(t *testing.T) {
+	m := initialModel("find go files", false)
+	m.state = stateInterrupted
+	m.opts.withUndo = true
+	if m.keyAction("k") != actionNone {
+		t.Error("Expected an incomplete structured reply not to be kept")
+	}
+}
+
+func TestStreamedTextShownLive(t *testing.T) {
+	m := initialModel("find go files", false)
+	m.generator = fakeGenerator{text: "find . -name '*.go'"}
+
+	// Run the generation first, as the listener only sees the latest text
+	done := m.generateCommand()()
+	var updated tea.Model = m
+
+	// The last update is the end of the generation
+	if msg := m.live.listen()().(cmdStreamChunkMsg); !msg.done {
+		t.Fatalf("Expected the listener to be released when the generation ends, got %+v", msg)
+	}
+
+	updated, cmd := updated.Update(cmdStreamChunkMsg{text: "find . -na"})
+	if got := updated.(model); got.state != stateStreaming || cmd == nil {
+		t.Fatalf("Expected streamed text to switch to the streaming view and keep listening, got state %v", got.state)
+	}
+	if view := updated.View(); !strings.Contains(view, "find . -na") {
+		t.Errorf("Expected the text so far to be shown, got %q", view)
+	}
+
+	updated, _ = updated.Update(done)
+	if got := updated.(model); got.state != stateResult || got.generatedCmd != "find . -name '*.go'" {
+		t.Fatalf("Expected the finished command in the result view, got state %v and %q", got.state, got.generatedCmd)
+	}
+
+	// Stragglers after the result are ignored
+	updated, cmd = updated.Update(cmdStreamChunkMsg{text: "find"})
+	if updated.(model).state != stateResult || cmd != nil {
+		t.Error("Expected text arriving after the result to be ignored")
+	}
+}
+
+func TestQuitWhileStreamingCancels(t *testing.T) {
+	m := initialModel("find go files", false)
+	ctx, _ := m.live.begin()
+	m.state = stateStreaming
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if cmd == nil {
+		t.Fatal("Expected Ctrl+C to quit")
+	}
+	if ctx.Err() == nil {
+		t.Error("Expected quitting to cancel the request")
+	}
+}
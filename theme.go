@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// theme holds the colors the UI is drawn in
+type theme struct {
+	title             lipgloss.Color // Title and spinner
+	prompt            lipgloss.Color // Headings and success messages
+	command           lipgloss.Color // Text of the command box
+	commandBackground lipgloss.Color
+	border            lipgloss.Color // Border of the command box
+	help              lipgloss.Color // Help text and the prompt being generated for
+	err               lipgloss.Color // Errors and removed words in diffs
+	dim               lipgloss.Color // Secondary details and their borders
+	secondary         lipgloss.Color // Text of the secondary and verbose boxes
+	verboseBackground lipgloss.Color
+	critique          lipgloss.Color
+	critiqueBorder    lipgloss.Color
+	syntaxCommand     lipgloss.Color // Highlighted program names in the command box
+	syntaxFlag        lipgloss.Color
+	syntaxString      lipgloss.Color
+	syntaxOperator    lipgloss.Color // Pipes, &&, ; and redirections
+}
+
+var (
+	darkTheme = theme{
+		title:             "#7C3AED",
+		prompt:            "#059669",
+		command:           "#F9FAFB",
+		commandBackground: "#1F2937",
+		border:            "#6B7280",
+		help:              "#6B7280",
+		err:               "#DC2626",
+		dim:               "#4B5563",
+		secondary:         "#D1D5DB",
+		verboseBackground: "#374151",
+		critique:          "#FDE68A",
+		critiqueBorder:    "#D97706",
+		syntaxCommand:     "#93C5FD",
+		syntaxFlag:        "#FCD34D",
+		syntaxString:      "#86EFAC",
+		syntaxOperator:    "#F472B6",
+	}
+
+	// lightTheme keeps text dark enough to read on a light background
+	lightTheme = theme{
+		title:             "#6D28D9",
+		prompt:            "#047857",
+		command:           "#111827",
+		commandBackground: "#F3F4F6",
+		border:            "#9CA3AF",
+		help:              "#374151",
+		err:               "#B91C1C",
+		dim:               "#4B5563",
+		secondary:         "#1F2937",
+		verboseBackground: "#E5E7EB",
+		critique:          "#92400E",
+		critiqueBorder:    "#D97706",
+		syntaxCommand:     "#1D4ED8",
+		syntaxFlag:        "#B45309",
+		syntaxString:      "#15803D",
+		syntaxOperator:    "#BE185D",
+	}
+
+	themes = map[string]theme{"dark": darkTheme, "light": lightTheme}
+
+	colors = darkTheme
+)
+
+// themeColors maps the names used by --theme-color and the config file's
+// [theme] table onto the colors they set
+var themeColors = map[string]func(*theme) *lipgloss.Color{
+	"title":              func(t *theme) *lipgloss.Color { return &t.title },
+	"prompt":             func(t *theme) *lipgloss.Color { return &t.prompt },
+	"command":            func(t *theme) *lipgloss.Color { return &t.command },
+	"command_background": func(t *theme) *lipgloss.Color { return &t.commandBackground },
+	"border":             func(t *theme) *lipgloss.Color { return &t.border },
+	"help":               func(t *theme) *lipgloss.Color { return &t.help },
+	"error":              func(t *theme) *lipgloss.Color { return &t.err },
+	"dim":                func(t *theme) *lipgloss.Color { return &t.dim },
+	"secondary":          func(t *theme) *lipgloss.Color { return &t.secondary },
+	"verbose_background": func(t *theme) *lipgloss.Color { return &t.verboseBackground },
+	"critique":           func(t *theme) *lipgloss.Color { return &t.critique },
+	"critique_border":    func(t *theme) *lipgloss.Color { return &t.critiqueBorder },
+	"syntax_command":     func(t *theme) *lipgloss.Color { return &t.syntaxCommand },
+	"syntax_flag":        func(t *theme) *lipgloss.Color { return &t.syntaxFlag },
+	"syntax_string":      func(t *theme) *lipgloss.Color { return &t.syntaxString },
+	"syntax_operator":    func(t *theme) *lipgloss.Color { return &t.syntaxOperator },
+}
+
+// colorRe matches the colors lipgloss understands: hex, or an ANSI number
+var colorRe = regexp.MustCompile(`^(#[0-9A-Fa-f]{3}|#[0-9A-Fa-f]{6}|[0-9]|[1-9][0-9]|1[0-9][0-9]|2[0-4][0-9]|25[0-5])$`)
+
+// themeColorNames lists the colors that can be set, for error messages
+func themeColorNames() string {
+	names := make([]string, 0, len(themeColors))
+	for name := range themeColors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// parseThemeColor checks a name=color override from --theme-color
+func parseThemeColor(v string) (string, string, error) {
+	name, color, ok := strings.Cut(v, "=")
+	if !ok {
+		return "", "", fmt.Errorf("--theme-color must look like name=color, got %q", v)
+	}
+	if _, ok := themeColors[name]; !ok {
+		return "", "", fmt.Errorf("--theme-color: unknown color %q, expected one of %s", name, themeColorNames())
+	}
+	if !colorRe.MatchString(color) {
+		return "", "", fmt.Errorf("--theme-color: %s must be a hex color like #333333 or an ANSI number, got %q", name, color)
+	}
+	return name, color, nil
+}
+
+// resolveTheme returns the named built-in theme with the name=color
+// overrides applied, which parseArgs has already checked
+func resolveTheme(name string, overrides []string) theme {
+	t, ok := themes[name]
+	if !ok {
+		t = darkTheme
+	}
+	for _, override := range overrides {
+		if key, color, err := parseThemeColor(override); err == nil {
+			*themeColors[key](&t) = lipgloss.Color(color)
+		}
+	}
+	return t
+}
+
+// setTheme switches the UI to the given colors, updating the styles
+func setTheme(t theme) {
+	colors = t
+	titleStyle = titleStyle.Foreground(t.title)
+	promptStyle = promptStyle.Foreground(t.prompt)
+	cmdStyle = cmdStyle.Foreground(t.command).Background(t.commandBackground).BorderForeground(t.border)
+	helpStyle = helpStyle.Foreground(t.help)
+	errorStyle = errorStyle.Foreground(t.err)
+	dimStyle = dimStyle.Foreground(t.dim)
+	secondaryStyle = secondaryStyle.Foreground(t.secondary).BorderForeground(t.dim)
+	verbosePromptStyle = verbosePromptStyle.Foreground(t.secondary).Background(t.verboseBackground).BorderForeground(t.dim)
+	critiqueStyle = critiqueStyle.Foreground(t.critique).BorderForeground(t.critiqueBorder)
+	diffAddedStyle = diffAddedStyle.Foreground(t.prompt)
+	diffRemovedStyle = diffRemovedStyle.Foreground(t.err)
+}
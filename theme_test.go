@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestResolveTheme(t *testing.T) {
+	if got := resolveTheme("", nil); got != darkTheme {
+		t.Errorf("Expected the dark theme by default, got %+v", got)
+	}
+
+	got := resolveTheme("light", []string{"help=#333333", "title=5"})
+	if got.help != "#333333" || got.title != "5" {
+		t.Errorf("Expected the overrides to apply, got help %q title %q", got.help, got.title)
+	}
+	if got.command != lightTheme.command {
+		t.Errorf("Expected the rest of the light theme, got command %q", got.command)
+	}
+}
+
+func TestSetTheme(t *testing.T) {
+	t.Cleanup(func() { setTheme(darkTheme) })
+
+	setTheme(resolveTheme("light", []string{"help=#333333"}))
+	if helpStyle.GetForeground() != lipgloss.Color("#333333") {
+		t.Errorf("Expected the help text to use the override, got %v", helpStyle.GetForeground())
+	}
+	if cmdStyle.GetBackground() != lightTheme.commandBackground || colors.help != "#333333" {
+		t.Errorf("Expected the command box to use the light theme, got %v", cmdStyle.GetBackground())
+	}
+
+	// Switching back restores the original colors
+	setTheme(darkTheme)
+	if helpStyle.GetForeground() != lipgloss.Color("#6B7280") {
+		t.Errorf("Expected the dark theme's help color, got %v", helpStyle.GetForeground())
+	}
+}
+
+func TestParseArgsTheme(t *testing.T) {
+	opts, err := parseArgs([]string{"--theme", "light", "--theme-color", "help=#333", "--theme-color", "error=196"})
+	if err != nil || opts.theme != "light" || strings.Join(opts.themeColors, "|") != "help=#333|error=196" {
+		t.Errorf("Expected the theme and both overrides, got %q %q (err %v)", opts.theme, opts.themeColors, err)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--theme", "solarized"}, "--theme must be dark or light"},
+		{[]string{"--theme-color", "help"}, "must look like name=color"},
+		{[]string{"--theme-color", "colour=#333333"}, `unknown color "colour"`},
+		{[]string{"--theme-color", "help=grey"}, "must be a hex color"},
+		{[]string{"--theme-color", "help=256"}, "must be a hex color"},
+	}
+	for _, tt := range tests {
+		if _, err := parseArgs(tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: expected an error containing %q, got %v", tt.args, tt.want, err)
+		}
+	}
+}
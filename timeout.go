@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultTimeout is how long a generation may take unless --timeout says
+// otherwise
+const defaultTimeout = 30 * time.Second
+
+// withTimeout bounds ctx by --timeout, if one is set. The returned cancel
+// must be called to release the timer.
+func (m model) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.opts.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, m.opts.timeout)
+}
+
+// timeoutError replaces err with a plain explanation when ctx ran out of
+// time, since the SDK and HTTP errors for it don't say which timeout it was
+func (m model) timeoutError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("request timed out after %v (raise it with --timeout)", m.opts.timeout)
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benmyles/clippycli/clippy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// slowGenerator replies only after delay, unless its context ends first
+type slowGenerator struct {
+	delay time.Duration
+	done  chan struct{} // Closed when generate returns
+}
+
+func (g slowGenerator) Generate(ctx context.Context, _ string, _ []clippy.Message, _ func(string)) (clippy.Generation, error) {
+	defer close(g.done)
+	select {
+	case <-time.After(g.delay):
+		return clippy.Generation{Text: "ls"}, nil
+	case <-ctx.Done():
+		return clippy.Generation{}, ctx.Err()
+	}
+}
+
+func (g slowGenerator) WarmUp(context.Context) error { return nil }
+
+func TestGenerationTimesOut(t *testing.T) {
+	g := slowGenerator{delay: 5 * time.Second, done: make(chan struct{})}
+	m := newModel(options{prompt: "list files", timeout: 20 * time.Millisecond})
+	m.generator = g
+
+	start := time.Now()
+	msg, ok := m.generateCommand()().(cmdGeneratedMsg)
+	if !ok || msg.err == nil || !strings.Contains(msg.err.Error(), "request timed out after 20ms") {
+		t.Fatalf("Expected a timeout error, got %#v", msg)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected to give up at the deadline, took %v", elapsed)
+	}
+
+	// The request was cancelled rather than left running
+	select {
+	case <-g.done:
+	case <-time.After(time.Second):
+		t.Error("Expected the generator to return once the deadline passed")
+	}
+
+	updated, _ := m.Update(msg)
+	if m = updated.(model); !strings.Contains(m.View(), "timed out") {
+		t.Errorf("Expected the timeout to be shown, got %q", m.View())
+	}
+}
+
+func TestGenerationWithinTimeout(t *testing.T) {
+	m := newModel(options{prompt: "list files", timeout: time.Second})
+	m.generator = slowGenerator{delay: time.Millisecond, done: make(chan struct{})}
+	if msg := m.generateCommand()().(cmdGeneratedMsg); msg.err != nil || msg.cmd != "ls" {
+		t.Errorf("Expected the command, got %#v", msg)
+	}
+}
+
+func TestParseArgsTimeout(t *testing.T) {
+	if opts, _ := parseArgs(nil); opts.timeout != defaultTimeout {
+		t.Errorf("Expected the default timeout, got %v", opts.timeout)
+	}
+	if opts, err := parseArgs([]string{"--timeout", "90"}); err != nil || opts.timeout != 90*time.Second {
+		t.Errorf("Expected 90s, got %v (err %v)", opts.timeout, err)
+	}
+	if _, err := parseArgs([]string{"--timeout", "soon"}); err == nil {
+		t.Error("Expected an error for a timeout that isn't a number")
+	}
+}
+
+func TestContinuationTimesOut(t *testing.T) {
+	g := slowGenerator{delay: 5 * time.Second, done: make(chan struct{})}
+	m := newModel(options{prompt: "list files", timeout: 20 * time.Millisecond})
+	m.generator = g
+	m.partialCmd = "find . -name"
+
+	msg, ok := m.continueGeneration()().(generationInterruptedMsg)
+	if !ok || msg.err == nil || !strings.Contains(msg.err.Error(), "request timed out after 20ms") {
+		t.Fatalf("Expected a timeout error, got %#v", msg)
+	}
+	if msg.partial != "find . -name" {
+		t.Errorf("Expected the partial command to be kept, got %q", msg.partial)
+	}
+	select {
+	case <-g.done:
+	case <-time.After(time.Second):
+		t.Error("Expected the continuation to be cancelled at the deadline")
+	}
+}
+
+func TestSideRequestsTimeOut(t *testing.T) {
+	m := newModel(options{prompt: "clean up", timeout: 20 * time.Millisecond})
+	m.generatedCmd = "rm -rf build"
+
+	for name, request := range map[string]func(model) tea.Cmd{
+		"explanation": model.explainCommand,
+		"description": model.describeCommand,
+		"critique":    model.critiqueCommand,
+	} {
+		g := slowGenerator{delay: 5 * time.Second, done: make(chan struct{})}
+		m.generator = g
+
+		var err error
+		switch msg := request(m)().(type) {
+		case explanationMsg:
+			err = msg.err
+		case descriptionMsg:
+			err = msg.err
+		case critiqueMsg:
+			err = msg.err
+		}
+		if err == nil || !strings.Contains(err.Error(), "request timed out after 20ms") {
+			t.Errorf("Expected the %s to time out, got %v", name, err)
+		}
+		select {
+		case <-g.done:
+		case <-time.After(time.Second):
+			t.Errorf("Expected the %s request to be cancelled at the deadline", name)
+		}
+	}
+}
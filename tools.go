@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// shellBuiltins are provided by the shell itself, so they're never missing
+var shellBuiltins = map[string]bool{
+	".": true, ":": true, "[": true, "[[": true, "alias": true, "bg": true,
+	"builtin": true, "case": true, "cd": true, "command": true, "declare": true,
+	"do": true, "done": true, "echo": true, "elif": true, "else": true,
+	"esac": true, "eval": true, "exec": true, "exit": true, "export": true,
+	"false": true, "fg": true, "fi": true, "for": true, "function": true,
+	"hash": true, "history": true, "if": true, "jobs": true, "kill": true,
+	"let": true, "local": true, "printf": true, "pwd": true, "read": true,
+	"return": true, "select": true, "set": true, "shift": true, "source": true,
+	"test": true, "then": true, "time": true, "trap": true, "true": true,
+	"type": true, "ulimit": true, "umask": true, "unset": true, "until": true,
+	"wait": true, "while": true, "{": true, "}": true, "!": true,
+}
+
+// commandWrappers run another command, so the real tool follows them
+var commandWrappers = map[string]bool{
+	"sudo": true, "env": true, "nohup": true, "nice": true, "time": true,
+	"exec": true, "command": true, "doas": true,
+}
+
+// splitSegments splits a command line into the segments separated by
+// unquoted pipes, &&, ||, and semicolons
+func splitSegments(cmd string) []string {
+	var segments []string
+	var current strings.Builder
+	var quote rune
+	escaped := false
+
+	flush := func() {
+		if seg := strings.TrimSpace(current.String()); seg != "" {
+			segments = append(segments, seg)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(cmd)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '|' || r == ';' || r == '\n' || (r == '&' && i+1 < len(runes) && runes[i+1] == '&'):
+			flush()
+			if i+1 < len(runes) && (runes[i+1] == r || r == '&') {
+				i++
+			}
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+
+	return segments
+}
+
+// primaryTool returns the program a command segment runs, skipping leading
+// variable assignments and wrappers like sudo or env
+func primaryTool(segment string) string {
+	fields := strings.Fields(segment)
+	for i := 0; i < len(fields); i++ {
+		field := strings.TrimLeft(fields[i], "(")
+		switch {
+		case field == "":
+			continue
+		case strings.HasPrefix(field, "#"):
+			// The rest of the segment is a comment
+			return ""
+		case strings.Contains(field, "=") && !strings.HasPrefix(field, "="):
+			// FOO=bar assignment
+			continue
+		case commandWrappers[field]:
+			// Skip the wrapper's own flags
+			for i+1 < len(fields) && strings.HasPrefix(fields[i+1], "-") {
+				i++
+			}
+			continue
+		}
+		return strings.Trim(field, `"'`)
+	}
+	return ""
+}
+
+// pipelineTools returns the distinct tools each segment of cmd starts with,
+// excluding shell builtins
+func pipelineTools(cmd string) []string {
+	seen := make(map[string]bool)
+	var tools []string
+	for _, segment := range splitSegments(cmd) {
+		tool := primaryTool(segment)
+		if tool == "" || shellBuiltins[tool] || seen[tool] {
+			continue
+		}
+		seen[tool] = true
+		tools = append(tools, tool)
+	}
+	return tools
+}
+
+// toolAvailable reports whether tool can be run, either as a builtin, a path
+// to an executable, or a program found on PATH
+func toolAvailable(tool string) bool {
+	if shellBuiltins[tool] {
+		return true
+	}
+	if strings.Contains(tool, string(filepath.Separator)) {
+		info, err := os.Stat(tool)
+		return err == nil && !info.IsDir()
+	}
+	_, err := exec.LookPath(tool)
+	return err == nil
+}
+
+// missingTools returns the tools used by cmd that aren't installed
+func missingTools(cmd string) []string {
+	var missing []string
+	for _, tool := range pipelineTools(cmd) {
+		if !toolAvailable(tool) {
+			missing = append(missing, tool)
+		}
+	}
+	return missing
+}
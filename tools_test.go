@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fakePath points PATH at a temp directory containing only the given tools
+func fakePath(t *testing.T, tools ...string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, tool := range tools {
+		name := tool
+		if runtime.GOOS == "windows" {
+			name += ".exe"
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestPrimaryTool(t *testing.T) {
+	tests := []struct {
+		segment  string
+		expected string
+	}{
+		{"ls -la", "ls"},
+		{"sudo -E apt-get install jq", "apt-get"},
+		{"LANG=C sort file", "sort"},
+		{"env FOO=1 node app.js", "node"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if result := primaryTool(tt.segment); result != tt.expected {
+			t.Errorf("primaryTool(%q) = %q; want %q", tt.segment, result, tt.expected)
+		}
+	}
+}
+
+func TestPipelineTools(t *testing.T) {
+	tests := []struct {
+		cmd      string
+		expected []string
+	}{
+		{"rg foo | sort | uniq -c", []string{"rg", "sort", "uniq"}},
+		{"cd src && make; make install", []string{"make"}},
+		{`grep "a|b" file || echo none`, []string{"grep"}},
+		{"ps aux | grep go | grep -v grep", []string{"ps", "grep"}},
+	}
+
+	for _, tt := range tests {
+		if result := pipelineTools(tt.cmd); !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("pipelineTools(%q) = %q; want %q", tt.cmd, result, tt.expected)
+		}
+	}
+}
+
+func TestToolAvailable(t *testing.T) {
+	fakePath(t, "grep", "sort")
+
+	if !toolAvailable("grep") {
+		t.Error("Expected grep to be available")
+	}
+	if toolAvailable("rg") {
+		t.Error("Expected rg to be unavailable")
+	}
+	if !toolAvailable("cd") {
+		t.Error("Expected builtins to always be available")
+	}
+
+	missing := missingTools("rg foo | sort | fzf")
+	if !reflect.DeepEqual(missing, []string{"rg", "fzf"}) {
+		t.Errorf("Expected rg and fzf to be missing, got %q", missing)
+	}
+}
+
+func TestMissingToolsWarningAndRegenerate(t *testing.T) {
+	fakePath(t, "grep")
+
+	testModel := initialModel("search for foo", false)
+	updatedModel, _ := testModel.Update(cmdGeneratedMsg{cmd: "rg foo"})
+
+	m := updatedModel.(model)
+	if !strings.Contains(m.View(), "`rg` is not installed") {
+		t.Error("Expected the result view to warn about the missing tool")
+	}
+
+	// Regenerating should constrain the model to installed tools
+	updatedModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+	m = updatedModel.(model)
+	if m.state != stateLoading || cmd == nil {
+		t.Fatal("Expected pressing i to regenerate")
+	}
+	if !strings.Contains(m.systemPrompt(""), "rg") {
+		t.Error("Expected the system prompt to rule out the missing tool")
+	}
+}
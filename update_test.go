@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// batchSize counts the commands in cmd. It calls cmd to see whether it's a
+// tea.Batch, which runs cmd when it's a single command rather than a batch,
+// so anything with side effects has to be stubbed first. Batched commands
+// are counted without running them.
+func batchSize(cmd tea.Cmd) int {
+	if cmd == nil {
+		return 0
+	}
+	if batch, ok := cmd().(tea.BatchMsg); ok {
+		n := 0
+		for _, c := range batch {
+			if c != nil {
+				n++
+			}
+		}
+		return n
+	}
+	return 1
+}
+
+func TestUpdateKeepsPendingCommands(t *testing.T) {
+	// Submitting a prompt should start the spinner, the generation and the
+	// listener for its streamed text
+	testModel := initialModel("", false)
+	testModel.textarea.SetValue("list files")
+	updatedModel, cmd := testModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if updatedModel.(model).state != stateLoading {
+		t.Fatal("Expected Enter to start loading")
+	}
+	if batchSize(cmd) != 3 {
+		t.Errorf("Expected the spinner tick, generation and listener to all be returned, got %d commands", batchSize(cmd))
+	}
+
+	// A notification queued for a result must survive alongside other work
+	origNotify := runNotifyCmd
+	runNotifyCmd = func(*exec.Cmd) error { return nil }
+	defer func() { runNotifyCmd = origNotify }()
+	notifyModel := initialModel("list files", false)
+	notifyModel.opts.notify = true
+	_, cmd = notifyModel.Update(cmdGeneratedMsg{cmd: "ls"})
+	if batchSize(cmd) != 1 {
+		t.Errorf("Expected the notification command to be returned, got %d commands", batchSize(cmd))
+	}
+
+	// Confirming the strict phrase copies without flagging a mismatch
+	stubClipboard(t)
+	confirmModel := initialModel("clean up", false)
+	confirmModel.opts.strictConfirm = true
+	var m tea.Model = confirmModel
+	m, _ = m.Update(cmdGeneratedMsg{cmd: "rm -rf build"})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = typeText(m, "rm")
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.(model).confirmMismatch {
+		t.Error("Expected a matching phrase not to be flagged as a mismatch")
+	}
+	if batchSize(cmd) != 1 {
+		t.Errorf("Expected the copy command to be returned, got %d commands", batchSize(cmd))
+	}
+}
+
+func TestCopiedMessageQuitsWithPendingCommands(t *testing.T) {
+	testModel := initialModel("list files", false)
+	_, cmd := testModel.Update(cmdCopiedMsg{cmd: "ls"})
+	if cmd == nil {
+		t.Fatal("Expected a quit command after copying")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("Expected copying to quit the program")
+	}
+}
+
+func TestSpinnerStopsOutsideLoading(t *testing.T) {
+	testModel := initialModel("list files", false)
+	tick := testModel.spinner.Tick()
+
+	// While loading, each tick schedules the next
+	if _, cmd := testModel.Update(tick); cmd == nil {
+		t.Fatal("Expected a tick while loading to schedule another")
+	}
+
+	updatedModel, _ := testModel.Update(cmdGeneratedMsg{cmd: "ls"})
+	if _, cmd := updatedModel.Update(tick); cmd != nil {
+		t.Error("Expected a tick in stateResult to schedule nothing")
+	}
+
+	// A critique spins the spinner on the result screen until it arrives
+	m := updatedModel.(model)
+	m.critiquing = true
+	if _, cmd := m.Update(m.spinner.Tick()); cmd == nil {
+		t.Error("Expected a tick during a critique to schedule another")
+	}
+}
+
+func TestInitOnlyTicksWhenLoading(t *testing.T) {
+	stubWarmUp(t)
+	cmd := initialModel("", false).Init()
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok {
+		t.Fatal("Expected Init to return a batch")
+	}
+	for _, c := range batch {
+		if c == nil {
+			continue
+		}
+		if _, isTick := c().(spinner.TickMsg); isTick {
+			t.Error("Expected no spinner tick while waiting for a prompt")
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// latestReleaseURL is queried for the newest published release
+	latestReleaseURL = "https://api.github.com/repos/benmyles/clippycli/releases/latest"
+
+	// updateCheckInterval is how often the releases API is queried at most
+	updateCheckInterval = 24 * time.Hour
+
+	// updateCheckEnv opts in to update checks when set to 1
+	updateCheckEnv = "CLIPPY_UPDATE_CHECK"
+)
+
+// updateAvailableMsg reports a newer release than the running one
+type updateAvailableMsg struct {
+	latest string
+}
+
+// updateState is the locally cached result of the last update check
+type updateState struct {
+	LastChecked time.Time `json:"last_checked"`
+	Latest      string    `json:"latest,omitempty"`
+}
+
+// fetchLatestRelease returns the tag of the newest release; replaced in tests.
+// Only the release tag is requested and nothing about the user is sent.
+var fetchLatestRelease = func(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, latestReleaseURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("releases API returned %s", resp.Status)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", err
+	}
+	return release.TagName, nil
+}
+
+// defaultUpdateStatePath is where the last update check is cached
+func defaultUpdateStatePath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "update-check.json"), nil
+}
+
+// loadUpdateState reads the cached update check, treating a missing or
+// unreadable cache as never having checked
+func loadUpdateState(path string) updateState {
+	var state updateState
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+// saveUpdateState caches the result of an update check
+func saveUpdateState(path string, state updateState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// parseVersion splits a version like v1.2.3 into its numeric parts
+func parseVersion(v string) ([]int, error) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	// Ignore pre-release and build suffixes like -rc1 or +dirty
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	var parts []int
+	for _, field := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", v)
+		}
+		parts = append(parts, n)
+	}
+	return parts, nil
+}
+
+// newerVersion reports whether latest is a later release than current
+func newerVersion(latest, current string) bool {
+	l, err := parseVersion(latest)
+	if err != nil {
+		return false
+	}
+	c, err := parseVersion(current)
+	if err != nil {
+		return false
+	}
+	for i := 0; i < max(len(l), len(c)); i++ {
+		var a, b int
+		if i < len(l) {
+			a = l[i]
+		}
+		if i < len(c) {
+			b = c[i]
+		}
+		if a != b {
+			return a > b
+		}
+	}
+	return false
+}
+
+// checkForUpdate returns the latest release if it's newer than current. The
+// releases API is queried at most once per updateCheckInterval; in between,
+// the cached answer is used.
+func checkForUpdate(ctx context.Context, statePath, current string, now time.Time) (string, error) {
+	state := loadUpdateState(statePath)
+
+	if now.Sub(state.LastChecked) >= updateCheckInterval {
+		latest, err := fetchLatestRelease(ctx)
+		// Record failed checks too, so an offline machine isn't retried every run
+		state = updateState{LastChecked: now, Latest: latest}
+		if err == nil && latest == "" {
+			err = errors.New("releases API returned no version")
+		}
+		if saveErr := saveUpdateState(statePath, state); err == nil {
+			err = saveErr
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if newerVersion(state.Latest, current) {
+		return state.Latest, nil
+	}
+	return "", nil
+}
+
+// updateCheckEnabled reports whether this run should check for updates:
+// only when opted in, not overridden or asked to be quiet, and running the
+// interactive TUI from a released build
+func (m model) updateCheckEnabled() bool {
+	return m.opts.updateCheck && !m.opts.noUpdateCheck && !m.opts.quiet && !m.opts.widget &&
+		m.updateStatePath != "" && version != "dev"
+}
+
+// checkUpdateCmd checks for a newer release in the background, never
+// blocking or failing the UI
+func (m model) checkUpdateCmd() tea.Cmd {
+	if !m.updateCheckEnabled() {
+		return nil
+	}
+	path := m.updateStatePath
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		latest, err := checkForUpdate(ctx, path, version, time.Now())
+		if err != nil || latest == "" {
+			return nil
+		}
+		return updateAvailableMsg{latest: latest}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stubLatestRelease makes the releases API return latest, counting requests
+func stubLatestRelease(t *testing.T, latest string, err error) *int {
+	t.Helper()
+	calls := 0
+	orig := fetchLatestRelease
+	fetchLatestRelease = func(ctx context.Context) (string, error) {
+		calls++
+		return latest, err
+	}
+	t.Cleanup(func() { fetchLatestRelease = orig })
+	return &calls
+}
+
+func TestCheckForUpdateRespectsDailyCache(t *testing.T) {
+	calls := stubLatestRelease(t, "v1.3.0", nil)
+	path := filepath.Join(t.TempDir(), "update-check.json")
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	latest, err := checkForUpdate(context.Background(), path, "v1.2.0", now)
+	if err != nil || latest != "v1.3.0" {
+		t.Fatalf("Expected v1.3.0 to be reported, got %q, %v", latest, err)
+	}
+	if *calls != 1 {
+		t.Fatalf("Expected one request, got %d", *calls)
+	}
+
+	// Later the same day the cached answer is used
+	latest, _ = checkForUpdate(context.Background(), path, "v1.2.0", now.Add(23*time.Hour))
+	if *calls != 1 {
+		t.Errorf("Expected no request within a day, got %d", *calls)
+	}
+	if latest != "v1.3.0" {
+		t.Errorf("Expected the cached release to still be reported, got %q", latest)
+	}
+
+	// A day later it checks again
+	checkForUpdate(context.Background(), path, "v1.2.0", now.Add(24*time.Hour))
+	if *calls != 2 {
+		t.Errorf("Expected a new request after a day, got %d", *calls)
+	}
+}
+
+func TestCheckForUpdateFailuresAreCached(t *testing.T) {
+	calls := stubLatestRelease(t, "", errors.New("offline"))
+	path := filepath.Join(t.TempDir(), "update-check.json")
+	now := time.Now()
+
+	if _, err := checkForUpdate(context.Background(), path, "v1.2.0", now); err == nil {
+		t.Error("Expected the failure to be returned")
+	}
+	checkForUpdate(context.Background(), path, "v1.2.0", now.Add(time.Hour))
+	if *calls != 1 {
+		t.Errorf("Expected a failed check not to be retried within a day, got %d requests", *calls)
+	}
+}
+
+func TestNewerVersion(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		expected        bool
+	}{
+		{"v1.3.0", "v1.2.0", true},
+		{"v1.10.0", "v1.9.3", true},
+		{"1.2.1", "v1.2", true},
+		{"v1.2.0", "v1.2.0", false},
+		{"v1.1.9", "v1.2.0", false},
+		{"v2.0.0-rc1", "v1.9.0", true},
+		{"", "v1.2.0", false},
+		{"v1.3.0", "dev", false},
+	}
+	for _, tt := range tests {
+		if got := newerVersion(tt.latest, tt.current); got != tt.expected {
+			t.Errorf("newerVersion(%q, %q): expected %v, got %v", tt.latest, tt.current, tt.expected, got)
+		}
+	}
+}
+
+func TestUpdateCheckSkippedWhenDisabled(t *testing.T) {
+	calls := stubLatestRelease(t, "v9.9.9", nil)
+	origVersion := version
+	version = "v1.0.0"
+	t.Cleanup(func() { version = origVersion })
+
+	testModel := initialModel("", false)
+	testModel.updateStatePath = filepath.Join(t.TempDir(), "update-check.json")
+
+	if testModel.checkUpdateCmd() != nil {
+		t.Error("Expected no update check without opting in")
+	}
+
+	testModel.opts.updateCheck = true
+	testModel.opts.noUpdateCheck = true
+	if testModel.checkUpdateCmd() != nil {
+		t.Error("Expected --no-update-check to override the opt-in")
+	}
+
+	testModel.opts.noUpdateCheck = false
+	testModel.opts.quiet = true
+	if testModel.checkUpdateCmd() != nil {
+		t.Error("Expected no update check with --quiet")
+	}
+
+	testModel.opts.quiet = false
+	testModel.opts.widget = true
+	if testModel.checkUpdateCmd() != nil {
+		t.Error("Expected no update check in widget mode")
+	}
+
+	testModel.opts.widget = false
+	cmd := testModel.checkUpdateCmd()
+	if cmd == nil {
+		t.Fatal("Expected an update check when opted in")
+	}
+	if msg, ok := cmd().(updateAvailableMsg); !ok || msg.latest != "v9.9.9" {
+		t.Errorf("Expected v9.9.9 to be reported, got %v", msg)
+	}
+	if *calls != 1 {
+		t.Errorf("Expected exactly one request, got %d", *calls)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// modelPrice is what a model costs in US dollars per million tokens
+type modelPrice struct {
+	prefix        string // Matches the model ID and any dated versions of it
+	input, output float64
+}
+
+// modelPrices are list prices, close enough for a rough estimate. More
+// specific prefixes come first, since the first match wins.
+var modelPrices = []modelPrice{
+	{"claude-3-5-haiku", 0.80, 4},
+	{"claude-3-haiku", 0.25, 1.25},
+	{"claude-haiku-4", 1, 5},
+	{"claude-3-5-sonnet", 3, 15},
+	{"claude-3-7-sonnet", 3, 15},
+	{"claude-sonnet-4", 3, 15},
+	{"claude-3-opus", 15, 75},
+	{"claude-opus-4", 15, 75},
+	{"gpt-4o-mini", 0.15, 0.60},
+	{"gpt-4o", 2.50, 10},
+	{"gpt-4.1-nano", 0.10, 0.40},
+	{"gpt-4.1-mini", 0.40, 1.60},
+	{"gpt-4.1", 2, 8},
+}
+
+// estimateCost returns the rough cost of a request to model, or false when
+// the model's price isn't known
+func estimateCost(model string, inputTokens, outputTokens int) (float64, bool) {
+	for _, p := range modelPrices {
+		if strings.HasPrefix(model, p.prefix) {
+			return (float64(inputTokens)*p.input + float64(outputTokens)*p.output) / 1e6, true
+		}
+	}
+	return 0, false
+}
+
+// usageLine summarizes the tokens the last generation used, with its cost
+// when the model's price is known, e.g. "Tokens: 412 in / 23 out (~$0.002)"
+func (m model) usageLine() string {
+	if m.inputTokens == 0 && m.outputTokens == 0 {
+		return ""
+	}
+	line := fmt.Sprintf("Tokens: %d in / %d out", m.inputTokens, m.outputTokens)
+	// Local models cost nothing per token
+	if m.provider() == "ollama" {
+		return line
+	}
+	cost, ok := estimateCost(m.modelName, m.inputTokens, m.outputTokens)
+	switch {
+	case !ok:
+	case cost < 0.001:
+		line += " (<$0.001)"
+	default:
+		line += fmt.Sprintf(" (~$%.3f)", cost)
+	}
+	return line
+}
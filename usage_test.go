@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateCost(t *testing.T) {
+	cost, ok := estimateCost("claude-sonnet-4-20250514", 1_000_000, 100_000)
+	if !ok || cost != 4.5 {
+		t.Errorf("Expected $4.50 for Sonnet 4, got %v (known %v)", cost, ok)
+	}
+	if cost, _ := estimateCost("gpt-4o-mini", 1_000_000, 0); cost != 0.15 {
+		t.Errorf("Expected gpt-4o-mini not to be priced as gpt-4o, got %v", cost)
+	}
+	if _, ok := estimateCost("llama3", 100, 10); ok {
+		t.Error("Expected no price for an unknown model")
+	}
+}
+
+func TestUsageShownInVerboseMode(t *testing.T) {
+	m := initialModel("list files", true)
+	m.generator = fakeGenerator{text: "ls -la", inputTokens: 412, outputTokens: 23}
+	updated, _ := m.Update(m.generateCommand()())
+	if view := updated.View(); !strings.Contains(view, "Tokens: 412 in / 23 out (~$0.002)") {
+		t.Errorf("Expected token usage and cost in verbose mode, got %q", view)
+	}
+
+	m = initialModel("list files", false)
+	m.generator = fakeGenerator{text: "ls -la", inputTokens: 412, outputTokens: 23}
+	updated, _ = m.Update(m.generateCommand()())
+	if strings.Contains(updated.View(), "Tokens:") {
+		t.Error("Expected no token usage outside verbose mode")
+	}
+}
+
+func TestUsageLineLocalModel(t *testing.T) {
+	m := newModel(options{provider: "ollama"})
+	m.inputTokens, m.outputTokens = 100, 10
+	if line := m.usageLine(); line != "Tokens: 100 in / 10 out" {
+		t.Errorf("Expected no cost for a local model, got %q", line)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// version, commit and date describe the build, set at build time with
+// -ldflags "-X main.version=v1.2.3 -X main.commit=abc1234 -X main.date=...".
+// Development builds report "dev".
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
+
+// buildVersion returns the version, commit and build date, filling in any not
+// set with -ldflags from what the Go toolchain recorded, as for go install
+func buildVersion(readBuildInfo func() (*debug.BuildInfo, bool)) (string, string, string) {
+	v, c, d := version, commit, date
+	info, ok := readBuildInfo()
+	if !ok {
+		return v, c, d
+	}
+	if v == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		v = info.Main.Version
+	}
+	modified := false
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if c == "" {
+				c = s.Value
+			}
+		case "vcs.time":
+			if d == "" {
+				d = s.Value
+			}
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	// Only the toolchain's revision can be from a tree with local changes
+	if modified && commit == "" && c != "" {
+		c += "-dirty"
+	}
+	return v, c, d
+}
+
+// versionLine describes the build for --version
+func versionLine(readBuildInfo func() (*debug.BuildInfo, bool)) string {
+	v, c, d := buildVersion(readBuildInfo)
+	if c == "" {
+		c = "unknown"
+	}
+	if d == "" {
+		d = "unknown"
+	}
+	return fmt.Sprintf("clippycli %s (commit %s, built %s)", v, c, d)
+}
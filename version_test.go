@@ -0,0 +1,35 @@
+package main
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestVersionLine(t *testing.T) {
+	noInfo := func() (*debug.BuildInfo, bool) { return nil, false }
+	fromToolchain := func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			Main: debug.Module{Version: "v1.4.0"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "0123abc"},
+				{Key: "vcs.time", Value: "2026-01-02T03:04:05Z"},
+				{Key: "vcs.modified", Value: "true"},
+			},
+		}, true
+	}
+
+	if got := versionLine(noInfo); got != "clippycli dev (commit unknown, built unknown)" {
+		t.Errorf("Expected an unknown development build, got %q", got)
+	}
+	if got := versionLine(fromToolchain); got != "clippycli v1.4.0 (commit 0123abc-dirty, built 2026-01-02T03:04:05Z)" {
+		t.Errorf("Expected the toolchain's build info, got %q", got)
+	}
+
+	// Values set with -ldflags win over the toolchain's
+	oldVersion, oldCommit, oldDate := version, commit, date
+	t.Cleanup(func() { version, commit, date = oldVersion, oldCommit, oldDate })
+	version, commit, date = "v1.5.0", "fedcba9", "2026-02-03"
+	if got := versionLine(fromToolchain); got != "clippycli v1.5.0 (commit fedcba9, built 2026-02-03)" {
+		t.Errorf("Expected the -ldflags build info, got %q", got)
+	}
+}
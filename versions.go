@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// versionSensitiveTools are tools whose syntax differs noticeably between
+// versions. Detection only runs --version for these, never arbitrary words.
+var versionSensitiveTools = []string{
+	"awk", "bash", "brew", "cmake", "curl", "docker", "docker-compose", "ffmpeg",
+	"find", "gcc", "git", "go", "grep", "helm", "ip", "java", "jq", "kubectl",
+	"make", "node", "npm", "openssl", "podman", "psql", "python", "python3",
+	"rsync", "sed", "tar", "terraform", "yq", "zsh",
+}
+
+var versionRE = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// parseVersionOutput extracts the version number from a tool's --version output
+func parseVersionOutput(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if v := versionRE.FindString(line); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// runVersionCmd runs "tool --version"; replaced in tests
+var runVersionCmd = func(tool string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, tool, "--version").CombinedOutput()
+	return string(out), err
+}
+
+// versionCache remembers detected versions for the life of the process
+var versionCache sync.Map
+
+// detectToolVersion returns the installed version of tool, if it can be found
+func detectToolVersion(tool string) (string, bool) {
+	if cached, ok := versionCache.Load(tool); ok {
+		v := cached.(string)
+		return v, v != ""
+	}
+
+	var version string
+	if toolAvailable(tool) {
+		if out, err := runVersionCmd(tool); err == nil {
+			version = parseVersionOutput(out)
+		}
+	}
+
+	versionCache.Store(tool, version)
+	return version, version != ""
+}
+
+// mentionedTools returns the version-sensitive tools named in the prompt
+func mentionedTools(prompt string) []string {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(prompt), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+	}) {
+		words[word] = true
+	}
+
+	var tools []string
+	for _, tool := range versionSensitiveTools {
+		if words[tool] {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// toolVersionHints lists explicit tool=version hints, followed by versions
+// detected for tools mentioned in the prompt when detection is enabled
+func toolVersionHints(explicit []string, detect bool, prompt string) string {
+	var hints []string
+	seen := make(map[string]bool)
+
+	for _, hint := range explicit {
+		tool, version, _ := strings.Cut(hint, "=")
+		seen[tool] = true
+		hints = append(hints, fmt.Sprintf("- %s: %s", tool, version))
+	}
+
+	if detect {
+		for _, tool := range mentionedTools(prompt) {
+			if seen[tool] {
+				continue
+			}
+			if version, ok := detectToolVersion(tool); ok {
+				hints = append(hints, fmt.Sprintf("- %s: %s (installed)", tool, version))
+			}
+		}
+	}
+
+	if len(hints) == 0 {
+		return ""
+	}
+	return "Tool versions (use syntax that works with these versions):\n" + strings.Join(hints, "\n")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVersionOutput(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected string
+	}{
+		{"Docker version 24.0.7, build afdd53b", "24.0.7"},
+		{"git version 2.39.2 (Apple Git-143)", "2.39.2"},
+		{"Python 3.11.4", "3.11.4"},
+		{"ip utility, iproute2-6.1.0, libbpf 1.1.0", "6.1.0"},
+		{"GNU bash, version 5.2.15(1)-release (x86_64-pc-linux-gnu)\nCopyright (C) 2022", "5.2.15"},
+		{"no version here", ""},
+	}
+
+	for _, tt := range tests {
+		if result := parseVersionOutput(tt.output); result != tt.expected {
+			t.Errorf("parseVersionOutput(%q) = %q; want %q", tt.output, result, tt.expected)
+		}
+	}
+}
+
+func TestToolVersionHintsInPrompt(t *testing.T) {
+	info := getEnvironmentInfo(envOptions{toolVersions: []string{"docker=20.10", "kubectl=1.28"}})
+	prompt := initialModel("", false).systemPrompt(info)
+
+	for _, want := range []string{"Tool versions", "- docker: 20.10", "- kubectl: 1.28"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected the assembled prompt to contain %q", want)
+		}
+	}
+
+	// No hints means no section
+	if strings.Contains(getEnvironmentInfo(envOptions{}), "Tool versions") {
+		t.Error("Expected no tool versions section without hints")
+	}
+}
+
+func TestDetectToolVersionIsCached(t *testing.T) {
+	fakePath(t, "docker", "git")
+	versionCache.Clear()
+	defer versionCache.Clear()
+
+	calls := 0
+	orig := runVersionCmd
+	runVersionCmd = func(tool string) (string, error) {
+		calls++
+		return "Docker version 24.0.7, build afdd53b", nil
+	}
+	defer func() { runVersionCmd = orig }()
+
+	hints := toolVersionHints(nil, true, "restart my docker containers")
+	if !strings.Contains(hints, "- docker: 24.0.7 (installed)") {
+		t.Errorf("Expected detected docker version in hints, got %q", hints)
+	}
+
+	// A second lookup reuses the cached version
+	toolVersionHints(nil, true, "list docker images")
+	if calls != 1 {
+		t.Errorf("Expected --version to run once, ran %d times", calls)
+	}
+
+	// Explicit hints take precedence over detection
+	hints = toolVersionHints([]string{"docker=19.03"}, true, "docker ps")
+	if strings.Contains(hints, "24.0.7") || !strings.Contains(hints, "- docker: 19.03") {
+		t.Errorf("Expected the explicit hint to win, got %q", hints)
+	}
+
+	// Detection is opt-in
+	if hints := toolVersionHints(nil, false, "docker ps"); hints != "" {
+		t.Errorf("Expected no hints without detection, got %q", hints)
+	}
+}
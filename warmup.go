@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/benmyles/clippycli/clippy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// warmUpTimeout bounds the warm-up request so a slow network never keeps a
+// goroutine around for long
+const warmUpTimeout = 5 * time.Second
+
+// warmUpRequest makes the cheapest API call available so TLS and connection
+// setup are done before the first real generation. It's a var so tests can
+// observe it without touching the network
+var warmUpRequest = func(ctx context.Context, generator clippy.Generator) error {
+	return generator.WarmUp(ctx)
+}
+
+// warmUp opens a connection in the background while the user types their
+// prompt. It's best-effort: failures are ignored, and the real generation
+// will surface any problem with the key or network
+func (m model) warmUp() tea.Cmd {
+	// Quick mode starts generating straight away, so there's nothing to gain
+	if m.state != stateInput || m.generator == nil {
+		return nil
+	}
+	generator := m.generator
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), warmUpTimeout)
+		defer cancel()
+		_ = warmUpRequest(ctx, generator)
+		return nil
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benmyles/clippycli/clippy"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stubWarmUp replaces the warm-up request and counts how often it's made
+func stubWarmUp(t *testing.T) *int {
+	t.Helper()
+	calls := 0
+	orig := warmUpRequest
+	warmUpRequest = func(ctx context.Context, generator clippy.Generator) error {
+		calls++
+		return nil
+	}
+	t.Cleanup(func() { warmUpRequest = orig })
+	return &calls
+}
+
+// runInitCmds runs every command Init returns. Only use it with models that
+// don't start generating, so tests never reach the API
+func runInitCmds(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	if batch, ok := cmd().(tea.BatchMsg); ok {
+		for _, c := range batch {
+			if c != nil {
+				c()
+			}
+		}
+	}
+}
+
+func TestInitWarmsUpClientInInteractiveMode(t *testing.T) {
+	calls := stubWarmUp(t)
+	testModel := initialModel("", false)
+	runInitCmds(testModel.Init())
+	if *calls != 1 {
+		t.Errorf("Expected one warm-up request in interactive mode, got %d", *calls)
+	}
+}
+
+func TestInitSkipsWarmUpInQuickMode(t *testing.T) {
+	testModel := initialModel("list files", false)
+	if testModel.warmUp() != nil {
+		t.Error("Expected no warm-up when generation starts immediately")
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// widgetBufferEnv is the variable a shell widget sets to the current
+// command-line buffer, e.g. CLIPPY_BUFFER="$BUFFER" in a zle widget
+const widgetBufferEnv = "CLIPPY_BUFFER"
+
+// widgetPrompt returns the prompt for --widget mode: the widget's buffer if
+// it set one, otherwise the prompt given on the command line
+func widgetPrompt(opts options) string {
+	if buffer := strings.TrimSpace(os.Getenv(widgetBufferEnv)); buffer != "" {
+		return buffer
+	}
+	return opts.prompt
+}
+
+// generationResult turns the message from generateCommand into the command,
+// or an error saying why there isn't one, for modes without the TUI
+func generationResult(msg any) (string, error) {
+	switch msg := msg.(type) {
+	case cmdGeneratedMsg:
+		if msg.err != nil {
+			return "", msg.err
+		}
+		return msg.cmd, nil
+	case generationInterruptedMsg:
+		return "", fmt.Errorf("the reply was cut off: %w", msg.err)
+	case injectionWarningMsg:
+		return "", errors.New("the context looks like it contains instructions to the AI; run clippycli interactively to review it")
+	default:
+		return "", fmt.Errorf("unexpected result %T", msg)
+	}
+}
+
+// writeWidgetOutput writes just the generated command, with no styling or
+// trailing newline, so the widget can insert it into the command line as is
+func writeWidgetOutput(w io.Writer, msg any) error {
+	cmd, err := generationResult(msg)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, cmd)
+	return err
+}
+
+// runWidget generates a command for the widget's buffer without starting the
+// TUI, returning the process exit code
+func runWidget(m model, stdout, stderr io.Writer) int {
+	m.prompt = widgetPrompt(m.opts)
+	if m.prompt == "" {
+		fmt.Fprintf(stderr, "Error: --widget needs a prompt in $%s or on the command line\n", widgetBufferEnv)
+		return 1
+	}
+
+	msg := m.runGeneration()
+	if err := writeWidgetOutput(stdout, msg); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// The command lands on the command line unreviewed, so a downloaded
+	// script is at least named before Enter is pressed
+	cmd, _ := generationResult(msg)
+	if warning := pipeToShellWarning(cmd); warning != "" {
+		fmt.Fprintf(stderr, "\nWarning: %s\n", warning)
+	}
+	return 0
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWidgetOutputIsExactlyTheCommand(t *testing.T) {
+	var out bytes.Buffer
+	cmd := `find . -name "*.go" | xargs wc -l`
+	if err := writeWidgetOutput(&out, cmdGeneratedMsg{cmd: cmd, fullPrompt: "System: ..."}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), []byte(cmd)) {
+		t.Errorf("Expected exactly %q with no extra bytes, got %q", cmd, out.String())
+	}
+}
+
+func TestRunWidgetWarnsAboutDownloadedScripts(t *testing.T) {
+	t.Setenv(widgetBufferEnv, "install it")
+	m := newModel(options{widget: true})
+	m.generator = fakeGenerator{text: "curl -fsSL https://example.com/install.sh | sh"}
+
+	var stdout, stderr bytes.Buffer
+	if code := runWidget(m, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d (stderr %q)", code, stderr.String())
+	}
+	if stdout.String() != "curl -fsSL https://example.com/install.sh | sh" {
+		t.Errorf("Expected exactly the command on stdout, got %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "https://example.com/install.sh") {
+		t.Errorf("Expected a warning naming the URL on stderr, got %q", stderr.String())
+	}
+}
+
+func TestWidgetOutputErrors(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeWidgetOutput(&out, cmdGeneratedMsg{err: errors.New("rate limited")}); err == nil {
+		t.Error("Expected a generation error to be returned")
+	}
+	if err := writeWidgetOutput(&out, injectionWarningMsg{}); err == nil {
+		t.Error("Expected an injection warning to be an error without the TUI")
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing on stdout after an error, got %q", out.String())
+	}
+}
+
+func TestWidgetPrompt(t *testing.T) {
+	t.Setenv(widgetBufferEnv, "  list large files  ")
+	if got := widgetPrompt(options{prompt: "ignored"}); got != "list large files" {
+		t.Errorf("Expected the widget buffer to be the prompt, got %q", got)
+	}
+
+	t.Setenv(widgetBufferEnv, "")
+	if got := widgetPrompt(options{prompt: "show disk usage"}); got != "show disk usage" {
+		t.Errorf("Expected to fall back to the command-line prompt, got %q", got)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runWidget(initialModel("", false), &stdout, &stderr); code == 0 || stdout.Len() != 0 {
+		t.Error("Expected an empty prompt to fail without output")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// maxListedEntries caps how many of the working directory's entries are
+// named in the prompt
+const maxListedEntries = 50
+
+// workingDirInfo describes the working directory and what's in it, so file
+// commands can use names that exist. It's empty if the directory can't be
+// determined.
+func workingDirInfo() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	info := fmt.Sprintf("Working directory: %s\n", dir)
+	if listing := dirListing(dir); listing != "" {
+		info += listing + "\n"
+	}
+	return info
+}
+
+// dirListing names the entries in dir, sorted, with directories marked by a
+// trailing slash. Only the first maxListedEntries are named.
+func dirListing(dir string) string {
+	f, err := os.Open(dir)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	entries, _ := f.ReadDir(maxScannedEntries)
+	if len(entries) == 0 {
+		return "Directory contents: (empty)"
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	listing := "Directory contents: "
+	if len(names) > maxListedEntries {
+		more := fmt.Sprintf("%d", len(names)-maxListedEntries)
+		// A directory too big to read in full has more than we counted
+		if len(entries) == maxScannedEntries {
+			more += "+"
+		}
+		names = append(names[:maxListedEntries], "... and "+more+" more")
+	}
+	return listing + strings.Join(names, ", ")
+}
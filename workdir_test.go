@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvironmentInfoIncludesWorkingDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	cwd, _ := os.Getwd()
+	envInfo := getEnvironmentInfo(envOptions{})
+	if !strings.Contains(envInfo, "Working directory: "+cwd+"\n") {
+		t.Errorf("Expected the working directory in %q", envInfo)
+	}
+	if !strings.Contains(envInfo, "Directory contents: notes.txt, src/\n") {
+		t.Errorf("Expected the directory's entries in %q", envInfo)
+	}
+}
+
+func TestDirListingIsBounded(t *testing.T) {
+	dir := t.TempDir()
+	for i := range maxListedEntries + 5 {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%03d", i)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	listing := dirListing(dir)
+	if strings.Count(listing, "file") != maxListedEntries {
+		t.Errorf("Expected only %d entries to be named, got %q", maxListedEntries, listing)
+	}
+	if !strings.HasPrefix(listing, "Directory contents: file000, file001") || !strings.HasSuffix(listing, "... and 5 more") {
+		t.Errorf("Expected the first entries in order and a count of the rest, got %q", listing)
+	}
+}
+
+func TestDirListingEmpty(t *testing.T) {
+	if got := dirListing(t.TempDir()); got != "Directory contents: (empty)" {
+		t.Errorf("Expected an empty directory to say so, got %q", got)
+	}
+	if got := dirListing(filepath.Join(t.TempDir(), "missing")); got != "" {
+		t.Errorf("Expected nothing for an unreadable directory, got %q", got)
+	}
+}